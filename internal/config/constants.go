@@ -22,6 +22,10 @@ var (
 	LyraChainID            = big.NewInt(957)
 	LyraSepoliaChainID     = big.NewInt(902)
 	Ancient8SepoliaChainID = big.NewInt(28122024)
+	ScrollChainID          = big.NewInt(534352)
+	ScrollSepoliaChainID   = big.NewInt(534351)
+	LineaChainID           = big.NewInt(59144)
+	LineaSepoliaChainID    = big.NewInt(59141)
 
 	OpStackChains = mapset.NewSet(
 		OptimismChainID.Uint64(),
@@ -40,4 +44,14 @@ var (
 		ArbitrumGoerliChainID.Uint64(),
 		ArbitrumSepoliaChainID.Uint64(),
 	)
+
+	ScrollChains = mapset.NewSet(
+		ScrollChainID.Uint64(),
+		ScrollSepoliaChainID.Uint64(),
+	)
+
+	LineaChains = mapset.NewSet(
+		LineaChainID.Uint64(),
+		LineaSepoliaChainID.Uint64(),
+	)
 )
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+)
+
+// bundleFeeStrategyKey returns the variable that sets the fee strategy for a bundler mode. This falls back to
+// erc4337_bundler_bundle_fee_strategy if the mode specific variable is not set.
+func bundleFeeStrategyKey(mode string) string {
+	key := fmt.Sprintf("erc4337_bundler_%s_fee_strategy", mode)
+	if variableNotSetOrIsNil(key) {
+		return "erc4337_bundler_bundle_fee_strategy"
+	}
+	return key
+}
+
+func newBundleFeeStrategy(mode string, maxFee string, percentile float64) transaction.FeeStrategy {
+	key := bundleFeeStrategyKey(mode)
+	name := viper.GetString(key)
+	switch name {
+	case "mean":
+		return transaction.MeanFeeStrategy()
+	case "min_profitable":
+		return transaction.MinProfitableFeeStrategy()
+	case "network":
+		if maxFee == "" {
+			return transaction.NetworkFeeStrategy(nil)
+		}
+		mf, ok := big.NewInt(0).SetString(maxFee, 10)
+		if !ok {
+			panic(fmt.Sprintf("Fatal config error: invalid erc4337_bundler_bundle_fee_max_fee %s", maxFee))
+		}
+		return transaction.NetworkFeeStrategy(mf)
+	case "percentile":
+		if percentile < 0 || percentile > 100 {
+			panic("Fatal config error: erc4337_bundler_bundle_fee_percentile must be between 0 and 100")
+		}
+		return transaction.PercentileFeeStrategy(percentile)
+	default:
+		panic(fmt.Sprintf("Fatal config error: unknown %s %s", key, name))
+	}
+}
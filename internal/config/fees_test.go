@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// TestBundleFeeStrategyKey sets a fee strategy for the searcher mode only. Expect the searcher mode to use
+// its own variable and the private mode to fall back to erc4337_bundler_bundle_fee_strategy.
+func TestBundleFeeStrategyKey(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("erc4337_bundler_bundle_fee_strategy", "mean")
+	viper.Set("erc4337_bundler_searcher_fee_strategy", "min_profitable")
+
+	if key := bundleFeeStrategyKey("searcher"); key != "erc4337_bundler_searcher_fee_strategy" {
+		t.Fatalf("got %s, want erc4337_bundler_searcher_fee_strategy", key)
+	}
+	if key := bundleFeeStrategyKey("private"); key != "erc4337_bundler_bundle_fee_strategy" {
+		t.Fatalf("got %s, want erc4337_bundler_bundle_fee_strategy", key)
+	}
+}
+
+// TestNewBundleFeeStrategyUnknown sets an unknown fee strategy for the private mode. Expect a panic.
+func TestNewBundleFeeStrategyUnknown(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("erc4337_bundler_bundle_fee_strategy", "mean")
+	viper.Set("erc4337_bundler_private_fee_strategy", "unknown")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("got nil, want panic")
+		}
+	}()
+	newBundleFeeStrategy("private", "", 50)
+}
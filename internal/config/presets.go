@@ -0,0 +1,218 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+)
+
+const (
+	envPrefix     = "erc4337_bundler_"
+	chainsKey     = "chains"
+	reputationKey = "reputation"
+)
+
+type presetValidator = func(val any) (string, error)
+
+func validateAddresses(val any) (string, error) {
+	var addrs []string
+	switch v := val.(type) {
+	case []any:
+		for _, a := range v {
+			addrs = append(addrs, fmt.Sprint(a))
+		}
+	case string:
+		addrs = strings.Split(v, ",")
+	default:
+		return "", fmt.Errorf("expected a list of addresses, got %v", val)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("expected at least one address")
+	}
+
+	for i, a := range addrs {
+		addrs[i] = strings.TrimSpace(a)
+		if !common.IsHexAddress(addrs[i]) {
+			return "", fmt.Errorf("invalid address %s", addrs[i])
+		}
+	}
+	return strings.Join(addrs, ","), nil
+}
+
+func validateUint(val any) (string, error) {
+	s := fmt.Sprint(val)
+	if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		return "", fmt.Errorf("expected an unsigned integer, got %v", val)
+	}
+	return s, nil
+}
+
+func validateBool(val any) (string, error) {
+	b, err := strconv.ParseBool(fmt.Sprint(val))
+	if err != nil {
+		return "", fmt.Errorf("expected a boolean, got %v", val)
+	}
+	return strconv.FormatBool(b), nil
+}
+
+func validateString(val any) (string, error) {
+	s, ok := val.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("expected a non-empty string, got %v", val)
+	}
+	return s, nil
+}
+
+func validatePVGCalculator(val any) (string, error) {
+	s, err := validateString(val)
+	if err != nil {
+		return "", err
+	}
+
+	names := append(NewPVGRegistry().Names(), gas.NoPVGCalculator)
+	if !mapset.NewSet(names...).Contains(s) {
+		return "", fmt.Errorf("unknown calculator %s, expected one of %v", s, names)
+	}
+	return s, nil
+}
+
+// presetValidators maps each key allowed in a chain preset to a function that validates it and returns the
+// value in the same format as its env var. Keys under reputation map to the env var of the same name.
+var presetValidators = map[string]presetValidator{
+	"supported_entry_points":          validateAddresses,
+	"pvg_calculator":                  validatePVGCalculator,
+	"max_verification_gas":            validateUint,
+	"max_batch_gas_limit":             validateUint,
+	"native_bundler_collector_tracer": validateString,
+	"native_bundler_executor_tracer":  validateString,
+	"is_rip7212_supported":            validateBool,
+
+	reputationKey + ".min_unstake_delay":                  validateUint,
+	reputationKey + ".min_stake_value":                    validateUint,
+	reputationKey + ".same_sender_mempool_count":          validateUint,
+	reputationKey + ".same_unstaked_entity_mempool_count": validateUint,
+	reputationKey + ".throttled_entity_mempool_count":     validateUint,
+	reputationKey + ".throttled_entity_live_blocks":       validateUint,
+	reputationKey + ".throttled_entity_bundle_count":      validateUint,
+	reputationKey + ".min_inclusion_rate_denominator":     validateUint,
+	reputationKey + ".throttling_slack":                   validateUint,
+	reputationKey + ".ban_slack":                          validateUint,
+}
+
+// ChainPreset is a set of env var values keyed by their full env var name.
+type ChainPreset map[string]string
+
+// ChainPresets maps a chain ID to its preset.
+type ChainPresets map[uint64]ChainPreset
+
+// LoadChainPresets reads and validates a YAML, TOML, or JSON file of per chain presets. The format is
+// inferred from the file extension. Presets are listed under a top level chains key by chain ID:
+//
+//	chains:
+//	  10:
+//	    supported_entry_points: ["0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"]
+//	    pvg_calculator: optimism
+//	    reputation:
+//	      same_sender_mempool_count: 4
+func LoadChainPresets(path string) (ChainPresets, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	chains := v.GetStringMap(chainsKey)
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("presets: no chains defined in %s", path)
+	}
+	for _, key := range v.AllKeys() {
+		if !strings.HasPrefix(key, chainsKey+".") {
+			return nil, fmt.Errorf("presets: unknown key %s", key)
+		}
+	}
+
+	presets := ChainPresets{}
+	for id := range chains {
+		chainID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("presets: invalid chain ID %s", id)
+		}
+
+		preset := ChainPreset{}
+		sub := v.Sub(chainsKey + "." + id)
+		if sub == nil {
+			return nil, fmt.Errorf("presets: chain %s is empty", id)
+		}
+		for _, key := range sub.AllKeys() {
+			validate, ok := presetValidators[key]
+			if !ok {
+				return nil, fmt.Errorf("presets: chain %s: unknown key %s", id, key)
+			}
+
+			val, err := validate(sub.Get(key))
+			if err != nil {
+				return nil, fmt.Errorf("presets: chain %s: %s: %w", id, key, err)
+			}
+			preset[envPrefix+strings.TrimPrefix(key, reputationKey+".")] = val
+		}
+		presets[chainID] = preset
+	}
+
+	return presets, nil
+}
+
+// isSetByUser returns true if the variable was set by an env var or .env file.
+func isSetByUser(env string) bool {
+	_, ok := os.LookupEnv(strings.ToUpper(env))
+	return ok || viper.InConfig(env)
+}
+
+// Apply sets the preset values for the given chain in viper. Variables that have been set by an env var or
+// .env file take precedence and are not changed. Returns false if there is no preset for the chain.
+func (p ChainPresets) Apply(chainID uint64) bool {
+	preset, ok := p[chainID]
+	if !ok {
+		return false
+	}
+
+	for env, val := range preset {
+		if !isSetByUser(env) {
+			viper.Set(env, val)
+		}
+	}
+	return true
+}
+
+// rpcHost returns the host of an RPC URL so that it can be used in errors without leaking API keys that are
+// commonly part of the path or query.
+func rpcHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "eth client"
+	}
+	return u.Host
+}
+
+func getChainID(rawURL string) (*big.Int, error) {
+	eth, err := ethclient.Dial(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("presets: cannot connect to %s: %w", rpcHost(rawURL), err)
+	}
+	defer eth.Close()
+
+	chain, err := eth.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("presets: cannot get chain ID from %s: %w", rpcHost(rawURL), err)
+	}
+	return chain, nil
+}
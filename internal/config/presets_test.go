@@ -0,0 +1,149 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const yamlPresets = `
+chains:
+  10:
+    supported_entry_points:
+      - "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"
+    pvg_calculator: optimism
+    max_verification_gas: 6000000
+    is_rip7212_supported: true
+    reputation:
+      same_sender_mempool_count: 8
+`
+
+const tomlPresets = `
+[chains.42161]
+pvg_calculator = "arbitrum"
+max_batch_gas_limit = 25000000
+native_bundler_collector_tracer = "bundlerCollectorTracer"
+`
+
+func writePresets(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadChainPresetsYAML calls config.LoadChainPresets with a valid YAML file. Expect values to be keyed
+// by their env var name with reputation values flattened.
+func TestLoadChainPresetsYAML(t *testing.T) {
+	presets, err := LoadChainPresets(writePresets(t, "presets.yaml", yamlPresets))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	want := ChainPreset{
+		"erc4337_bundler_supported_entry_points":    "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789",
+		"erc4337_bundler_pvg_calculator":            "optimism",
+		"erc4337_bundler_max_verification_gas":      "6000000",
+		"erc4337_bundler_is_rip7212_supported":      "true",
+		"erc4337_bundler_same_sender_mempool_count": "8",
+	}
+	got := presets[OptimismChainID.Uint64()]
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for env, val := range want {
+		if got[env] != val {
+			t.Fatalf("%s: got %s, want %s", env, got[env], val)
+		}
+	}
+}
+
+// TestLoadChainPresetsTOML calls config.LoadChainPresets with a valid TOML file. Expect the preset to be
+// loaded for the chain.
+func TestLoadChainPresetsTOML(t *testing.T) {
+	presets, err := LoadChainPresets(writePresets(t, "presets.toml", tomlPresets))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	got := presets[ArbitrumOneChainID.Uint64()]
+	if got["erc4337_bundler_max_batch_gas_limit"] != "25000000" {
+		t.Fatalf("got %s, want 25000000", got["erc4337_bundler_max_batch_gas_limit"])
+	}
+}
+
+// TestLoadChainPresetsInvalid calls config.LoadChainPresets with invalid files. Expect an error naming the
+// offending key.
+func TestLoadChainPresetsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown_key":     "chains:\n  10:\n    max_gas: 1\n",
+		"bad_address":     "chains:\n  10:\n    supported_entry_points: [\"0x1234\"]\n",
+		"bad_uint":        "chains:\n  10:\n    max_batch_gas_limit: -1\n",
+		"bad_calculator":  "chains:\n  10:\n    pvg_calculator: polygon\n",
+		"bad_chain_id":    "chains:\n  optimism:\n    pvg_calculator: optimism\n",
+		"unknown_section": "chains:\n  10:\n    pvg_calculator: optimism\nmempool:\n  size: 1\n",
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadChainPresets(writePresets(t, "presets.yaml", data)); err == nil {
+				t.Fatal("got nil, want err")
+			} else if !strings.HasPrefix(err.Error(), "presets:") {
+				t.Fatalf("got err %v, want presets error", err)
+			}
+		})
+	}
+}
+
+// TestChainPresetsApplyEnvOverride calls config.ChainPresets.Apply with a value also set by an env var.
+// Expect the env var to take precedence over the preset.
+func TestChainPresetsApplyEnvOverride(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	t.Setenv("ERC4337_BUNDLER_MAX_VERIFICATION_GAS", "3000000")
+	_ = viper.BindEnv("erc4337_bundler_max_verification_gas")
+	_ = viper.BindEnv("erc4337_bundler_pvg_calculator")
+
+	presets, err := LoadChainPresets(writePresets(t, "presets.yaml", yamlPresets))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if presets.Apply(EthereumChainID.Uint64()) {
+		t.Fatal("got true for chain without preset, want false")
+	}
+	if !presets.Apply(OptimismChainID.Uint64()) {
+		t.Fatal("got false for chain with preset, want true")
+	}
+
+	if got := viper.GetInt("erc4337_bundler_max_verification_gas"); got != 3000000 {
+		t.Fatalf("max verification gas: got %d, want 3000000", got)
+	}
+	if got := viper.GetString("erc4337_bundler_pvg_calculator"); got != OptimismPVGCalculator {
+		t.Fatalf("pvg calculator: got %s, want %s", got, OptimismPVGCalculator)
+	}
+}
+
+// TestGetChainIDError calls getChainID with a node that fails the request. Expect the error to name the host
+// of the RPC URL without its API key.
+func TestGetChainIDError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := getChainID(srv.URL + "/v1/secret-key")
+	if err == nil {
+		t.Fatal("got nil, want err")
+	}
+	if host := strings.TrimPrefix(srv.URL, "http://"); !strings.Contains(err.Error(), host) {
+		t.Fatalf("got %v, want error naming %s", err, host)
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Fatalf("got %v, want error without API key", err)
+	}
+}
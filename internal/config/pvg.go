@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+)
+
+// Names of the chain specific PVG calculators that can be set with erc4337_bundler_pvg_calculator.
+const (
+	ArbitrumPVGCalculator = "arbitrum"
+	OptimismPVGCalculator = "optimism"
+	ScrollPVGCalculator   = "scroll"
+	LineaPVGCalculator    = "linea"
+)
+
+// NewPVGRegistry returns a registry of all supported PVG calculators with their default chain IDs.
+func NewPVGRegistry() *gas.PVGRegistry {
+	r := gas.NewPVGRegistry()
+	r.Register(ArbitrumPVGCalculator, gas.ArbitrumPVGCalculator(), ArbStackChains.ToSlice()...)
+	r.Register(OptimismPVGCalculator, gas.OptimismPVGCalculator(), OpStackChains.ToSlice()...)
+	r.Register(ScrollPVGCalculator, gas.ScrollPVGCalculator(), ScrollChains.ToSlice()...)
+	r.Register(LineaPVGCalculator, gas.LineaPVGCalculator(), LineaChains.ToSlice()...)
+	return r
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+)
+
+// parseRateLimit parses a limit in the format "rate[:burst]" where rate is in requests per second. If burst
+// is not set, it defaults to the rate rounded up.
+func parseRateLimit(s string) (tenant.Limit, error) {
+	rateStr, burstStr, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil || rate < 0 {
+		return tenant.Limit{}, fmt.Errorf("invalid rate %s", rateStr)
+	}
+
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst < 1 {
+			return tenant.Limit{}, fmt.Errorf("invalid burst %s", burstStr)
+		}
+	}
+	return tenant.Limit{Rate: rate, Burst: burst}, nil
+}
+
+func newClientRateLimit(limit string) tenant.Limit {
+	if limit == "" {
+		return tenant.Limit{}
+	}
+
+	l, err := parseRateLimit(limit)
+	if err != nil {
+		panic(fmt.Sprintf("Fatal config error: erc4337_bundler_rpc_rate_limit: %s", err))
+	}
+	return l
+}
+
+// newMethodRateLimits parses limits in the format "method=rate[:burst]&method=rate[:burst]".
+func newMethodRateLimits(limits string) map[string]tenant.Limit {
+	out := map[string]tenant.Limit{}
+	if limits == "" {
+		return out
+	}
+
+	for method, limit := range envKeyValStringToMap(limits) {
+		l, err := parseRateLimit(limit)
+		if err != nil {
+			panic(fmt.Sprintf("Fatal config error: erc4337_bundler_rpc_method_rate_limits: %s: %s", method, err))
+		}
+		out[method] = l
+	}
+	return out
+}
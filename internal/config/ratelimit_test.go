@@ -0,0 +1,19 @@
+package config
+
+import "testing"
+
+// TestParseRateLimit calls config.parseRateLimit with valid and invalid limits. Expect burst to default to
+// the rate rounded up.
+func TestParseRateLimit(t *testing.T) {
+	if l, err := parseRateLimit("2.5"); err != nil || l.Rate != 2.5 || l.Burst != 3 {
+		t.Fatalf("got %+v, %v, want rate 2.5 and burst 3", l, err)
+	}
+	if l, err := parseRateLimit("5:20"); err != nil || l.Rate != 5 || l.Burst != 20 {
+		t.Fatalf("got %+v, %v, want rate 5 and burst 20", l, err)
+	}
+	for _, s := range []string{"", "-1", "abc", "5:0", "5:x"} {
+		if _, err := parseRateLimit(s); err == nil {
+			t.Fatalf("%q: got nil, want err", s)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+)
+
+const tenantsKey = "tenants"
+
+func toStrings(val any) ([]string, error) {
+	var out []string
+	switch v := val.(type) {
+	case []any:
+		for _, s := range v {
+			out = append(out, strings.TrimSpace(fmt.Sprint(s)))
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			out = append(out, strings.TrimSpace(s))
+		}
+	default:
+		return nil, fmt.Errorf("expected a list of strings, got %v", val)
+	}
+
+	for _, s := range out {
+		if s == "" {
+			return nil, fmt.Errorf("expected non-empty strings, got %v", val)
+		}
+	}
+	return out, nil
+}
+
+func toAddressSet(val any) (mapset.Set[common.Address], error) {
+	addrs, err := validateAddresses(val)
+	if err != nil {
+		return nil, err
+	}
+
+	set := mapset.NewSet[common.Address]()
+	for _, a := range strings.Split(addrs, ",") {
+		set.Add(common.HexToAddress(a))
+	}
+	return set, nil
+}
+
+// parseTenantMethodRateLimits parses a list of limits in the format "method=rate[:burst]". Method names are
+// values rather than keys since viper lowercases keys and splits them on dots.
+func parseTenantMethodRateLimits(t *tenant.Tenant, val any) error {
+	limits, err := toStrings(val)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range limits {
+		method, limit, ok := strings.Cut(l, "=")
+		if !ok {
+			return fmt.Errorf("expected method=rate[:burst], got %s", l)
+		}
+		if t.MethodRateLimits[method], err = parseRateLimit(limit); err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// parseTenant sets a single tenant field from the tenants file and returns any API keys it defines.
+func parseTenant(t *tenant.Tenant, key string, val any) ([]string, error) {
+	var err error
+	switch key {
+	case "api_keys":
+		return toStrings(val)
+	case "allowed_methods":
+		t.AllowedMethods, err = toStrings(val)
+	case "allowed_entry_points":
+		t.AllowedEntryPoints, err = toAddressSet(val)
+	case "allowed_senders":
+		t.AllowedSenders, err = toAddressSet(val)
+	case "allowed_paymasters":
+		t.AllowedPaymasters, err = toAddressSet(val)
+	case "rate_limit":
+		t.RateLimit, err = parseRateLimit(fmt.Sprint(val))
+	case "method_rate_limits":
+		err = parseTenantMethodRateLimits(t, val)
+	default:
+		err = fmt.Errorf("unknown key")
+	}
+	return nil, err
+}
+
+// LoadTenants reads and validates a YAML, TOML, or JSON file of API key tenants. The format is inferred from
+// the file extension. Tenants are listed under a top level tenants key by ID. Since viper lowercases keys,
+// tenant IDs are always lowercase:
+//
+//	tenants:
+//	  acme:
+//	    api_keys: ["key1", "key2"]
+//	    allowed_methods: ["eth_*"]
+//	    allowed_entry_points: ["0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"]
+//	    allowed_paymasters: ["0x..."]
+//	    rate_limit: "10:20"
+//	    method_rate_limits: ["eth_sendUserOperation=1:5"]
+//
+// Tenants without a rate_limit use the given client limit. Method limits not set for a tenant use the given
+// method limits.
+func LoadTenants(
+	path string,
+	clientLimit tenant.Limit,
+	methodLimits map[string]tenant.Limit,
+) (*tenant.Directory, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	tenants := v.GetStringMap(tenantsKey)
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("tenants: no tenants defined in %s", path)
+	}
+	for _, key := range v.AllKeys() {
+		if !strings.HasPrefix(key, tenantsKey+".") {
+			return nil, fmt.Errorf("tenants: unknown key %s", key)
+		}
+	}
+
+	dir := tenant.NewDirectory()
+	for id := range tenants {
+		sub := v.Sub(tenantsKey + "." + id)
+		if sub == nil {
+			return nil, fmt.Errorf("tenants: %s is empty", id)
+		}
+
+		t := tenant.New(id)
+		t.RateLimit = clientLimit
+		for method, limit := range methodLimits {
+			t.MethodRateLimits[method] = limit
+		}
+
+		var apiKeys []string
+		for _, key := range sub.AllKeys() {
+			keys, err := parseTenant(t, key, sub.Get(key))
+			if err != nil {
+				return nil, fmt.Errorf("tenants: %s: %s: %w", id, key, err)
+			}
+			apiKeys = append(apiKeys, keys...)
+		}
+		if err := dir.Add(t, apiKeys...); err != nil {
+			return nil, err
+		}
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+)
+
+const yamlTenants = `
+tenants:
+  acme:
+    api_keys: ["key1", "key2"]
+    allowed_methods: ["eth_*"]
+    allowed_entry_points:
+      - "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"
+    rate_limit: "10:20"
+    method_rate_limits: ["eth_sendUserOperation=1:5"]
+  other:
+    api_keys: ["key3"]
+`
+
+// TestLoadTenants calls config.LoadTenants with a valid YAML file. Expect tenants to be keyed by API key with
+// unset rate limits falling back to the defaults.
+func TestLoadTenants(t *testing.T) {
+	defaultLimit := tenant.Limit{Rate: 1, Burst: 1}
+	dir, err := LoadTenants(
+		writePresets(t, "tenants.yaml", yamlTenants),
+		defaultLimit,
+		map[string]tenant.Limit{"eth_estimateUserOperationGas": {Rate: 2, Burst: 2}},
+	)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	acme := dir.FromAPIKey("key2")
+	if acme == nil || acme.ID != "acme" {
+		t.Fatalf("got %v, want acme", acme)
+	}
+	if acme.IsMethodAllowed("debug_bundler_clearState") {
+		t.Fatal("got debug method allowed, want not allowed")
+	}
+	if !acme.IsEntryPointAllowed(common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")) {
+		t.Fatal("got EntryPoint not allowed, want allowed")
+	}
+	if acme.RateLimit != (tenant.Limit{Rate: 10, Burst: 20}) {
+		t.Fatalf("got rate limit %v, want 10:20", acme.RateLimit)
+	}
+	if l := acme.MethodRateLimits["eth_sendUserOperation"]; l != (tenant.Limit{Rate: 1, Burst: 5}) {
+		t.Fatalf("got method rate limit %v, want 1:5", l)
+	}
+	if _, ok := acme.MethodRateLimits["eth_estimateUserOperationGas"]; !ok {
+		t.Fatal("got no default method rate limit, want 2:2")
+	}
+
+	other := dir.FromAPIKey("key3")
+	if other == nil || other.RateLimit != defaultLimit || !other.IsMethodAllowed("debug_bundler_clearState") {
+		t.Fatalf("got %v, want other with default policies", other)
+	}
+}
+
+// TestLoadTenantsInvalid calls config.LoadTenants with invalid files. Expect an error for each.
+func TestLoadTenantsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":          "tenants:\n  acme:\n    api_keys: [key1]\n    foo: bar\n",
+		"invalid address":      "tenants:\n  acme:\n    api_keys: [key1]\n    allowed_senders: [\"0x1\"]\n",
+		"invalid limit":        "tenants:\n  acme:\n    api_keys: [key1]\n    rate_limit: fast\n",
+		"no keys":              "tenants:\n  acme:\n    allowed_methods: [eth_chainId]\n",
+		"duplicate key":        "tenants:\n  a:\n    api_keys: [key1]\n  b:\n    api_keys: [key1]\n",
+		"unknown top key":      "tenants:\n  acme:\n    api_keys: [key1]\nfoo: bar\n",
+		"no tenants":           "foo: bar\n",
+		"invalid method limit": "tenants:\n  acme:\n    api_keys: [key1]\n    method_rate_limits: [eth_chainId]\n",
+	}
+	for name, data := range cases {
+		if _, err := LoadTenants(writePresets(t, "tenants.yaml", data), tenant.Limit{}, nil); err == nil {
+			t.Errorf("%s: got nil, want err", name)
+		}
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
 )
 
 type Values struct {
@@ -24,10 +26,22 @@ type Values struct {
 	MaxBatchGasLimit             *big.Int
 	MaxOpTTL                     time.Duration
 	OpLookupLimit                uint64
+	MaxMempoolOps                int
+	MaxMempoolOpsPerSender       int
+	MaxMempoolBytes              int64
 	Beneficiary                  string
 	NativeBundlerCollectorTracer string
 	NativeBundlerExecutorTracer  string
+	LocalValidationTracing       bool
 	ReputationConstants          *entities.ReputationConstants
+	PrivateBundleFeeStrategy     transaction.FeeStrategy
+	SearcherBundleFeeStrategy    transaction.FeeStrategy
+	RPCMaxBatchConcurrency       int
+	RPCClientRateLimit           tenant.Limit
+	RPCMethodRateLimits          map[string]tenant.Limit
+	RPCAPIKeyHeader              string
+	Tenants                      *tenant.Directory
+	PolicyFile                   string
 
 	// Searcher mode variables.
 	EthBuilderUrls    []string
@@ -47,6 +61,9 @@ type Values struct {
 	IsOpStackNetwork   bool
 	IsRIP7212Supported bool
 	IsArbStackNetwork  bool
+	PVGCalculator      string
+	AmortizePVG        bool
+	MaxPVGShortfall    uint64
 
 	// Undocumented variables.
 	DebugMode bool
@@ -97,11 +114,21 @@ func GetValues() *Values {
 	viper.SetDefault("erc4337_bundler_max_batch_gas_limit", 18000000)
 	viper.SetDefault("erc4337_bundler_max_op_ttl_seconds", 180)
 	viper.SetDefault("erc4337_bundler_op_lookup_limit", 2000)
+	viper.SetDefault("erc4337_bundler_max_mempool_ops", 10000)
+	viper.SetDefault("erc4337_bundler_max_mempool_ops_per_sender", 0)
+	viper.SetDefault("erc4337_bundler_max_mempool_bytes", 0)
+	viper.SetDefault("erc4337_bundler_bundle_fee_strategy", "mean")
+	viper.SetDefault("erc4337_bundler_bundle_fee_percentile", 50)
+	viper.SetDefault("erc4337_bundler_rpc_max_batch_concurrency", 1)
+	viper.SetDefault("erc4337_bundler_rpc_api_key_header", tenant.DefaultAPIKeyHeader)
 	viper.SetDefault("erc4337_bundler_blocks_in_the_future", 6)
 	viper.SetDefault("erc4337_bundler_otel_insecure_mode", false)
 	viper.SetDefault("erc4337_bundler_is_op_stack_network", false)
 	viper.SetDefault("erc4337_bundler_is_arb_stack_network", false)
+	viper.SetDefault("erc4337_bundler_amortize_pvg", false)
+	viper.SetDefault("erc4337_bundler_max_pvg_shortfall_blocks", 10)
 	viper.SetDefault("erc4337_bundler_is_rip7212_supported", false)
+	viper.SetDefault("erc4337_bundler_local_validation_tracing", false)
 	viper.SetDefault("erc4337_bundler_debug_mode", false)
 	viper.SetDefault("erc4337_bundler_gin_mode", gin.ReleaseMode)
 
@@ -127,10 +154,25 @@ func GetValues() *Values {
 	_ = viper.BindEnv("erc4337_bundler_beneficiary")
 	_ = viper.BindEnv("erc4337_bundler_native_bundler_collector_tracer")
 	_ = viper.BindEnv("erc4337_bundler_native_bundler_executor_tracer")
+	_ = viper.BindEnv("erc4337_bundler_local_validation_tracing")
 	_ = viper.BindEnv("erc4337_bundler_max_verification_gas")
 	_ = viper.BindEnv("erc4337_bundler_max_batch_gas_limit")
 	_ = viper.BindEnv("erc4337_bundler_max_op_ttl_seconds")
 	_ = viper.BindEnv("erc4337_bundler_op_lookup_limit")
+	_ = viper.BindEnv("erc4337_bundler_max_mempool_ops")
+	_ = viper.BindEnv("erc4337_bundler_max_mempool_ops_per_sender")
+	_ = viper.BindEnv("erc4337_bundler_max_mempool_bytes")
+	_ = viper.BindEnv("erc4337_bundler_bundle_fee_strategy")
+	_ = viper.BindEnv("erc4337_bundler_private_fee_strategy")
+	_ = viper.BindEnv("erc4337_bundler_searcher_fee_strategy")
+	_ = viper.BindEnv("erc4337_bundler_bundle_fee_max_fee")
+	_ = viper.BindEnv("erc4337_bundler_bundle_fee_percentile")
+	_ = viper.BindEnv("erc4337_bundler_rpc_max_batch_concurrency")
+	_ = viper.BindEnv("erc4337_bundler_rpc_rate_limit")
+	_ = viper.BindEnv("erc4337_bundler_rpc_method_rate_limits")
+	_ = viper.BindEnv("erc4337_bundler_rpc_api_key_header")
+	_ = viper.BindEnv("erc4337_bundler_tenants_file")
+	_ = viper.BindEnv("erc4337_bundler_policy_file")
 	_ = viper.BindEnv("erc4337_bundler_eth_builder_urls")
 	_ = viper.BindEnv("erc4337_bundler_blocks_in_the_future")
 	_ = viper.BindEnv("erc4337_bundler_otel_service_name")
@@ -142,6 +184,10 @@ func GetValues() *Values {
 	_ = viper.BindEnv("erc4337_bundler_is_op_stack_network")
 	_ = viper.BindEnv("erc4337_bundler_is_arb_stack_network")
 	_ = viper.BindEnv("erc4337_bundler_is_rip7212_supported")
+	_ = viper.BindEnv("erc4337_bundler_pvg_calculator")
+	_ = viper.BindEnv("erc4337_bundler_amortize_pvg")
+	_ = viper.BindEnv("erc4337_bundler_max_pvg_shortfall_blocks")
+	_ = viper.BindEnv("erc4337_bundler_config_file")
 	_ = viper.BindEnv("erc4337_bundler_debug_mode")
 	_ = viper.BindEnv("erc4337_bundler_gin_mode")
 
@@ -154,6 +200,21 @@ func GetValues() *Values {
 		panic("Fatal config error: erc4337_bundler_private_key not set")
 	}
 
+	// Apply chain presets with env vars taking precedence
+	if !variableNotSetOrIsNil("erc4337_bundler_config_file") {
+		file := viper.GetString("erc4337_bundler_config_file")
+		presets, err := LoadChainPresets(file)
+		if err != nil {
+			panic(fmt.Errorf("fatal config error: %w", err))
+		}
+
+		chain, err := getChainID(viper.GetString("erc4337_bundler_eth_client_url"))
+		if err != nil {
+			panic(fmt.Errorf("fatal config error: applying %s: %w", file, err))
+		}
+		presets.Apply(chain.Uint64())
+	}
+
 	if !viper.IsSet("erc4337_bundler_beneficiary") {
 		s, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
 		if err != nil {
@@ -181,6 +242,22 @@ func GetValues() *Values {
 		panic("Fatal config error: erc4337_bundler_alt_mempool_ids is set without specifying an IPFS gateway")
 	}
 
+	// Validate bundle fee strategy variables
+	privateBundleFeeStrategy := newBundleFeeStrategy(
+		"private",
+		viper.GetString("erc4337_bundler_bundle_fee_max_fee"),
+		viper.GetFloat64("erc4337_bundler_bundle_fee_percentile"),
+	)
+	searcherBundleFeeStrategy := newBundleFeeStrategy(
+		"searcher",
+		viper.GetString("erc4337_bundler_bundle_fee_max_fee"),
+		viper.GetFloat64("erc4337_bundler_bundle_fee_percentile"),
+	)
+
+	// Validate RPC rate limit variables
+	rpcClientRateLimit := newClientRateLimit(viper.GetString("erc4337_bundler_rpc_rate_limit"))
+	rpcMethodRateLimits := newMethodRateLimits(viper.GetString("erc4337_bundler_rpc_method_rate_limits"))
+
 	// Return Values
 	privateKey := viper.GetString("erc4337_bundler_private_key")
 	ethClientUrl := viper.GetString("erc4337_bundler_eth_client_url")
@@ -190,10 +267,29 @@ func GetValues() *Values {
 	beneficiary := viper.GetString("erc4337_bundler_beneficiary")
 	nativeBundlerCollectorTracer := viper.GetString("erc4337_bundler_native_bundler_collector_tracer")
 	nativeBundlerExecutorTracer := viper.GetString("erc4337_bundler_native_bundler_executor_tracer")
+	localValidationTracing := viper.GetBool("erc4337_bundler_local_validation_tracing")
 	maxVerificationGas := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_verification_gas")))
 	maxBatchGasLimit := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_batch_gas_limit")))
 	maxOpTTL := time.Second * viper.GetDuration("erc4337_bundler_max_op_ttl_seconds")
 	opLookupLimit := viper.GetUint64("erc4337_bundler_op_lookup_limit")
+	maxMempoolOps := viper.GetInt("erc4337_bundler_max_mempool_ops")
+	maxMempoolOpsPerSender := viper.GetInt("erc4337_bundler_max_mempool_ops_per_sender")
+	maxMempoolBytes := viper.GetInt64("erc4337_bundler_max_mempool_bytes")
+	rpcMaxBatchConcurrency := viper.GetInt("erc4337_bundler_rpc_max_batch_concurrency")
+	rpcAPIKeyHeader := viper.GetString("erc4337_bundler_rpc_api_key_header")
+	var tenants *tenant.Directory
+	if !variableNotSetOrIsNil("erc4337_bundler_tenants_file") {
+		dir, err := LoadTenants(
+			viper.GetString("erc4337_bundler_tenants_file"),
+			rpcClientRateLimit,
+			rpcMethodRateLimits,
+		)
+		if err != nil {
+			panic(fmt.Errorf("fatal config error: %w", err))
+		}
+		tenants = dir
+	}
+	policyFile := viper.GetString("erc4337_bundler_policy_file")
 	ethBuilderUrls := envArrayToStringSlice(viper.GetString("erc4337_bundler_eth_builder_urls"))
 	blocksInTheFuture := viper.GetInt("erc4337_bundler_blocks_in_the_future")
 	otelServiceName := viper.GetString("erc4337_bundler_otel_service_name")
@@ -205,6 +301,14 @@ func GetValues() *Values {
 	isOpStackNetwork := viper.GetBool("erc4337_bundler_is_op_stack_network")
 	isArbStackNetwork := viper.GetBool("erc4337_bundler_is_arb_stack_network")
 	isRIP7212Supported := viper.GetBool("erc4337_bundler_is_rip7212_supported")
+	pvgCalculator := viper.GetString("erc4337_bundler_pvg_calculator")
+	if pvgCalculator == "" && isOpStackNetwork {
+		pvgCalculator = OptimismPVGCalculator
+	} else if pvgCalculator == "" && isArbStackNetwork {
+		pvgCalculator = ArbitrumPVGCalculator
+	}
+	amortizePVG := viper.GetBool("erc4337_bundler_amortize_pvg")
+	maxPVGShortfall := viper.GetUint64("erc4337_bundler_max_pvg_shortfall_blocks")
 	debugMode := viper.GetBool("erc4337_bundler_debug_mode")
 	ginMode := viper.GetString("erc4337_bundler_gin_mode")
 	return &Values{
@@ -216,11 +320,23 @@ func GetValues() *Values {
 		Beneficiary:                  beneficiary,
 		NativeBundlerCollectorTracer: nativeBundlerCollectorTracer,
 		NativeBundlerExecutorTracer:  nativeBundlerExecutorTracer,
+		LocalValidationTracing:       localValidationTracing,
 		MaxVerificationGas:           maxVerificationGas,
 		MaxBatchGasLimit:             maxBatchGasLimit,
 		MaxOpTTL:                     maxOpTTL,
 		OpLookupLimit:                opLookupLimit,
+		MaxMempoolOps:                maxMempoolOps,
+		MaxMempoolOpsPerSender:       maxMempoolOpsPerSender,
+		MaxMempoolBytes:              maxMempoolBytes,
 		ReputationConstants:          NewReputationConstantsFromEnv(),
+		PrivateBundleFeeStrategy:     privateBundleFeeStrategy,
+		SearcherBundleFeeStrategy:    searcherBundleFeeStrategy,
+		RPCMaxBatchConcurrency:       rpcMaxBatchConcurrency,
+		RPCClientRateLimit:           rpcClientRateLimit,
+		RPCMethodRateLimits:          rpcMethodRateLimits,
+		RPCAPIKeyHeader:              rpcAPIKeyHeader,
+		Tenants:                      tenants,
+		PolicyFile:                   policyFile,
 		EthBuilderUrls:               ethBuilderUrls,
 		BlocksInTheFuture:            blocksInTheFuture,
 		OTELServiceName:              otelServiceName,
@@ -232,6 +348,9 @@ func GetValues() *Values {
 		IsOpStackNetwork:             isOpStackNetwork,
 		IsArbStackNetwork:            isArbStackNetwork,
 		IsRIP7212Supported:           isRIP7212Supported,
+		PVGCalculator:                pvgCalculator,
+		AmortizePVG:                  amortizePVG,
+		MaxPVGShortfall:              maxPVGShortfall,
 		DebugMode:                    debugMode,
 		GinMode:                      ginMode,
 	}
@@ -0,0 +1,148 @@
+package e2e
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Account is a SimpleAccount owned by an EOA and created through the Harness factory.
+type Account struct {
+	Owner   *signer.EOA
+	Salt    *big.Int
+	Address common.Address
+
+	h *Harness
+}
+
+// NewAccount returns a counterfactual SimpleAccount with a random owner. The account is not deployed until
+// its first UserOperation is included.
+func (h *Harness) NewAccount() *Account {
+	h.t.Helper()
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	owner, err := signer.New(hexutil.Encode(crypto.FromECDSA(pk))[2:])
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	salt := big.NewInt(0)
+	factory := bind.NewBoundContract(h.Factory, factoryABI, h.Sim, h.Sim, h.Sim)
+	out := []any{}
+	if err := factory.Call(nil, &out, "getAddress", owner.Address, salt); err != nil {
+		h.t.Fatal(err)
+	}
+	return &Account{Owner: owner, Salt: salt, Address: out[0].(common.Address), h: h}
+}
+
+// InitCode returns the initCode that deploys the account through the factory.
+func (a *Account) InitCode() []byte {
+	a.h.t.Helper()
+	data, err := factoryABI.Pack("createAccount", a.Owner.Address, a.Salt)
+	if err != nil {
+		a.h.t.Fatal(err)
+	}
+	return append(a.h.Factory.Bytes(), data...)
+}
+
+// IsDeployed returns true if the account has code on chain.
+func (a *Account) IsDeployed() bool {
+	a.h.t.Helper()
+	code, err := a.h.Sim.CodeAt(context.Background(), a.Address, nil)
+	if err != nil {
+		a.h.t.Fatal(err)
+	}
+	return len(code) > 0
+}
+
+// UserOp returns an unsigned UserOperation for the account with the given callData and fees. The initCode
+// is set if the account has not been deployed.
+func (a *Account) UserOp(nonce *big.Int, callData []byte, maxFee, tip *big.Int) *userop.UserOperation {
+	initCode := []byte{}
+	verificationGas := big.NewInt(100000)
+	if !a.IsDeployed() {
+		initCode = a.InitCode()
+		verificationGas = big.NewInt(500000)
+	}
+
+	op := &userop.UserOperation{
+		Sender:               a.Address,
+		Nonce:                nonce,
+		InitCode:             initCode,
+		CallData:             callData,
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: verificationGas,
+		PreVerificationGas:   big.NewInt(0),
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: tip,
+		PaymasterAndData:     []byte{},
+		Signature:            a.dummySignature(),
+	}
+	pvg, err := a.h.overhead.CalcPreVerificationGasWithBuffer(op)
+	if err != nil {
+		a.h.t.Fatal(err)
+	}
+	op.PreVerificationGas = pvg
+	return op
+}
+
+func (a *Account) dummySignature() []byte {
+	sig := make([]byte, 65)
+	sig[64] = 27
+	return sig
+}
+
+// Sign sets the signature of a UserOperation as expected by SimpleAccount, which is an EIP-191 signature of
+// the userOpHash by the owner.
+func (a *Account) Sign(op *userop.UserOperation) {
+	a.h.t.Helper()
+	hash := op.GetUserOpHash(a.h.EntryPoint, ChainID)
+	sig, err := crypto.Sign(accounts.TextHash(hash.Bytes()), a.Owner.PrivateKey)
+	if err != nil {
+		a.h.t.Fatal(err)
+	}
+	sig[64] += 27
+	op.Signature = sig
+}
+
+// Sponsor sets the paymasterAndData of a UserOperation as expected by the VerifyingPaymaster, which is the
+// validity window followed by a signature of the paymaster hash by the harness EOA. PreVerificationGas is
+// recalculated, so the op must be signed by the account afterwards.
+func (h *Harness) Sponsor(op *userop.UserOperation, validUntil, validAfter uint64) {
+	h.t.Helper()
+	until, after := new(big.Int).SetUint64(validUntil), new(big.Int).SetUint64(validAfter)
+	window, err := abi.Arguments{{Type: uint48T}, {Type: uint48T}}.Pack(until, after)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	op.PaymasterAndData = append(append(h.Paymaster.Bytes(), window...), make([]byte, 65)...)
+	pvg, err := h.overhead.CalcPreVerificationGasWithBuffer(op)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	op.PreVerificationGas = pvg
+
+	pm := bind.NewBoundContract(h.Paymaster, paymasterABI, h.Sim, h.Sim, h.Sim)
+	out := []any{}
+	if err := pm.Call(nil, &out, "getHash", entrypoint.UserOperation(*op), until, after); err != nil {
+		h.t.Fatal(err)
+	}
+	hash := out[0].([32]byte)
+	sig, err := crypto.Sign(accounts.TextHash(hash[:]), h.EOA.PrivateKey)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	sig[64] += 27
+	op.PaymasterAndData = append(append(h.Paymaster.Bytes(), window...), sig...)
+}
@@ -0,0 +1,157 @@
+package e2e
+
+import (
+	"embed"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+const (
+	// Artifact names in the testdata directory holding the hex encoded creation bytecode of each contract.
+	// These are compiled from eth-infinitism/account-abstraction v0.6.0. See testdata/README.md.
+	EntryPointBin   = "entrypoint.bin"
+	FactoryBin      = "simpleaccountfactory.bin"
+	PaymasterBin    = "verifyingpaymaster.bin"
+	ArtifactsDirEnv = "ERC4337_BUNDLER_E2E_ARTIFACTS"
+)
+
+var (
+	//go:embed testdata
+	artifacts embed.FS
+
+	addressT, _ = abi.NewType("address", "", nil)
+	uint256T, _ = abi.NewType("uint256", "", nil)
+	uint48T, _  = abi.NewType("uint48", "", nil)
+	bytes32T, _ = abi.NewType("bytes32", "", nil)
+
+	// userOpT is the UserOperation tuple as declared by the EntryPoint.
+	userOpT = func() abi.Type {
+		ep, _ := entrypoint.EntrypointMetaData.GetAbi()
+		return ep.Methods["simulateValidation"].Inputs[0].Type
+	}()
+
+	factoryABI = abi.ABI{
+		Constructor: abi.NewMethod("", "", abi.Constructor, "", false, false, abi.Arguments{
+			{Name: "_entryPoint", Type: addressT},
+		}, nil),
+		Methods: map[string]abi.Method{
+			"createAccount": abi.NewMethod("createAccount", "createAccount", abi.Function, "", false, false,
+				abi.Arguments{{Name: "owner", Type: addressT}, {Name: "salt", Type: uint256T}},
+				abi.Arguments{{Name: "ret", Type: addressT}},
+			),
+			"getAddress": abi.NewMethod("getAddress", "getAddress", abi.Function, "view", false, false,
+				abi.Arguments{{Name: "owner", Type: addressT}, {Name: "salt", Type: uint256T}},
+				abi.Arguments{{Name: "", Type: addressT}},
+			),
+		},
+	}
+
+	paymasterABI = abi.ABI{
+		Constructor: abi.NewMethod("", "", abi.Constructor, "", false, false, abi.Arguments{
+			{Name: "_entryPoint", Type: addressT},
+			{Name: "_verifyingSigner", Type: addressT},
+		}, nil),
+		Methods: map[string]abi.Method{
+			"deposit": abi.NewMethod("deposit", "deposit", abi.Function, "payable", false, true, nil, nil),
+			"getHash": abi.NewMethod("getHash", "getHash", abi.Function, "view", false, false,
+				abi.Arguments{
+					{Name: "userOp", Type: userOpT},
+					{Name: "validUntil", Type: uint48T},
+					{Name: "validAfter", Type: uint48T},
+				},
+				abi.Arguments{{Name: "", Type: bytes32T}},
+			),
+		},
+	}
+)
+
+// ReadBytecode returns the creation bytecode from a hex encoded artifact embedded from the testdata directory.
+// The ERC4337_BUNDLER_E2E_ARTIFACTS environment variable can be set to read artifacts from another directory
+// instead.
+func ReadBytecode(name string) ([]byte, error) {
+	var b []byte
+	var err error
+	if dir := os.Getenv(ArtifactsDirEnv); dir != "" {
+		b, err = os.ReadFile(filepath.Join(dir, name))
+	} else {
+		b, err = artifacts.ReadFile(path.Join("testdata", name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	code := common.FromHex(strings.TrimSpace(string(b)))
+	if len(code) == 0 {
+		return nil, fmt.Errorf("e2e: %s has no bytecode", name)
+	}
+	return code, nil
+}
+
+func deploy(
+	sim *backends.SimulatedBackend,
+	auth *bind.TransactOpts,
+	bin string,
+	contract abi.ABI,
+	params ...any,
+) (common.Address, error) {
+	code, err := ReadBytecode(bin)
+	if err != nil {
+		return common.Address{}, err
+	}
+	addr, _, _, err := bind.DeployContract(auth, contract, code, sim, params...)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sim.Commit()
+	return addr, nil
+}
+
+// DeployEntryPoint deploys the EntryPoint and returns its address.
+func DeployEntryPoint(sim *backends.SimulatedBackend, auth *bind.TransactOpts) (common.Address, error) {
+	ep, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return deploy(sim, auth, EntryPointBin, *ep)
+}
+
+// DeployFactory deploys a SimpleAccountFactory for the EntryPoint and returns its address.
+func DeployFactory(
+	sim *backends.SimulatedBackend,
+	auth *bind.TransactOpts,
+	ep common.Address,
+) (common.Address, error) {
+	return deploy(sim, auth, FactoryBin, factoryABI, ep)
+}
+
+// DeployPaymaster deploys a VerifyingPaymaster for the EntryPoint, funds its deposit, and returns its address.
+func DeployPaymaster(
+	sim *backends.SimulatedBackend,
+	auth *bind.TransactOpts,
+	ep common.Address,
+	signer common.Address,
+	deposit *big.Int,
+) (common.Address, error) {
+	addr, err := deploy(sim, auth, PaymasterBin, paymasterABI, ep, signer)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	opts := *auth
+	opts.Value = deposit
+	pm := bind.NewBoundContract(addr, paymasterABI, sim, sim, sim)
+	if _, err := pm.Transact(&opts, "deposit"); err != nil {
+		return common.Address{}, err
+	}
+	sim.Commit()
+	return addr, nil
+}
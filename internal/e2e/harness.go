@@ -0,0 +1,229 @@
+// Package e2e provides an in-process harness for end-to-end tests of the bundler. It runs a simulated chain
+// with a deployed EntryPoint, SimpleAccountFactory, and VerifyingPaymaster, and wires a Client and Bundler
+// the same way as a private mode bundler.
+package e2e
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/internal/start"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// ChainID is the chain ID of the simulated backend.
+	ChainID = big.NewInt(1337)
+
+	// GasLimit is the block gas limit of the simulated chain.
+	GasLimit = uint64(30000000)
+
+	maxVerificationGas = big.NewInt(6000000)
+	maxBatchGasLimit   = big.NewInt(25000000)
+	opLookupLimit      = uint64(2000)
+	maxOpTTL           = 180 * time.Second
+	maxPVGShortfall    = uint64(10)
+	paymasterDeposit   = new(big.Int).Mul(big.NewInt(10), testutils.OneETH)
+	genesisBalance     = new(big.Int).Mul(big.NewInt(1000), testutils.OneETH)
+)
+
+// Harness is a bundler running in process against a simulated chain.
+type Harness struct {
+	Sim        *backends.SimulatedBackend
+	Rpc        *rpc.Client
+	EOA        *signer.EOA
+	EntryPoint common.Address
+	Factory    common.Address
+	Paymaster  common.Address
+	Mempool    *mempool.Mempool
+	Client     *client.Client
+	Bundler    *bundler.Bundler
+
+	t        testing.TB
+	overhead *gas.Overhead
+}
+
+// New returns a Harness with freshly deployed contracts. The test fails if the contract bytecode is missing.
+func New(t testing.TB) *Harness {
+	t.Helper()
+	for _, bin := range []string{EntryPointBin, FactoryBin, PaymasterBin} {
+		if _, err := ReadBytecode(bin); err != nil {
+			t.Fatalf("e2e: missing contract artifact: %v", err)
+		}
+	}
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eoa, err := signer.New(hexutil.Encode(crypto.FromECDSA(pk))[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(eoa.PrivateKey, ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{eoa.Address: {Balance: genesisBalance}}, GasLimit)
+	t.Cleanup(func() { _ = sim.Close() })
+
+	rc, err := NewRpcClient(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rc.Close)
+
+	h := &Harness{Sim: sim, Rpc: rc, EOA: eoa, t: t}
+	if h.EntryPoint, err = DeployEntryPoint(sim, auth); err != nil {
+		t.Fatal(err)
+	}
+	if h.Factory, err = DeployFactory(sim, auth, h.EntryPoint); err != nil {
+		t.Fatal(err)
+	}
+	if h.Paymaster, err = DeployPaymaster(sim, auth, h.EntryPoint, eoa.Address, paymasterDeposit); err != nil {
+		t.Fatal(err)
+	}
+
+	h.wire()
+	return h
+}
+
+// wire initializes the Client and Bundler with start.NewPrivateBundler so that the same modules as a private
+// mode bundler are used.
+func (h *Harness) wire() {
+	logr := logger.NewZeroLogr().
+		WithName("stackup_bundler").
+		WithValues("bundler_mode", "e2e")
+
+	db := testutils.DBMock()
+	h.t.Cleanup(func() { _ = db.Close() })
+
+	alt, err := altmempools.New(ChainID, nil)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	conf := &config.Values{
+		SupportedEntryPoints: []common.Address{h.EntryPoint},
+		MaxVerificationGas:   maxVerificationGas,
+		MaxBatchGasLimit:     maxBatchGasLimit,
+		MaxOpTTL:             maxOpTTL,
+		OpLookupLimit:        opLookupLimit,
+		MaxPVGShortfall:      maxPVGShortfall,
+		ReputationConstants: &entities.ReputationConstants{
+			MinUnstakeDelay:                86400,
+			MinStakeValue:                  2000000000000000,
+			SameSenderMempoolCount:         4,
+			SameUnstakedEntityMempoolCount: 11,
+			ThrottledEntityMempoolCount:    4,
+			ThrottledEntityLiveBlocks:      10,
+			ThrottledEntityBundleCount:     4,
+			MinInclusionRateDenominator:    10,
+			ThrottlingSlack:                10,
+			BanSlack:                       50,
+		},
+	}
+	ov := gas.NewDefaultOverhead()
+	pb, err := start.NewPrivateBundler(conf, &start.PrivateDeps{
+		Logger:      logr,
+		DB:          db,
+		Eth:         h.Sim,
+		EOA:         h.EOA,
+		Beneficiary: h.EOA.Address,
+		ChainID:     ChainID,
+		Overhead:    ov,
+		AltMempools: alt,
+		TraceCaller: NewTraceCaller(h.Sim),
+		Tracer:      tracer.Loaded.BundlerCollectorTracer,
+		GetGasTip:   gasprice.GetGasTipWithEthClient(h.Sim),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	// Bundle and mine on demand as a debug mode bundler does.
+	pb.Bundler.SetMaxBatch(1)
+	pb.Relayer.SetWaitTimeout(0)
+
+	h.overhead = ov
+	h.Mempool = pb.Mempool
+	h.Client = pb.Client
+	h.Bundler = pb.Bundler
+}
+
+// Fund transfers ETH from the bundler EOA to addr and mines a block.
+func (h *Harness) Fund(addr common.Address, amount *big.Int) {
+	h.t.Helper()
+	ctx := context.Background()
+	n, err := h.Sim.PendingNonceAt(ctx, h.EOA.Address)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	head, err := h.Sim.HeaderByNumber(ctx, nil)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	tx, err := types.SignNewTx(h.EOA.PrivateKey, types.LatestSignerForChainID(ChainID), &types.DynamicFeeTx{
+		ChainID:   ChainID,
+		Nonce:     n,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: new(big.Int).Mul(head.BaseFee, big.NewInt(2)),
+		Gas:       21000,
+		To:        &addr,
+		Value:     amount,
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if err := h.Sim.SendTransaction(ctx, tx); err != nil {
+		h.t.Fatal(err)
+	}
+	h.Sim.Commit()
+}
+
+// SendUserOperation submits a UserOperation through the Client and returns its hash.
+func (h *Harness) SendUserOperation(op *userop.UserOperation) (string, error) {
+	data, err := op.ToMap()
+	if err != nil {
+		return "", err
+	}
+	return h.Client.SendUserOperation(data, h.EntryPoint.String())
+}
+
+// Bundle processes a batch from the mempool and mines a block with the resulting transaction.
+func (h *Harness) Bundle() {
+	h.t.Helper()
+	if _, err := h.Bundler.Process(h.EntryPoint); err != nil {
+		h.t.Fatal(err)
+	}
+	h.Sim.Commit()
+}
+
+// Receipt returns the receipt of a UserOperation.
+func (h *Harness) Receipt(hash string) (*filter.UserOperationReceipt, error) {
+	return h.Client.GetUserOperationReceipt(hash)
+}
@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestHarnessDeployAccount sends a UserOperation that deploys a new SimpleAccount through the harness.
+// Expect the account to be deployed and the receipt to report success.
+func TestHarnessDeployAccount(t *testing.T) {
+	h := New(t)
+	acc := h.NewAccount()
+	h.Fund(acc.Address, testutils.OneETH)
+
+	head, err := h.Sim.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip := big.NewInt(1000000000)
+	maxFee := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	op := acc.UserOp(big.NewInt(0), []byte{}, maxFee, tip)
+	acc.Sign(op)
+
+	hash, err := h.SendUserOperation(op)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	h.Bundle()
+
+	rcpt, err := h.Receipt(hash)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if !rcpt.Success {
+		t.Fatal("got unsuccessful receipt, want success")
+	}
+	if rcpt.Sender != acc.Address {
+		t.Fatalf("got sender %s, want %s", rcpt.Sender, acc.Address)
+	}
+	if !acc.IsDeployed() {
+		t.Fatal("got account not deployed, want deployed")
+	}
+}
+
+// TestReadBytecode reads a hex encoded artifact from an overridden artifacts directory. Expect the decoded
+// bytecode.
+func TestReadBytecode(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ArtifactsDirEnv, dir)
+	if err := os.WriteFile(filepath.Join(dir, EntryPointBin), []byte("0x6080604052\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := ReadBytecode(EntryPointBin)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if common.Bytes2Hex(code) != "6080604052" {
+		t.Fatalf("got %x, want 6080604052", code)
+	}
+}
+
+// TestReadBytecodeEmpty reads an artifact with no bytecode. Expect an error.
+func TestReadBytecodeEmpty(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ArtifactsDirEnv, dir)
+	if err := os.WriteFile(filepath.Join(dir, EntryPointBin), []byte("0x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadBytecode(EntryPointBin); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
@@ -0,0 +1,154 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/local"
+
+	// Register the native callTracer used to run eth_call.
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+// revertError is returned by eth_call when execution reverts. The revert data is set as the error data the
+// same way as a node.
+type revertError struct {
+	data string
+}
+
+func (e *revertError) Error() string {
+	return "execution reverted"
+}
+
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+func (e *revertError) ErrorData() any {
+	return e.data
+}
+
+// ethService implements the eth namespace methods used by the bundler against a simulated backend.
+type ethService struct {
+	sim *backends.SimulatedBackend
+}
+
+func (s *ethService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.sim.Blockchain().Config().ChainID)
+}
+
+func (s *ethService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	gp, err := s.sim.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(gp), err
+}
+
+func (s *ethService) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tip, err := s.sim.SuggestGasTipCap(ctx)
+	return (*hexutil.Big)(tip), err
+}
+
+func (s *ethService) GetTransactionCount(
+	ctx context.Context,
+	addr common.Address,
+	block string,
+) (hexutil.Uint64, error) {
+	n, err := s.sim.PendingNonceAt(ctx, addr)
+	return hexutil.Uint64(n), err
+}
+
+func (s *ethService) GetCode(ctx context.Context, addr common.Address, block string) (hexutil.Bytes, error) {
+	return s.sim.CodeAt(ctx, addr, nil)
+}
+
+func (s *ethService) GetBlockByNumber(ctx context.Context, block string, full bool) (json.RawMessage, error) {
+	if block != "latest" && block != "pending" {
+		return nil, fmt.Errorf("e2e: unsupported block %s", block)
+	}
+	head, err := s.sim.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(head)
+}
+
+// Call runs eth_call with an optional state override set. The call is traced with the native callTracer to
+// capture the revert data.
+func (s *ethService) Call(
+	ctx context.Context,
+	args local.CallArgs,
+	block string,
+	overrides *state.OverrideSet,
+) (hexutil.Bytes, error) {
+	tc := &local.TraceCall{Args: args, Config: local.CallConfig{Tracer: "callTracer"}}
+	if overrides != nil {
+		tc.Config.StateOverrides = *overrides
+	}
+	res, err := trace(s.sim, tc)
+	if err != nil {
+		return nil, err
+	}
+
+	var frame struct {
+		Output hexutil.Bytes `json:"output"`
+		Error  string        `json:"error"`
+	}
+	if err := json.Unmarshal(res, &frame); err != nil {
+		return nil, err
+	}
+	if frame.Error == "execution reverted" {
+		return nil, &revertError{data: hexutil.Encode(frame.Output)}
+	} else if frame.Error != "" {
+		return nil, errors.New(frame.Error)
+	}
+	return frame.Output, nil
+}
+
+// debugService implements debug_traceCall against a simulated backend.
+type debugService struct {
+	sim *backends.SimulatedBackend
+}
+
+func (s *debugService) TraceCall(
+	ctx context.Context,
+	args json.RawMessage,
+	block string,
+	config json.RawMessage,
+) (json.RawMessage, error) {
+	tc, err := local.ParseTraceCall("debug_traceCall", args, block, config)
+	if err != nil {
+		return nil, err
+	}
+	return trace(s.sim, tc)
+}
+
+// trace runs a call with the configured tracer on top of the latest block.
+func trace(sim *backends.SimulatedBackend, tc *local.TraceCall) (json.RawMessage, error) {
+	bc := sim.Blockchain()
+	head := bc.CurrentBlock()
+	statedb, err := bc.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	return local.Trace(statedb, local.NewBlockContext(head, core.GetHashFn(head, bc)), bc.Config(), tc)
+}
+
+// NewRpcClient returns an in process RPC client for the simulated backend. It serves the eth and debug
+// methods required for simulations and gas estimation, including state overrides on eth_call.
+func NewRpcClient(sim *backends.SimulatedBackend) (*rpc.Client, error) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", &ethService{sim: sim}); err != nil {
+		return nil, err
+	}
+	if err := srv.RegisterName("debug", &debugService{sim: sim}); err != nil {
+		return nil, err
+	}
+	return rpc.DialInProc(srv), nil
+}
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+)
+
+var (
+	// returnCode returns a 32 byte word with the value 1.
+	returnCode = hexutil.Bytes(common.Hex2Bytes("600160005260206000f3"))
+
+	// revertCode reverts with a 32 byte word with the value 1.
+	revertCode = hexutil.Bytes(common.Hex2Bytes("600160005260206000fd"))
+)
+
+func newRpcClient(t *testing.T) *rpc.Client {
+	t.Helper()
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		testutils.ValidAddress1: {Code: returnCode, Balance: big.NewInt(0)},
+	}, 30000000)
+	t.Cleanup(func() { _ = sim.Close() })
+
+	rc, err := NewRpcClient(sim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+// TestRpcClientEthClient calls the in process RPC client through an ethclient. Expect the chain ID and the
+// latest header of the simulated backend.
+func TestRpcClientEthClient(t *testing.T) {
+	eth := ethclient.NewClient(newRpcClient(t))
+
+	id, err := eth.ChainID(context.Background())
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if id.Cmp(ChainID) != 0 {
+		t.Fatalf("got %s, want %s", id, ChainID)
+	}
+
+	head, err := eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if head.BaseFee == nil {
+		t.Fatal("got nil base fee, want value")
+	}
+}
+
+// TestRpcClientCall calls eth_call on a contract that returns a value. Expect the returned data.
+func TestRpcClientCall(t *testing.T) {
+	var res hexutil.Bytes
+	req := utils.EthCallReq{To: testutils.ValidAddress1}
+	if err := newRpcClient(t).CallContext(context.Background(), &res, "eth_call", &req, "latest", nil); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if new(big.Int).SetBytes(res).Cmp(common.Big1) != 0 {
+		t.Fatalf("got %s, want 1", res)
+	}
+}
+
+// TestRpcClientCallRevertWithOverride calls eth_call on an address with overridden code that reverts. Expect
+// an rpc.DataError with the revert data.
+func TestRpcClientCallRevertWithOverride(t *testing.T) {
+	req := utils.EthCallReq{To: testutils.ValidAddress2}
+	sos := state.OverrideSet{testutils.ValidAddress2: {Code: &revertCode}}
+	err := newRpcClient(t).CallContext(context.Background(), nil, "eth_call", &req, "latest", sos)
+	if err == nil {
+		t.Fatal("got nil, want err")
+	}
+
+	dataErr, ok := err.(rpc.DataError)
+	if !ok {
+		t.Fatalf("got %T, want rpc.DataError", err)
+	}
+	want := hexutil.Encode(common.LeftPadBytes([]byte{1}, 32))
+	if dataErr.ErrorData() != want {
+		t.Fatalf("got %v, want %s", dataErr.ErrorData(), want)
+	}
+}
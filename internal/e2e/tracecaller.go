@@ -0,0 +1,35 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/local"
+)
+
+// TraceCaller implements backend.TraceCaller for a simulated backend by running debug_traceCall in process
+// against the latest block.
+type TraceCaller struct {
+	sim *backends.SimulatedBackend
+}
+
+// NewTraceCaller returns a TraceCaller for the given simulated backend.
+func NewTraceCaller(sim *backends.SimulatedBackend) *TraceCaller {
+	return &TraceCaller{sim: sim}
+}
+
+// CallContext runs debug_traceCall and decodes the tracer result into result. Only the latest block is
+// supported.
+func (t *TraceCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	tc, err := local.ParseTraceCall(method, args...)
+	if err != nil {
+		return err
+	}
+
+	res, err := trace(t.sim, tc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(res, result)
+}
@@ -0,0 +1,70 @@
+package e2e
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+)
+
+var (
+	// logCode emits an empty LOG0 and stops.
+	logCode = common.Hex2Bytes("60006000a000")
+
+	// callCode calls testutils.ValidAddress2 with no data and stops.
+	callCode = append(
+		append(common.Hex2Bytes("6000600060006000600073"), testutils.ValidAddress2.Bytes()...),
+		common.Hex2Bytes("5af100")...,
+	)
+)
+
+// TestTraceCallBundlerCollector calls TraceCaller.CallContext with the BundlerCollectorTracer on a contract
+// that calls another contract which emits a log. Expect the inner call and log to be collected.
+func TestTraceCallBundlerCollector(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		testutils.ValidAddress1: {Code: callCode, Balance: big.NewInt(0)},
+		testutils.ValidAddress2: {Code: logCode, Balance: big.NewInt(0)},
+	}, 30000000)
+	defer sim.Close()
+
+	var res tracer.BundlerCollectorReturn
+	req := utils.TraceCallReq{
+		From:         common.HexToAddress("0x"),
+		To:           testutils.ValidAddress1,
+		MaxFeePerGas: hexutil.Big(*big.NewInt(2000000000)),
+	}
+	opts := utils.TraceCallOpts{
+		Tracer:         tracer.Loaded.BundlerCollectorTracer,
+		StateOverrides: state.WithMaxBalanceOverride(common.HexToAddress("0x"), nil),
+	}
+	err := NewTraceCaller(sim).CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if len(res.Calls) == 0 || res.Calls[0].To != testutils.ValidAddress2 {
+		t.Fatalf("got calls %+v, want call to %s", res.Calls, testutils.ValidAddress2)
+	}
+	if len(res.Logs) != 1 {
+		t.Fatalf("got %d logs, want 1", len(res.Logs))
+	}
+}
+
+// TestTraceCallUnsupportedMethod calls TraceCaller.CallContext with a method other than debug_traceCall.
+// Expect an error.
+func TestTraceCallUnsupportedMethod(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{}, 30000000)
+	defer sim.Close()
+
+	var res any
+	if err := NewTraceCaller(sim).CallContext(context.Background(), &res, "eth_call"); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
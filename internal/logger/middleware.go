@@ -7,8 +7,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
 	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
 )
 
+func withRPCCallValues(logEvent logr.Logger, call *jsonrpc.Call) logr.Logger {
+	logEvent = logEvent.WithValues("rpc_method", call.Method).WithValues("rpc_id", call.ID)
+	if call.Error != nil {
+		logEvent = logEvent.WithValues("rpc_error_code", call.Error.Code())
+	}
+	return logEvent
+}
+
 // WithLogr uses a logger with the go-logr/logr interface to log a gin HTTP request.
 func WithLogr(logger logr.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -47,10 +56,18 @@ func WithLogr(logger logr.Logger) gin.HandlerFunc {
 			WithValues("path", param.Path).
 			WithValues("latency", param.Latency.String())
 
-		req, exists := c.Get("json-rpc-request")
-		if exists {
-			json := req.(map[string]any)
-			logEvent = logEvent.WithValues("rpc_method", json["method"])
+		calls := jsonrpc.GetCalls(c)
+		if len(calls) > 0 && !jsonrpc.IsBatch(c) {
+			logEvent = withRPCCallValues(logEvent, calls[0])
+		} else if len(calls) > 0 {
+			logEvent = logEvent.WithValues("rpc_batch_size", len(calls))
+			for i, call := range calls {
+				withRPCCallValues(logger.WithName("rpc"), call).
+					WithValues("client_id", param.ClientIP).
+					WithValues("rpc_batch_index", i).
+					WithValues("latency", call.Latency.String()).
+					Info("")
+			}
 		}
 
 		// Log using the params
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+)
+
+type bucket struct {
+	limit  tenant.Limit
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit tenant.Limit, now time.Time) *bucket {
+	return &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+// refill adds tokens for the time elapsed since the last refill.
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.last = now
+	}
+}
+
+func (b *bucket) hasToken() bool {
+	return b.tokens >= 1
+}
+
+func (b *bucket) take() {
+	b.tokens--
+}
+
+func (b *bucket) isFull() bool {
+	return b.tokens >= float64(b.limit.Burst)
+}
+
+// retryAfter returns the time until the next token is available.
+func (b *bucket) retryAfter() time.Duration {
+	if b.hasToken() {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
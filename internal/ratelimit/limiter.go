@@ -0,0 +1,190 @@
+// Package ratelimit implements token bucket rate limiting for JSON-RPC requests per client and per method.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/ginutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// Scopes of a rate limit that are reported when a request is rejected.
+	ClientScope = "client"
+	MethodScope = "method"
+
+	sweepInterval = time.Minute
+)
+
+// ClientIDFunc returns the identifier of the client making the request.
+type ClientIDFunc = func(c *gin.Context) string
+
+type limits struct {
+	client  tenant.Limit
+	methods map[string]tenant.Limit
+}
+
+// Limiter applies token bucket rate limits to JSON-RPC requests. Each client has an overall limit across all
+// methods and an optional limit for each method. A request is only allowed if both limits have a token.
+type Limiter struct {
+	mu        sync.Mutex
+	defaults  *limits
+	overrides map[string]*limits
+	clientID  ClientIDFunc
+	clients   map[string]*bucket
+	methods   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	rejected  metric.Int64Counter
+}
+
+// New returns a Limiter with an overall limit per client and a limit per client for each given method.
+func New(clientLimit tenant.Limit, methodLimits map[string]tenant.Limit) *Limiter {
+	return &Limiter{
+		defaults:  &limits{client: clientLimit, methods: methodLimits},
+		overrides: make(map[string]*limits),
+		clientID:  defaultClientID,
+		clients:   make(map[string]*bucket),
+		methods:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// UseMeter defines an opentelemetry meter object used by the Limiter to count rejected requests.
+func (l *Limiter) UseMeter(meter metric.Meter) error {
+	c, err := meter.Int64Counter(
+		"rpc_rate_limited_requests",
+		metric.WithDescription("Number of JSON-RPC requests rejected by a rate limit"),
+	)
+	if err != nil {
+		return err
+	}
+	l.rejected = c
+	return nil
+}
+
+// SetClientIDFunc overrides how clients are identified. By default, the client IP is used. API keys should
+// only be used to identify a client once they are verified, for example by tenant.ClientID, since an arbitrary
+// key could otherwise be sent with each request to get a fresh limit.
+func (l *Limiter) SetClientIDFunc(fn ClientIDFunc) {
+	l.clientID = fn
+}
+
+// SetClientLimits overrides the default limits for a client ID.
+func (l *Limiter) SetClientLimits(
+	clientID string,
+	clientLimit tenant.Limit,
+	methodLimits map[string]tenant.Limit,
+) {
+	l.overrides[clientID] = &limits{client: clientLimit, methods: methodLimits}
+}
+
+func defaultClientID(c *gin.Context) string {
+	return "ip:" + ginutils.GetClientIPFromXFF(c)
+}
+
+func (l *Limiter) getBucket(
+	buckets map[string]*bucket,
+	key string,
+	limit tenant.Limit,
+	now time.Time,
+) *bucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = newBucket(limit, now)
+		buckets[key] = b
+	}
+	b.refill(now)
+	return b
+}
+
+// sweep removes full buckets since they are equivalent to new ones. This bounds memory to the number of
+// recently active clients.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	for _, buckets := range []map[string]*bucket{l.clients, l.methods} {
+		for key, b := range buckets {
+			b.refill(now)
+			if b.isFull() {
+				delete(buckets, key)
+			}
+		}
+	}
+	l.lastSweep = now
+}
+
+// Allow takes a token from the client and method buckets if both have one available. If not, the scope of the
+// limit that was exceeded is returned along with the time until a token is available.
+func (l *Limiter) Allow(client, method string) (bool, string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	lim, ok := l.overrides[client]
+	if !ok {
+		lim = l.defaults
+	}
+
+	var cb, mb *bucket
+	if !lim.client.IsUnlimited() {
+		cb = l.getBucket(l.clients, client, lim.client, now)
+		if !cb.hasToken() {
+			return false, ClientScope, cb.retryAfter()
+		}
+	}
+	if limit, ok := lim.methods[method]; ok && !limit.IsUnlimited() {
+		mb = l.getBucket(l.methods, client+"/"+method, limit, now)
+		if !mb.hasToken() {
+			return false, MethodScope, mb.retryAfter()
+		}
+	}
+
+	if cb != nil {
+		cb.take()
+	}
+	if mb != nil {
+		mb.take()
+	}
+	return true, "", 0
+}
+
+// BeforeCall returns a jsonrpc.BeforeCallFunc that rejects requests exceeding a rate limit with a
+// LIMIT_EXCEEDED error. Each element of a batch request counts as a separate request.
+func (l *Limiter) BeforeCall() jsonrpc.BeforeCallFunc {
+	return func(c *gin.Context, call *jsonrpc.Call) error {
+		ok, scope, retryAfter := l.Allow(l.clientID(c), call.Method)
+		if ok {
+			return nil
+		}
+
+		if l.rejected != nil {
+			l.rejected.Add(
+				context.Background(),
+				1,
+				metric.WithAttributes(
+					attribute.String("jsonrpc_method", call.Method),
+					attribute.String("scope", scope),
+				),
+			)
+		}
+		return errors.NewRPCError(
+			errors.LIMIT_EXCEEDED,
+			fmt.Sprintf("%s rate limit exceeded for %s", scope, call.Method),
+			map[string]any{"retryAfter": int(math.Ceil(retryAfter.Seconds()))},
+		)
+	}
+}
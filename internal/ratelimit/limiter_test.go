@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+)
+
+type mockClock struct {
+	now time.Time
+}
+
+func (c *mockClock) Now() time.Time {
+	return c.now
+}
+
+func newTestContext(ip string, apiKey string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Request.Header.Set("x-forwarded-for", ip)
+	c.Request.Header.Set("x-api-key", apiKey)
+	return c
+}
+
+func newTestLimiter(clientLimit tenant.Limit, methodLimits map[string]tenant.Limit) (*Limiter, *mockClock) {
+	clock := &mockClock{now: time.Unix(1700000000, 0)}
+	l := New(clientLimit, methodLimits)
+	l.now = clock.Now
+	l.lastSweep = clock.now
+	return l, clock
+}
+
+// TestAllowMethodLimit calls Limiter.Allow past a method limit. Expect the method to be rejected until the
+// bucket refills while other methods and clients are unaffected.
+func TestAllowMethodLimit(t *testing.T) {
+	l, clock := newTestLimiter(
+		tenant.Limit{},
+		map[string]tenant.Limit{"eth_estimateUserOperationGas": {Rate: 1, Burst: 2}},
+	)
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.Allow("a", "eth_estimateUserOperationGas"); !ok {
+			t.Fatalf("request %d: got rejected, want allowed", i)
+		}
+	}
+	ok, scope, retryAfter := l.Allow("a", "eth_estimateUserOperationGas")
+	if ok || scope != MethodScope || retryAfter != time.Second {
+		t.Fatalf("got %t, %s, %s, want rejected by method limit for 1s", ok, scope, retryAfter)
+	}
+
+	if ok, _, _ := l.Allow("a", "eth_chainId"); !ok {
+		t.Fatal("unlimited method: got rejected, want allowed")
+	}
+	if ok, _, _ := l.Allow("b", "eth_estimateUserOperationGas"); !ok {
+		t.Fatal("other client: got rejected, want allowed")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if ok, _, _ := l.Allow("a", "eth_estimateUserOperationGas"); !ok {
+		t.Fatal("after refill: got rejected, want allowed")
+	}
+}
+
+// TestAllowClientLimit calls Limiter.Allow past a client limit across different methods. Expect requests to
+// be rejected and a rejection by the method limit to not use a client token.
+func TestAllowClientLimit(t *testing.T) {
+	l, _ := newTestLimiter(
+		tenant.Limit{Rate: 1, Burst: 3},
+		map[string]tenant.Limit{"eth_sendUserOperation": {Rate: 1, Burst: 1}},
+	)
+
+	if ok, _, _ := l.Allow("a", "eth_sendUserOperation"); !ok {
+		t.Fatal("got rejected, want allowed")
+	}
+	if ok, scope, _ := l.Allow("a", "eth_sendUserOperation"); ok || scope != MethodScope {
+		t.Fatal("got allowed, want rejected by method limit")
+	}
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.Allow("a", "eth_chainId"); !ok {
+			t.Fatalf("request %d: got rejected, want allowed", i)
+		}
+	}
+	if ok, scope, _ := l.Allow("a", "eth_chainId"); ok || scope != ClientScope {
+		t.Fatal("got allowed, want rejected by client limit")
+	}
+}
+
+// TestAllowClientOverride calls Limiter.Allow for a client with overridden limits. Expect the override to
+// apply only to that client.
+func TestAllowClientOverride(t *testing.T) {
+	l, _ := newTestLimiter(tenant.Limit{Rate: 1, Burst: 1}, map[string]tenant.Limit{})
+	l.SetClientLimits(
+		"a",
+		tenant.Limit{Rate: 1, Burst: 2},
+		map[string]tenant.Limit{"eth_chainId": {Rate: 1, Burst: 1}},
+	)
+
+	if ok, _, _ := l.Allow("a", "eth_sendUserOperation"); !ok {
+		t.Fatal("got rejected, want allowed")
+	}
+	if ok, scope, _ := l.Allow("a", "eth_chainId"); !ok {
+		t.Fatalf("got rejected by %s limit, want allowed", scope)
+	}
+	if ok, scope, _ := l.Allow("a", "eth_chainId"); ok || scope != ClientScope {
+		t.Fatal("got allowed, want rejected by client limit")
+	}
+
+	if ok, _, _ := l.Allow("b", "eth_chainId"); !ok {
+		t.Fatal("other client: got rejected, want allowed")
+	}
+	if ok, scope, _ := l.Allow("b", "eth_chainId"); ok || scope != ClientScope {
+		t.Fatal("other client: got allowed, want rejected by default client limit")
+	}
+}
+
+// TestSweepRemovesFullBuckets calls Limiter.Allow after the sweep interval. Expect idle buckets to be removed.
+func TestSweepRemovesFullBuckets(t *testing.T) {
+	l, clock := newTestLimiter(tenant.Limit{Rate: 10, Burst: 10}, nil)
+
+	l.Allow("a", "eth_chainId")
+	l.Allow("b", "eth_chainId")
+	if len(l.clients) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(l.clients))
+	}
+
+	clock.now = clock.now.Add(sweepInterval)
+	l.Allow("c", "eth_chainId")
+	if len(l.clients) != 1 {
+		t.Fatalf("got %d buckets, want 1", len(l.clients))
+	}
+}
+
+// TestBeforeCall calls the jsonrpc.BeforeCallFunc past a limit for a client identified by IP. Expect a
+// LIMIT_EXCEEDED error only for that IP.
+func TestBeforeCall(t *testing.T) {
+	l, _ := newTestLimiter(tenant.Limit{Rate: 1, Burst: 1}, nil)
+	fn := l.BeforeCall()
+	call := &jsonrpc.Call{Method: "eth_chainId"}
+
+	if err := fn(newTestContext("1.1.1.1", ""), call); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	err := fn(newTestContext("1.1.1.1", ""), call)
+	if rpcErr, ok := err.(*errors.RPCError); !ok || rpcErr.Code() != errors.LIMIT_EXCEEDED {
+		t.Fatalf("got err %v, want LIMIT_EXCEEDED", err)
+	}
+	if err := fn(newTestContext("2.2.2.2", ""), call); err != nil {
+		t.Fatalf("other IP: got err %v, want nil", err)
+	}
+}
+
+// TestBeforeCallRotatingAPIKeys calls the jsonrpc.BeforeCallFunc past a limit from the same IP with a
+// different API key on each request. Expect unverified keys to be ignored and the limit to still apply.
+func TestBeforeCallRotatingAPIKeys(t *testing.T) {
+	l, _ := newTestLimiter(tenant.Limit{Rate: 1, Burst: 1}, nil)
+	fn := l.BeforeCall()
+	call := &jsonrpc.Call{Method: "eth_chainId"}
+
+	if err := fn(newTestContext("1.1.1.1", "key1"), call); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	err := fn(newTestContext("1.1.1.1", "key2"), call)
+	if rpcErr, ok := err.(*errors.RPCError); !ok || rpcErr.Code() != errors.LIMIT_EXCEEDED {
+		t.Fatalf("got err %v, want LIMIT_EXCEEDED", err)
+	}
+}
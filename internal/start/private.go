@@ -15,20 +15,15 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/internal/config"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
 	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/internal/ratelimit"
 	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
-	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
 	"github.com/stackup-wallet/stackup-bundler/pkg/client"
-	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
-	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/relay"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
 )
@@ -84,50 +79,49 @@ func PrivateMode() {
 	}
 
 	ov := gas.NewDefaultOverhead()
-	if conf.IsArbStackNetwork || config.ArbStackChains.Contains(chain.Uint64()) {
-		ov.SetCalcPreVerificationGasFunc(gas.CalcArbitrumPVGWithEthClient(rpc, conf.SupportedEntryPoints[0]))
-		ov.SetPreVerificationGasBufferFactor(16)
+	pvg, err := config.NewPVGRegistry().Find(conf.PVGCalculator, chain.Uint64())
+	if err != nil {
+		log.Fatal(err)
+	} else if pvg != nil {
+		pvg.Apply(ov, &gas.PVGCalculatorInput{
+			Rpc:        rpc,
+			ChainID:    chain,
+			EntryPoint: conf.SupportedEntryPoints[0],
+		})
 	}
 
-	if conf.IsOpStackNetwork || config.OpStackChains.Contains(chain.Uint64()) {
-		ov.SetCalcPreVerificationGasFunc(
-			gas.CalcOptimismPVGWithEthClient(rpc, chain, conf.SupportedEntryPoints[0]),
-		)
-		ov.SetPreVerificationGasBufferFactor(1)
+	alt, err := altmempools.NewFromIPFS(chain, conf.AltMempoolIPFSGateway, conf.AltMempoolIds)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	mem, err := mempool.New(db)
+	tc, collector, err := getValidationTracer(conf, rpc, eth, chain)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	alt, err := altmempools.NewFromIPFS(chain, conf.AltMempoolIPFSGateway, conf.AltMempoolIds)
+	fo := fees.NewOracle(eth)
+	pb, err := NewPrivateBundler(conf, &PrivateDeps{
+		Logger:      logr,
+		DB:          db,
+		Eth:         eth,
+		EOA:         eoa,
+		Beneficiary: beneficiary,
+		ChainID:     chain,
+		Overhead:    ov,
+		AltMempools: alt,
+		TraceCaller: tc,
+		Tracer:      collector,
+		BatchCaller: rpc,
+		GetGasTip:   gasprice.GetGasTipWithFeeOracle(fo),
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	check := checks.New(
-		db,
-		rpc,
-		ov,
-		alt,
-		conf.MaxVerificationGas,
-		conf.MaxBatchGasLimit,
-		conf.IsRIP7212Supported,
-		conf.NativeBundlerCollectorTracer,
-		conf.ReputationConstants,
-	)
-
-	exp := expire.New(conf.MaxOpTTL)
-
-	relayer := relay.New(eoa, eth, chain, beneficiary, logr)
-
-	rep := entities.New(db, eth, conf.ReputationConstants)
-
 	// Init Client
-	c := client.New(mem, ov, chain, conf.SupportedEntryPoints, conf.OpLookupLimit)
-	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(eth))
-	c.SetGetGasPricesFunc(client.GetGasPricesWithEthClient(eth))
+	c := pb.Client
+	c.SetGetGasPricesFunc(client.GetGasPricesWithFeeOracle(fo))
+	c.SetGetFeeTiersFunc(client.GetFeeTiersWithFeeOracle(fo))
 	c.SetGetGasEstimateFunc(
 		client.GetGasEstimateWithEthClient(
 			rpc,
@@ -138,38 +132,12 @@ func PrivateMode() {
 		),
 	)
 	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(eth))
-	c.SetGetStakeFunc(stake.GetStakeWithEthClient(eth))
-	c.UseLogger(logr)
-	c.UseModules(
-		rep.CheckStatus(),
-		rep.ValidateOpLimit(),
-		check.ValidateOpValues(),
-		check.SimulateOp(),
-		rep.IncOpsSeen(),
-	)
 
 	// Init Bundler
-	b := bundler.New(mem, chain, conf.SupportedEntryPoints)
-	b.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
-	b.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
-	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
-	b.UseLogger(logr)
+	b := pb.Bundler
 	if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
 		log.Fatal(err)
 	}
-	b.UseModules(
-		exp.DropExpired(),
-		gasprice.SortByGasPrice(),
-		gasprice.FilterUnderpriced(),
-		batch.SortByNonce(),
-		batch.MaintainGasLimit(conf.MaxBatchGasLimit),
-		check.CodeHashes(),
-		check.PaymasterDeposit(),
-		check.SimulateBatch(),
-		relayer.SendUserOperation(),
-		rep.IncOpsIncluded(),
-		check.Clean(),
-	)
 	if err := b.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -177,9 +145,9 @@ func PrivateMode() {
 	// init Debug
 	var d *client.Debug
 	if conf.DebugMode {
-		d = client.NewDebug(eoa, eth, mem, rep, b, chain, conf.SupportedEntryPoints[0], beneficiary)
+		d = client.NewDebug(eoa, pb.Mempool, pb.Reputation, b, chain, conf.SupportedEntryPoints[0], beneficiary)
 		b.SetMaxBatch(1)
-		relayer.SetWaitTimeout(0)
+		pb.Relayer.SetWaitTimeout(0)
 	}
 
 	// Init HTTP server
@@ -199,10 +167,28 @@ func PrivateMode() {
 	r.GET("/ping", func(g *gin.Context) {
 		g.Status(http.StatusOK)
 	})
-	handlers := []gin.HandlerFunc{
-		jsonrpc.Controller(client.NewRpcAdapter(c, d)),
-		jsonrpc.WithOTELTracerAttributes(),
+	rl := ratelimit.New(conf.RPCClientRateLimit, conf.RPCMethodRateLimits)
+	if err := rl.UseMeter(otel.GetMeterProvider().Meter("rpc")); err != nil {
+		log.Fatal(err)
 	}
+	handlers := []gin.HandlerFunc{}
+	beforeCall := rl.BeforeCall()
+	if conf.Tenants != nil {
+		rl.SetClientIDFunc(tenant.ClientID)
+		for _, t := range conf.Tenants.Tenants() {
+			rl.SetClientLimits(tenant.RateLimitKey(t.ID), t.RateLimit, t.MethodRateLimits)
+		}
+		handlers = append(handlers, conf.Tenants.Authenticate(conf.RPCAPIKeyHeader))
+		beforeCall = jsonrpc.ComposeBeforeCallFuncs(tenant.BeforeCall(), beforeCall)
+	}
+	handlers = append(
+		handlers,
+		jsonrpc.Controller(client.NewRpcRegistry(c, d), &jsonrpc.Opts{
+			MaxBatchConcurrency: conf.RPCMaxBatchConcurrency,
+			BeforeCall:          beforeCall,
+		}),
+		jsonrpc.WithOTELTracerAttributes(),
+	)
 	r.POST("/", handlers...)
 	r.POST("/rpc", handlers...)
 
@@ -16,10 +16,13 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/internal/config"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
 	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/internal/ratelimit"
 	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
 	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
@@ -29,7 +32,10 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/policy"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
 )
@@ -98,35 +104,50 @@ func SearcherMode() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	mem.SetMaxOps(conf.MaxMempoolOps)
+	mem.SetMaxOpsPerSender(conf.MaxMempoolOpsPerSender)
+	mem.SetMaxBytes(conf.MaxMempoolBytes)
+	mem.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
+	mem.SetGetNoncesFunc(nonce.GetNoncesWithRpcClient(rpc))
 
 	alt, err := altmempools.NewFromIPFS(chain, conf.AltMempoolIPFSGateway, conf.AltMempoolIds)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	tc, collector, err := getValidationTracer(conf, rpc, eth, chain)
+	if err != nil {
+		log.Fatal(err)
+	}
 	check := checks.New(
 		db,
-		rpc,
+		eth,
+		tc,
 		ov,
 		alt,
 		conf.MaxVerificationGas,
 		conf.MaxBatchGasLimit,
 		conf.IsRIP7212Supported,
-		conf.NativeBundlerCollectorTracer,
+		collector,
 		conf.ReputationConstants,
 	)
+	mem.SetOnEvictFunc(check.OnEvict(chain))
 
 	exp := expire.New(conf.MaxOpTTL)
 
+	fo := fees.NewOracle(eth)
+
 	// TODO: Create separate go-routine for tracking transactions sent to the block builder.
 	builder := builder.New(eoa, eth, fb, beneficiary, conf.BlocksInTheFuture)
+	builder.SetFeeStrategy(conf.SearcherBundleFeeStrategy)
 
-	rep := entities.New(db, eth, conf.ReputationConstants)
+	rep := entities.New(db, conf.ReputationConstants)
 
 	// Init Client
 	c := client.New(mem, ov, chain, conf.SupportedEntryPoints, conf.OpLookupLimit)
 	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(eth))
-	c.SetGetGasPricesFunc(client.GetGasPricesWithEthClient(eth))
+	c.SetGetGasPricesFunc(client.GetGasPricesWithFeeOracle(fo))
+	c.SetGetFeeTiersFunc(client.GetFeeTiersWithFeeOracle(fo))
 	c.SetGetGasEstimateFunc(
 		client.GetGasEstimateWithEthClient(
 			rpc,
@@ -139,7 +160,23 @@ func SearcherMode() {
 	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(eth))
 	c.SetGetStakeFunc(stake.GetStakeWithEthClient(eth))
 	c.UseLogger(logr)
+	tenantCheck := noop.UserOpHandler
+	if conf.Tenants != nil {
+		tenantCheck = conf.Tenants.CheckUserOp()
+	}
+	policyCheck := noop.UserOpHandler
+	if conf.PolicyFile != "" {
+		p, err := policy.New(conf.PolicyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p.UseLogger(logr)
+		p.Watch()
+		policyCheck = p.CheckUserOp()
+	}
 	c.UseModules(
+		tenantCheck,
+		policyCheck,
 		rep.CheckStatus(),
 		rep.ValidateOpLimit(),
 		check.ValidateOpValues(),
@@ -147,22 +184,32 @@ func SearcherMode() {
 		// TODO: add p2p propagation module
 		rep.IncOpsSeen(),
 	)
+	c.UseEstimateModules(tenantCheck)
 
 	// Init Bundler
 	b := bundler.New(mem, chain, conf.SupportedEntryPoints)
 	b.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
-	b.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
+	b.SetGetGasTipFunc(gasprice.GetGasTipWithFeeOracle(fo))
 	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
 	b.UseLogger(logr)
+	// The expected bundle size used to amortize PVG and the minimum bundle size enforced at bundle time are
+	// both derived from the bundler's recent bundles.
+	minBundleSize := noop.BatchHandler
+	if conf.AmortizePVG {
+		ov.SetExpectedBundleSizeFunc(b.Stats().ExpectedSize)
+		minBundleSize = batch.MaintainMinBundleSize(ov)
+	}
 	if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
 		log.Fatal(err)
 	}
 	b.UseModules(
 		exp.DropExpired(),
-		gasprice.SortByGasPrice(),
+		check.ValidityWindow(),
 		gasprice.FilterUnderpriced(),
-		batch.SortByNonce(),
+		check.StorageConflicts(),
 		batch.MaintainGasLimit(conf.MaxBatchGasLimit),
+		minBundleSize,
+		check.PreVerificationGas(conf.MaxPVGShortfall),
 		check.CodeHashes(),
 		check.PaymasterDeposit(),
 		check.SimulateBatch(),
@@ -177,7 +224,7 @@ func SearcherMode() {
 	// init Debug
 	var d *client.Debug
 	if conf.DebugMode {
-		d = client.NewDebug(eoa, eth, mem, rep, b, chain, conf.SupportedEntryPoints[0], beneficiary)
+		d = client.NewDebug(eoa, mem, rep, b, chain, conf.SupportedEntryPoints[0], beneficiary)
 		b.SetMaxBatch(1)
 	}
 
@@ -198,10 +245,28 @@ func SearcherMode() {
 	r.GET("/ping", func(g *gin.Context) {
 		g.Status(http.StatusOK)
 	})
-	handlers := []gin.HandlerFunc{
-		jsonrpc.Controller(client.NewRpcAdapter(c, d)),
-		jsonrpc.WithOTELTracerAttributes(),
+	rl := ratelimit.New(conf.RPCClientRateLimit, conf.RPCMethodRateLimits)
+	if err := rl.UseMeter(otel.GetMeterProvider().Meter("rpc")); err != nil {
+		log.Fatal(err)
 	}
+	handlers := []gin.HandlerFunc{}
+	beforeCall := rl.BeforeCall()
+	if conf.Tenants != nil {
+		rl.SetClientIDFunc(tenant.ClientID)
+		for _, t := range conf.Tenants.Tenants() {
+			rl.SetClientLimits(tenant.RateLimitKey(t.ID), t.RateLimit, t.MethodRateLimits)
+		}
+		handlers = append(handlers, conf.Tenants.Authenticate(conf.RPCAPIKeyHeader))
+		beforeCall = jsonrpc.ComposeBeforeCallFuncs(tenant.BeforeCall(), beforeCall)
+	}
+	handlers = append(
+		handlers,
+		jsonrpc.Controller(client.NewRpcRegistry(c, d), &jsonrpc.Opts{
+			MaxBatchConcurrency: conf.RPCMaxBatchConcurrency,
+			BeforeCall:          beforeCall,
+		}),
+		jsonrpc.WithOTELTracerAttributes(),
+	)
 	r.POST("/", handlers...)
 	r.POST("/rpc", handlers...)
 
@@ -0,0 +1,71 @@
+package start
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/local"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/native"
+)
+
+var (
+	// ErrLocalTracingPastShanghai is returned if local validation tracing is enabled on a chain that has
+	// activated a fork after Shanghai. The EVM used for local tracing does not implement those forks.
+	ErrLocalTracingPastShanghai = errors.New(
+		"start: local validation tracing does not support forks after Shanghai",
+	)
+
+	// ErrLocalTracingRIP7212 is returned if local validation tracing is enabled on a chain with the RIP-7212
+	// precompile. The EVM used for local tracing does not implement it.
+	ErrLocalTracingRIP7212 = errors.New("start: local validation tracing does not support RIP-7212")
+)
+
+// cancunHeaderFields are block fields that only exist once a chain activates Cancun.
+var cancunHeaderFields = []string{"blobGasUsed", "excessBlobGas", "parentBeaconBlockRoot"}
+
+// isPastShanghai returns true if the latest block has any field added after Shanghai. The raw block is
+// checked since the header type used by the bundler drops unknown fields.
+func isPastShanghai(rpc backend.TraceCaller) (bool, error) {
+	var block map[string]any
+	err := rpc.CallContext(context.Background(), &block, "eth_getBlockByNumber", "latest", false)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range cancunHeaderFields {
+		if _, ok := block[f]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getValidationTracer returns the TraceCaller and tracer used for validation. With local validation tracing,
+// only the node's prestateTracer is required and the native BundlerCollectorTracer runs in process unless
+// another tracer is configured. Local validation tracing is refused on chains it cannot execute correctly.
+func getValidationTracer(
+	conf *config.Values,
+	rpc backend.TraceCaller,
+	eth backend.HeaderReader,
+	chain *big.Int,
+) (backend.TraceCaller, string, error) {
+	if !conf.LocalValidationTracing {
+		return rpc, conf.NativeBundlerCollectorTracer, nil
+	}
+	if conf.IsRIP7212Supported {
+		return nil, "", ErrLocalTracingRIP7212
+	}
+	if past, err := isPastShanghai(rpc); err != nil {
+		return nil, "", err
+	} else if past {
+		return nil, "", ErrLocalTracingPastShanghai
+	}
+
+	t := conf.NativeBundlerCollectorTracer
+	if t == "" {
+		t = native.BundlerCollectorTracerName
+	}
+	return local.NewTraceCaller(rpc, eth, local.ChainConfig(chain)), t, nil
+}
@@ -0,0 +1,51 @@
+package start
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/native"
+)
+
+// blockCaller returns the given raw block for eth_getBlockByNumber.
+type blockCaller string
+
+func (b blockCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	return json.Unmarshal([]byte(b), result)
+}
+
+// TestGetValidationTracerLocal enables local tracing on a Shanghai chain. Expect the native tracer.
+func TestGetValidationTracerLocal(t *testing.T) {
+	conf := &config.Values{LocalValidationTracing: true}
+	_, tracer, err := getValidationTracer(conf, blockCaller(`{"withdrawalsRoot":"0x00"}`), nil, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if tracer != native.BundlerCollectorTracerName {
+		t.Fatalf("got %s, want %s", tracer, native.BundlerCollectorTracerName)
+	}
+}
+
+// TestGetValidationTracerPastShanghai enables local tracing on a Cancun chain. Expect
+// ErrLocalTracingPastShanghai.
+func TestGetValidationTracerPastShanghai(t *testing.T) {
+	conf := &config.Values{LocalValidationTracing: true}
+	_, _, err := getValidationTracer(conf, blockCaller(`{"blobGasUsed":"0x0"}`), nil, big.NewInt(1))
+	if !errors.Is(err, ErrLocalTracingPastShanghai) {
+		t.Fatalf("got %v, want %v", err, ErrLocalTracingPastShanghai)
+	}
+}
+
+// TestGetValidationTracerRIP7212 enables local tracing on a chain with the RIP-7212 precompile. Expect
+// ErrLocalTracingRIP7212.
+func TestGetValidationTracerRIP7212(t *testing.T) {
+	conf := &config.Values{LocalValidationTracing: true, IsRIP7212Supported: true}
+	_, _, err := getValidationTracer(conf, blockCaller(`{}`), nil, big.NewInt(1))
+	if !errors.Is(err, ErrLocalTracingRIP7212) {
+		t.Fatalf("got %v, want %v", err, ErrLocalTracingRIP7212)
+	}
+}
@@ -0,0 +1,167 @@
+package start
+
+import (
+	"math/big"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/policy"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/relay"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// Node is the Ethereum node used by the modules of a private mode bundler. Both *ethclient.Client and
+// go-ethereum's simulated backend satisfy it.
+type Node interface {
+	backend.TxSender
+	backend.EventReader
+	backend.FeeReader
+}
+
+// PrivateDeps holds the dependencies of a private mode bundler that are created outside of
+// NewPrivateBundler.
+type PrivateDeps struct {
+	Logger      logr.Logger
+	DB          *badger.DB
+	Eth         Node
+	EOA         *signer.EOA
+	Beneficiary common.Address
+	ChainID     *big.Int
+	Overhead    *gas.Overhead
+	AltMempools *altmempools.Directory
+
+	// TraceCaller and Tracer are used for validation. See getValidationTracer.
+	TraceCaller backend.TraceCaller
+	Tracer      string
+
+	// BatchCaller is optional. If set, the mempool fetches the nonces of all pending senders in one batch.
+	BatchCaller backend.BatchCaller
+
+	// GetGasTip returns the tip used for bundle transactions.
+	GetGasTip gasprice.GetGasTipFunc
+}
+
+// PrivateBundler is a Client and Bundler wired with the same modules as a private mode bundler. Functions
+// that require a full RPC client, such as gas estimation and fee suggestions, are left for the caller to set.
+type PrivateBundler struct {
+	Mempool    *mempool.Mempool
+	Client     *client.Client
+	Bundler    *bundler.Bundler
+	Relayer    *relay.Relayer
+	Reputation *entities.Reputation
+}
+
+// NewPrivateBundler returns a PrivateBundler using the given config values and dependencies. The Bundler is
+// not started.
+func NewPrivateBundler(conf *config.Values, deps *PrivateDeps) (*PrivateBundler, error) {
+	mem, err := mempool.New(deps.DB)
+	if err != nil {
+		return nil, err
+	}
+	mem.SetMaxOps(conf.MaxMempoolOps)
+	mem.SetMaxOpsPerSender(conf.MaxMempoolOpsPerSender)
+	mem.SetMaxBytes(conf.MaxMempoolBytes)
+	mem.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(deps.Eth))
+	mem.SetGetNonceFunc(nonce.GetNonceWithEthClient(deps.Eth))
+	if deps.BatchCaller != nil {
+		mem.SetGetNoncesFunc(nonce.GetNoncesWithRpcClient(deps.BatchCaller))
+	}
+
+	check := checks.New(
+		deps.DB,
+		deps.Eth,
+		deps.TraceCaller,
+		deps.Overhead,
+		deps.AltMempools,
+		conf.MaxVerificationGas,
+		conf.MaxBatchGasLimit,
+		conf.IsRIP7212Supported,
+		deps.Tracer,
+		conf.ReputationConstants,
+	)
+	mem.SetOnEvictFunc(check.OnEvict(deps.ChainID))
+
+	exp := expire.New(conf.MaxOpTTL)
+
+	relayer := relay.New(deps.EOA, deps.Eth, deps.ChainID, deps.Beneficiary, deps.Logger)
+	relayer.SetFeeStrategy(conf.PrivateBundleFeeStrategy)
+
+	rep := entities.New(deps.DB, conf.ReputationConstants)
+
+	// Init Client
+	c := client.New(mem, deps.Overhead, deps.ChainID, conf.SupportedEntryPoints, conf.OpLookupLimit)
+	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(deps.Eth))
+	c.SetGetStakeFunc(stake.GetStakeWithEthClient(deps.Eth))
+	c.UseLogger(deps.Logger)
+	tenantCheck := noop.UserOpHandler
+	if conf.Tenants != nil {
+		tenantCheck = conf.Tenants.CheckUserOp()
+	}
+	policyCheck := noop.UserOpHandler
+	if conf.PolicyFile != "" {
+		p, err := policy.New(conf.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		p.UseLogger(deps.Logger)
+		p.Watch()
+		policyCheck = p.CheckUserOp()
+	}
+	c.UseModules(
+		tenantCheck,
+		policyCheck,
+		rep.CheckStatus(),
+		rep.ValidateOpLimit(),
+		check.ValidateOpValues(),
+		check.SimulateOp(),
+		rep.IncOpsSeen(),
+	)
+	c.UseEstimateModules(tenantCheck)
+
+	// Init Bundler
+	b := bundler.New(mem, deps.ChainID, conf.SupportedEntryPoints)
+	b.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(deps.Eth))
+	b.SetGetGasTipFunc(deps.GetGasTip)
+	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(deps.Eth))
+	b.UseLogger(deps.Logger)
+	// The expected bundle size used to amortize PVG and the minimum bundle size enforced at bundle time are
+	// both derived from the bundler's recent bundles.
+	minBundleSize := noop.BatchHandler
+	if conf.AmortizePVG {
+		deps.Overhead.SetExpectedBundleSizeFunc(b.Stats().ExpectedSize)
+		minBundleSize = batch.MaintainMinBundleSize(deps.Overhead)
+	}
+	b.UseModules(
+		exp.DropExpired(),
+		check.ValidityWindow(),
+		gasprice.FilterUnderpriced(),
+		check.StorageConflicts(),
+		batch.MaintainGasLimit(conf.MaxBatchGasLimit),
+		minBundleSize,
+		check.PreVerificationGas(conf.MaxPVGShortfall),
+		check.CodeHashes(),
+		check.PaymasterDeposit(),
+		check.SimulateBatch(),
+		relayer.SendUserOperation(),
+		rep.IncOpsIncluded(),
+		check.Clean(),
+	)
+
+	return &PrivateBundler{Mempool: mem, Client: c, Bundler: b, Relayer: relayer, Reputation: rep}, nil
+}
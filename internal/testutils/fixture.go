@@ -0,0 +1,79 @@
+package testutils
+
+import (
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Flags shared by tests that record fixtures into their testdata directory. Recording tests are skipped
+// unless -record is set. Tests that record from a live node dial -record-rpc at -record-block.
+var (
+	record      = flag.Bool("record", false, "record test fixtures into testdata")
+	recordRpc   = flag.String("record-rpc", "", "RPC URL to record test fixtures from")
+	recordBlock = flag.Int64("record-block", -1, "block number to record test fixtures at, defaults to latest")
+)
+
+// Recording returns true if -record is set.
+func Recording() bool {
+	return *record
+}
+
+// SkipUnlessRecording skips a test that records fixtures unless -record is set.
+func SkipUnlessRecording(t testing.TB) {
+	t.Helper()
+	if !Recording() {
+		t.Skip("run with -record to record fixtures")
+	}
+}
+
+// DialRecordRpc returns a client for the node given with -record-rpc. The test fails if it is not set.
+func DialRecordRpc(t testing.TB) *rpc.Client {
+	t.Helper()
+	if *recordRpc == "" {
+		t.Fatal("set -record-rpc to the RPC URL to record fixtures from")
+	}
+	c, err := rpc.Dial(*recordRpc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// RecordBlock returns the block number given with -record-block, or nil for the latest block.
+func RecordBlock() *big.Int {
+	if *recordBlock < 0 {
+		return nil
+	}
+	return big.NewInt(*recordBlock)
+}
+
+// ReadFixture decodes the JSON fixture at testdata/<name>.json into v.
+func ReadFixture(t testing.TB, name string, v any) {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+// WriteFixture encodes v as indented JSON to testdata/<name>.json.
+func WriteFixture(t testing.TB, name string, v any) {
+	t.Helper()
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("testdata", name+".json"), append(b, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
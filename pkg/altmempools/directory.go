@@ -14,6 +14,8 @@ import (
 // accept.
 type Directory struct {
 	invalidStorageAccess *xsync.MapOf[string, []string]
+	forbiddenOpcode      *xsync.MapOf[string, []string]
+	notStaked            *xsync.MapOf[string, []string]
 }
 
 type Config struct {
@@ -25,6 +27,10 @@ func invalidStorageAccessID(entity string, contract string, slot string) string
 	return entity + contract + slot
 }
 
+func forbiddenOpcodeID(entity string, contract string, opcode string) string {
+	return entity + contract + opcode
+}
+
 func fetchMempoolConfig(url string) (map[string]any, error) {
 	resp, err := http.Get(url)
 	if err != nil {
@@ -43,6 +49,8 @@ func fetchMempoolConfig(url string) (map[string]any, error) {
 func New(chain *big.Int, altMempools []*Config) (*Directory, error) {
 	dir := &Directory{
 		invalidStorageAccess: xsync.NewMapOf[string, []string](),
+		forbiddenOpcode:      xsync.NewMapOf[string, []string](),
+		notStaked:            xsync.NewMapOf[string, []string](),
 	}
 	for _, alt := range altMempools {
 		if err := Schema.Validate(alt.Data); err != nil {
@@ -77,6 +85,22 @@ func New(chain *big.Int, altMempools []*Config) (*Directory, error) {
 					curr, _ := dir.invalidStorageAccess.Load(isaId)
 					dir.invalidStorageAccess.Store(isaId, append(curr, alt.Id))
 				}
+			case "forbiddenOpcode":
+				{
+					foId := forbiddenOpcodeID(
+						config["entity"].(string),
+						config["contract"].(string),
+						config["opcode"].(string),
+					)
+					curr, _ := dir.forbiddenOpcode.Load(foId)
+					dir.forbiddenOpcode.Store(foId, append(curr, alt.Id))
+				}
+			case "notStaked":
+				{
+					nsId := config["entity"].(string)
+					curr, _ := dir.notStaked.Load(nsId)
+					dir.notStaked.Store(nsId, append(curr, alt.Id))
+				}
 			}
 		}
 	}
@@ -105,3 +129,17 @@ func (d *Directory) HasInvalidStorageAccessException(entity string, contract str
 	ids, _ := d.invalidStorageAccess.Load(invalidStorageAccessID(entity, contract, slot))
 	return ids
 }
+
+// HasForbiddenOpcodeException will attempt to find all mempool ids that will accept the given entity using a
+// forbidden opcode in contract. If none is found, an empty array will be returned.
+func (d *Directory) HasForbiddenOpcodeException(entity string, contract string, opcode string) []string {
+	ids, _ := d.forbiddenOpcode.Load(forbiddenOpcodeID(entity, contract, opcode))
+	return ids
+}
+
+// HasNotStakedException will attempt to find all mempool ids that will accept the given entity without a
+// stake. If none is found, an empty array will be returned.
+func (d *Directory) HasNotStakedException(entity string) []string {
+	ids, _ := d.notStaked.Load(entity)
+	return ids
+}
@@ -88,3 +88,45 @@ func TestDirectoryIncompatibleChain(t *testing.T) {
 		t.Fatalf("got %v, want []", mempools)
 	}
 }
+
+func TestDirectoryHasForbiddenOpcodeException(t *testing.T) {
+	id := "1"
+	alts := []*altmempools.Config{
+		{Id: id, Data: testutils.AltMempoolMock()},
+	}
+	dir, err := altmempools.New(testutils.ChainID, alts)
+	if err != nil {
+		t.Fatal("error initializing directory")
+	}
+
+	mempools := dir.HasForbiddenOpcodeException("account", "0x0000000000000000000000000000000000000000", "GAS")
+	if len(mempools) != 1 || mempools[0] != id {
+		t.Fatalf("got %v, want [1]", mempools)
+	}
+
+	mempools = dir.HasForbiddenOpcodeException("account", "0x0000000000000000000000000000000000000000", "NUMBER")
+	if len(mempools) != 0 {
+		t.Fatalf("got %v, want []", mempools)
+	}
+}
+
+func TestDirectoryHasNotStakedException(t *testing.T) {
+	id := "1"
+	alts := []*altmempools.Config{
+		{Id: id, Data: testutils.AltMempoolMock()},
+	}
+	dir, err := altmempools.New(testutils.ChainID, alts)
+	if err != nil {
+		t.Fatal("error initializing directory")
+	}
+
+	mempools := dir.HasNotStakedException("0x0000000000000000000000000000000000000000")
+	if len(mempools) != 1 || mempools[0] != id {
+		t.Fatalf("got %v, want [1]", mempools)
+	}
+
+	mempools = dir.HasNotStakedException("paymaster")
+	if len(mempools) != 0 {
+		t.Fatalf("got %v, want []", mempools)
+	}
+}
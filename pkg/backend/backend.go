@@ -0,0 +1,70 @@
+// Package backend defines the narrow interfaces used by modules to access an Ethereum node. Both
+// *ethclient.Client and go-ethereum's simulated backend satisfy every interface except TraceCaller and
+// BatchCaller, which are satisfied by *rpc.Client.
+package backend
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CodeReader reads the code deployed at an address.
+type CodeReader interface {
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// HeaderReader reads block headers. A nil block number returns the latest header.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// NonceReader reads the nonce of an account.
+type NonceReader interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// FeeReader reads the suggested fees for a transaction.
+type FeeReader interface {
+	HeaderReader
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// ContractCaller calls contracts through the generated bindings.
+type ContractCaller interface {
+	bind.ContractBackend
+}
+
+// TxSender submits transactions to contracts and waits for them to be mined.
+type TxSender interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	NonceReader
+}
+
+// TxReader reads transactions and their receipts.
+type TxReader interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// EventReader filters contract events and reads the transactions that emitted them.
+type EventReader interface {
+	ContractCaller
+	TxReader
+}
+
+// TraceCaller makes raw JSON-RPC calls for methods without a typed client, such as debug_traceCall.
+type TraceCaller interface {
+	CallContext(ctx context.Context, result any, method string, args ...any) error
+}
+
+// BatchCaller sends multiple JSON-RPC requests in a single batch.
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
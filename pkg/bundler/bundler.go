@@ -34,6 +34,7 @@ type Bundler struct {
 	gbf                  gasprice.GetBaseFeeFunc
 	ggt                  gasprice.GetGasTipFunc
 	ggp                  gasprice.GetLegacyGasPriceFunc
+	stats                *BundleStats
 }
 
 // New initializes a new EIP-4337 bundler which can be extended with modules for validating batches and
@@ -53,6 +54,7 @@ func New(mempool *mempool.Mempool, chainID *big.Int, supportedEntryPoints []comm
 		gbf:                  gasprice.NoopGetBaseFeeFunc(),
 		ggt:                  gasprice.NoopGetGasTipFunc(),
 		ggp:                  gasprice.NoopGetLegacyGasPriceFunc(),
+		stats:                newBundleStats(DefaultBundleStatsWindow, DefaultBundleStatsMinSamples),
 	}
 }
 
@@ -77,6 +79,11 @@ func (i *Bundler) SetGetLegacyGasPriceFunc(ggp gasprice.GetLegacyGasPriceFunc) {
 	i.ggp = ggp
 }
 
+// Stats returns statistics on recent bundles sent by the Bundler.
+func (i *Bundler) Stats() *BundleStats {
+	return i.stats
+}
+
 // UseLogger defines the logger object used by the Bundler instance based on the go-logr/logr interface.
 func (i *Bundler) UseLogger(logger logr.Logger) {
 	i.logger = logger.WithName("bundler")
@@ -118,24 +125,23 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 		WithValues("entrypoint", ep.String()).
 		WithValues("chain_id", i.chainID.String())
 
-	// Get all pending userOps from the mempool. This will be in FIFO order. Downstream modules should sort it
-	// based on more specific strategies.
-	batch, err := i.mempool.Dump(ep)
+	// Get current block basefee
+	bf, err := i.gbf()
 	if err != nil {
 		l.Error(err, "bundler run error")
 		return nil, err
 	}
-	if len(batch) == 0 {
-		return nil, nil
-	}
-	batch = adjustBatchSize(i.maxBatch, batch)
 
-	// Get current block basefee
-	bf, err := i.gbf()
+	// Get the highest priced userOps from the mempool. Ops from the same sender will be ordered by nonce.
+	// Downstream modules can filter it based on more specific strategies.
+	batch, err := i.mempool.Best(ep, i.maxBatch, bf)
 	if err != nil {
 		l.Error(err, "bundler run error")
 		return nil, err
 	}
+	if len(batch) == 0 {
+		return nil, nil
+	}
 
 	// Get suggested gas tip
 	var gt *big.Int
@@ -174,6 +180,9 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 		l.Error(err, "bundler run error")
 		return nil, err
 	}
+	if len(ctx.Batch) > 0 {
+		i.stats.add(len(ctx.Batch))
+	}
 
 	// Update logs for the current run.
 	bat := []string{}
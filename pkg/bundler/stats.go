@@ -0,0 +1,56 @@
+package bundler
+
+import "sync"
+
+var (
+	// DefaultBundleStatsWindow is the number of recent bundles used to calculate the expected bundle size.
+	DefaultBundleStatsWindow = 50
+
+	// DefaultBundleStatsMinSamples is the number of bundles that must be recorded before the expected bundle
+	// size is derived from them.
+	DefaultBundleStatsMinSamples = 10
+)
+
+// BundleStats keeps track of the number of UserOperations included in recent bundles.
+type BundleStats struct {
+	mu         sync.Mutex
+	sizes      []int
+	next       int
+	count      int
+	minSamples int
+}
+
+func newBundleStats(window int, minSamples int) *BundleStats {
+	return &BundleStats{
+		sizes:      make([]int, window),
+		minSamples: minSamples,
+	}
+}
+
+func (s *BundleStats) add(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sizes[s.next] = size
+	s.next = (s.next + 1) % len(s.sizes)
+	if s.count < len(s.sizes) {
+		s.count++
+	}
+}
+
+// ExpectedSize returns the mean number of UserOperations in recent bundles. If not enough bundles have been
+// recorded, it returns 1.
+func (s *BundleStats) ExpectedSize() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count == 0 || s.count < s.minSamples {
+		return 1
+	}
+
+	sum := 0
+	for i := 0; i < s.count; i++ {
+		sum += s.sizes[i]
+	}
+	return float64(sum) / float64(s.count)
+}
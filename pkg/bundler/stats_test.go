@@ -0,0 +1,26 @@
+package bundler
+
+import "testing"
+
+// TestBundleStatsExpectedSize verifies that the expected size is 1 until enough bundles are recorded and is
+// then the mean over the window.
+func TestBundleStatsExpectedSize(t *testing.T) {
+	s := newBundleStats(4, 2)
+	s.add(3)
+	if size := s.ExpectedSize(); size != 1 {
+		t.Fatalf("got %v, want 1", size)
+	}
+
+	s.add(5)
+	if size := s.ExpectedSize(); size != 4 {
+		t.Fatalf("got %v, want 4", size)
+	}
+
+	// Older sizes are dropped once the window is full.
+	s.add(2)
+	s.add(2)
+	s.add(2)
+	if size := s.ExpectedSize(); size != 2.75 {
+		t.Fatalf("got %v, want 2.75", size)
+	}
+}
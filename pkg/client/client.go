@@ -2,6 +2,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"math/big"
 
@@ -11,11 +12,13 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	bundlerErrors "github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
 	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
@@ -27,9 +30,11 @@ type Client struct {
 	chainID              *big.Int
 	supportedEntryPoints []common.Address
 	userOpHandler        modules.UserOpHandlerFunc
+	estimateHandler      modules.UserOpHandlerFunc
 	logger               logr.Logger
 	getUserOpReceipt     GetUserOpReceiptFunc
 	getGasPrices         GetGasPricesFunc
+	getFeeTiers          GetFeeTiersFunc
 	getGasEstimate       GetGasEstimateFunc
 	getUserOpByHash      GetUserOpByHashFunc
 	getStakeFunc         stake.GetStakeFunc
@@ -51,9 +56,11 @@ func New(
 		chainID:              chainID,
 		supportedEntryPoints: supportedEntryPoints,
 		userOpHandler:        noop.UserOpHandler,
+		estimateHandler:      noop.UserOpHandler,
 		logger:               logger.NewZeroLogr().WithName("client"),
 		getUserOpReceipt:     getUserOpReceiptNoop(),
 		getGasPrices:         getGasPricesNoop(),
+		getFeeTiers:          getFeeTiersNoop(),
 		getGasEstimate:       getGasEstimateNoop(),
 		getUserOpByHash:      getUserOpByHashNoop(),
 		getStakeFunc:         stake.GetStakeFuncNoop(),
@@ -62,13 +69,18 @@ func New(
 }
 
 func (i *Client) parseEntryPointAddress(ep string) (common.Address, error) {
+	addr := common.HexToAddress(ep)
+	return addr, i.checkEntryPoint(addr)
+}
+
+func (i *Client) checkEntryPoint(ep common.Address) error {
 	for _, addr := range i.supportedEntryPoints {
-		if common.HexToAddress(ep) == addr {
-			return addr, nil
+		if ep == addr {
+			return nil
 		}
 	}
 
-	return common.Address{}, errors.New("entryPoint: Implementation not supported")
+	return errors.New("entryPoint: Implementation not supported")
 }
 
 // UseLogger defines the logger object used by the Client instance based on the go-logr/logr interface.
@@ -81,6 +93,12 @@ func (i *Client) UseModules(handlers ...modules.UserOpHandlerFunc) {
 	i.userOpHandler = modules.ComposeUserOpHandlerFunc(handlers...)
 }
 
+// UseEstimateModules defines the UserOpHandlers to check a userOp before its gas is estimated. The context
+// passed to the handlers only has the userOp, EntryPoint, chain ID, and tenant ID set.
+func (i *Client) UseEstimateModules(handlers ...modules.UserOpHandlerFunc) {
+	i.estimateHandler = modules.ComposeUserOpHandlerFunc(handlers...)
+}
+
 // SetGetUserOpReceiptFunc defines a general function for fetching a UserOpReceipt given a userOpHash and
 // EntryPoint address. This function is called in *Client.GetUserOperationReceipt.
 func (i *Client) SetGetUserOpReceiptFunc(fn GetUserOpReceiptFunc) {
@@ -94,6 +112,13 @@ func (i *Client) SetGetGasPricesFunc(fn GetGasPricesFunc) {
 	i.getGasPrices = fn
 }
 
+// SetGetFeeTiersFunc defines a general function for fetching suggested fees at different levels of inclusion
+// speed. This function is called in *Client.EstimateUserOperationGas to return recommended fees and the
+// required prefund alongside the gas limits.
+func (i *Client) SetGetFeeTiersFunc(fn GetFeeTiersFunc) {
+	i.getFeeTiers = fn
+}
+
 // SetGetGasEstimateFunc defines a general function for fetching an estimate for verificationGasLimit and
 // callGasLimit given a userOp and EntryPoint address. This function is called in
 // *Client.EstimateUserOperationGas.
@@ -116,24 +141,50 @@ func (i *Client) SetGetStakeFunc(fn stake.GetStakeFunc) {
 // SendUserOperation implements the method call for eth_sendUserOperation.
 // It returns true if userOp was accepted otherwise returns an error.
 func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error) {
-	// Init logger
-	l := i.logger.WithName("eth_sendUserOperation")
+	return i.SendUserOperationWithContext(context.Background(), op, ep)
+}
 
+// SendUserOperationWithContext is the same as SendUserOperation except the tenant attached to reqCtx, if any,
+// is passed on to the client module stack.
+func (i *Client) SendUserOperationWithContext(
+	reqCtx context.Context,
+	op map[string]any,
+	ep string,
+) (string, error) {
 	// Check EntryPoint and userOp is valid.
 	epAddr, err := i.parseEntryPointAddress(ep)
 	if err != nil {
-		l.Error(err, "eth_sendUserOperation error")
+		i.logger.WithName("eth_sendUserOperation").Error(err, "eth_sendUserOperation error")
 		return "", err
 	}
-	l = l.
-		WithValues("entrypoint", epAddr.String()).
-		WithValues("chain_id", i.chainID.String())
-
 	userOp, err := userop.New(op)
 	if err != nil {
+		i.logger.WithName("eth_sendUserOperation").Error(err, "eth_sendUserOperation error")
+		return "", err
+	}
+
+	return i.sendUserOperation(reqCtx, userOp, epAddr)
+}
+
+// sendUserOperation is the same as SendUserOperationWithContext with an already decoded userOp and
+// EntryPoint address.
+func (i *Client) sendUserOperation(
+	reqCtx context.Context,
+	userOp *userop.UserOperation,
+	epAddr common.Address,
+) (string, error) {
+	// Init logger
+	l := i.logger.WithName("eth_sendUserOperation")
+
+	// Check EntryPoint is supported.
+	if err := i.checkEntryPoint(epAddr); err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
 	}
+	l = l.
+		WithValues("entrypoint", epAddr.String()).
+		WithValues("chain_id", i.chainID.String())
+
 	hash := userOp.GetUserOpHash(epAddr, i.chainID)
 	l = l.WithValues("userop_hash", hash)
 
@@ -149,6 +200,7 @@ func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error)
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
 	}
+	ctx.TenantID = tenant.IDFromContext(reqCtx)
 	if err := i.userOpHandler(ctx); err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
@@ -157,6 +209,12 @@ func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error)
 	// Add userOp to mempool.
 	if err := i.mempool.AddOp(epAddr, ctx.UserOp); err != nil {
 		l.Error(err, "eth_sendUserOperation error")
+		switch {
+		case errors.Is(err, mempool.ErrMempoolFull):
+			return "", bundlerErrors.NewRPCError(bundlerErrors.MEMPOOL_FULL, err.Error(), nil)
+		case errors.Is(err, mempool.ErrSenderLimitReached):
+			return "", bundlerErrors.NewRPCError(bundlerErrors.BANNED_OR_THROTTLED_ENTITY, err.Error(), nil)
+		}
 		return "", err
 	}
 
@@ -173,30 +231,67 @@ func (i *Client) EstimateUserOperationGas(
 	ep string,
 	os map[string]any,
 ) (*gas.GasEstimates, error) {
-	// Init logger
-	l := i.logger.WithName("eth_estimateUserOperationGas")
+	return i.EstimateUserOperationGasWithContext(context.Background(), op, ep, os)
+}
 
-	// Check EntryPoint and userOp is valid.
+// EstimateUserOperationGasWithContext is the same as EstimateUserOperationGas except the tenant attached to
+// reqCtx, if any, is passed on to the estimate module stack.
+func (i *Client) EstimateUserOperationGasWithContext(
+	reqCtx context.Context,
+	op map[string]any,
+	ep string,
+	os map[string]any,
+) (*gas.GasEstimates, error) {
+	// Check EntryPoint, userOp and state override set are valid.
 	epAddr, err := i.parseEntryPointAddress(ep)
 	if err != nil {
-		l.Error(err, "eth_estimateUserOperationGas error")
+		i.logger.WithName("eth_estimateUserOperationGas").Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
 	}
-	l = l.
-		WithValues("entrypoint", epAddr.String()).
-		WithValues("chain_id", i.chainID.String())
-
 	userOp, err := userop.New(op)
 	if err != nil {
+		i.logger.WithName("eth_estimateUserOperationGas").Error(err, "eth_estimateUserOperationGas error")
+		return nil, err
+	}
+	sos, err := state.ParseOverrideData(os)
+	if err != nil {
+		i.logger.WithName("eth_estimateUserOperationGas").Error(err, "eth_estimateUserOperationGas error")
+		return nil, err
+	}
+
+	return i.estimateUserOperationGas(reqCtx, userOp, epAddr, sos)
+}
+
+// estimateUserOperationGas is the same as EstimateUserOperationGasWithContext with an already decoded userOp,
+// EntryPoint address, and state override set.
+func (i *Client) estimateUserOperationGas(
+	reqCtx context.Context,
+	userOp *userop.UserOperation,
+	epAddr common.Address,
+	sos state.OverrideSet,
+) (*gas.GasEstimates, error) {
+	// Init logger
+	l := i.logger.WithName("eth_estimateUserOperationGas")
+
+	// Check EntryPoint is supported.
+	if err := i.checkEntryPoint(epAddr); err != nil {
 		l.Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
 	}
+	l = l.
+		WithValues("entrypoint", epAddr.String()).
+		WithValues("chain_id", i.chainID.String())
+
 	hash := userOp.GetUserOpHash(epAddr, i.chainID)
 	l = l.WithValues("userop_hash", hash)
 
-	// Parse state override set.
-	sos, err := state.ParseOverrideData(os)
-	if err != nil {
+	// Run through estimate module stack.
+	if err := i.estimateHandler(&modules.UserOpHandlerCtx{
+		UserOp:     userOp,
+		EntryPoint: epAddr,
+		ChainID:    i.chainID,
+		TenantID:   tenant.IDFromContext(reqCtx),
+	}); err != nil {
 		l.Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
 	}
@@ -215,11 +310,12 @@ func (i *Client) EstimateUserOperationGas(
 	}
 
 	// Estimate gas limits
-	vg, cg, err := i.getGasEstimate(epAddr, userOp, sos)
+	est, err := i.getGasEstimate(epAddr, userOp, sos)
 	if err != nil {
 		l.Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
 	}
+	l = l.WithValues("estimate", est)
 
 	// Calculate PreVerificationGas
 	pvg, err := i.ov.CalcPreVerificationGasWithBuffer(userOp)
@@ -228,15 +324,36 @@ func (i *Client) EstimateUserOperationGas(
 		return nil, err
 	}
 
-	l.Info("eth_estimateUserOperationGas ok")
-	return &gas.GasEstimates{
+	res := &gas.GasEstimates{
 		PreVerificationGas:   pvg,
-		VerificationGasLimit: big.NewInt(int64(vg)),
-		CallGasLimit:         big.NewInt(int64(cg)),
+		VerificationGasLimit: big.NewInt(0).SetUint64(est.VerificationGasLimit),
+		CallGasLimit:         big.NewInt(0).SetUint64(est.CallGasLimit),
 
 		// TODO: Deprecate in v0.7
-		VerificationGas: big.NewInt(int64(vg)),
-	}, nil
+		VerificationGas: big.NewInt(0).SetUint64(est.VerificationGasLimit),
+
+		FactoryGas:               big.NewInt(0).SetUint64(est.FactoryGas),
+		AccountVerificationGas:   big.NewInt(0).SetUint64(est.AccountVerificationGas),
+		PaymasterVerificationGas: big.NewInt(0).SetUint64(est.PaymasterVerificationGas),
+		PostOpGas:                big.NewInt(0).SetUint64(est.PostOpGas),
+	}
+
+	// Suggest fees for each tier and the prefund required at those fees with the estimated gas limits.
+	ft, err := i.getFeeTiers()
+	if err != nil {
+		l.Error(err, "eth_estimateUserOperationGas error")
+		return nil, err
+	}
+	if ft != nil {
+		estOp := *userOp
+		estOp.PreVerificationGas = res.PreVerificationGas
+		estOp.VerificationGasLimit = res.VerificationGasLimit
+		estOp.CallGasLimit = res.CallGasLimit
+		res.Fees = gas.NewFeeEstimates(&estOp, ft)
+	}
+
+	l.Info("eth_estimateUserOperationGas ok")
+	return res, nil
 }
 
 // GetUserOperationReceipt fetches a UserOperation receipt based on a userOpHash returned by
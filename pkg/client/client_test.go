@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tenant"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+type rpcResponse struct {
+	Result map[string]json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int            `json:"code"`
+		Message string         `json:"message"`
+		Data    map[string]any `json:"data"`
+	} `json:"error"`
+}
+
+func estimateViaRpc(t *testing.T, fn GetGasEstimateFunc) *rpcResponse {
+	t.Helper()
+	c := New(nil, gas.NewDefaultOverhead(), big.NewInt(1), []common.Address{testutils.ValidAddress1}, 0)
+	c.SetGetGasEstimateFunc(fn)
+
+	params, _ := json.Marshal([]any{testutils.MockUserOpData, testutils.ValidAddress1.Hex()})
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_estimateUserOperationGas","params":%s}`, params)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	jsonrpc.Controller(NewRpcRegistry(c, nil), nil)(ctx)
+
+	var res rpcResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return &res
+}
+
+// TestEstimateUserOperationGasReturnsBreakdown calls eth_estimateUserOperationGas through the RPC registry.
+// Expect the per-entity gas breakdown from the estimator to be included in the result.
+func TestEstimateUserOperationGasReturnsBreakdown(t *testing.T) {
+	res := estimateViaRpc(t, func(
+		ep common.Address,
+		op *userop.UserOperation,
+		sos state.OverrideSet,
+	) (*gas.Estimate, error) {
+		return &gas.Estimate{
+			VerificationGasLimit:     100000,
+			CallGasLimit:             50000,
+			FactoryGas:               60000,
+			AccountVerificationGas:   30000,
+			PaymasterVerificationGas: 10000,
+			PostOpGas:                5000,
+		}, nil
+	})
+	if res.Error != nil {
+		t.Fatalf("got %v, want nil", res.Error.Message)
+	}
+
+	want := map[string]string{
+		"verificationGasLimit":     "100000",
+		"callGasLimit":             "50000",
+		"factoryGas":               "60000",
+		"accountVerificationGas":   "30000",
+		"paymasterVerificationGas": "10000",
+		"postOpGas":                "5000",
+	}
+	for k, v := range want {
+		if string(res.Result[k]) != v {
+			t.Fatalf("%s: got %s, want %s", k, res.Result[k], v)
+		}
+	}
+}
+
+// TestEstimateUserOperationGasReturnsRevertReason calls eth_estimateUserOperationGas through the RPC
+// registry with an estimator that reverts. Expect the AA code and reason to be included in the error data.
+func TestEstimateUserOperationGasReturnsRevertReason(t *testing.T) {
+	target := testutils.ValidAddress2
+	res := estimateViaRpc(t, func(
+		ep common.Address,
+		op *userop.UserOperation,
+		sos state.OverrideSet,
+	) (*gas.Estimate, error) {
+		return nil, errors.NewRPCError(
+			errors.REJECTED_BY_EP_OR_ACCOUNT,
+			"AA23 reverted: bad signature",
+			&gas.EstimateRevert{Code: "AA23", Reason: "bad signature", Entity: "account", Target: &target},
+		)
+	})
+	if res.Error == nil {
+		t.Fatal("got nil, want err")
+	}
+
+	if res.Error.Code != errors.REJECTED_BY_EP_OR_ACCOUNT {
+		t.Fatalf("got %d, want %d", res.Error.Code, errors.REJECTED_BY_EP_OR_ACCOUNT)
+	}
+	if res.Error.Data["code"] != "AA23" {
+		t.Fatalf("got %v, want AA23", res.Error.Data["code"])
+	}
+	if res.Error.Data["reason"] != "bad signature" {
+		t.Fatalf("got %v, want bad signature", res.Error.Data["reason"])
+	}
+	if res.Error.Data["entity"] != "account" {
+		t.Fatalf("got %v, want account", res.Error.Data["entity"])
+	}
+}
+
+// TestEstimateUserOperationGasWithContextChecksTenant calls Client.EstimateUserOperationGasWithContext for a
+// tenant that is not allowed to use the sender. Expect an UNAUTHORIZED error before gas is estimated.
+func TestEstimateUserOperationGasWithContextChecksTenant(t *testing.T) {
+	dir := tenant.NewDirectory()
+	acme := tenant.New("acme")
+	acme.AllowedSenders.Add(testutils.ValidAddress2)
+	if err := dir.Add(acme, "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	c := New(nil, gas.NewDefaultOverhead(), big.NewInt(1), []common.Address{testutils.ValidAddress1}, 0)
+	c.UseEstimateModules(dir.CheckUserOp())
+	c.SetGetGasEstimateFunc(func(
+		ep common.Address,
+		op *userop.UserOperation,
+		sos state.OverrideSet,
+	) (*gas.Estimate, error) {
+		called = true
+		return &gas.Estimate{}, nil
+	})
+
+	ctx := tenant.WithContext(context.Background(), acme)
+	ep := testutils.ValidAddress1.Hex()
+	_, err := c.EstimateUserOperationGasWithContext(ctx, testutils.MockUserOpData, ep, nil)
+	if rpcErr, ok := err.(*errors.RPCError); !ok || rpcErr.Code() != errors.UNAUTHORIZED {
+		t.Fatalf("got err %v, want UNAUTHORIZED", err)
+	}
+	if called {
+		t.Fatal("got estimate called, want not called")
+	}
+}
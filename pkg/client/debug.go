@@ -7,7 +7,6 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
@@ -17,7 +16,6 @@ import (
 // Debug exposes methods used for testing the bundler. These should not be made available in production.
 type Debug struct {
 	eoa         *signer.EOA
-	eth         *ethclient.Client
 	mempool     *mempool.Mempool
 	rep         *entities.Reputation
 	bundler     *bundler.Bundler
@@ -28,7 +26,6 @@ type Debug struct {
 
 func NewDebug(
 	eoa *signer.EOA,
-	eth *ethclient.Client,
 	mempool *mempool.Mempool,
 	rep *entities.Reputation,
 	bundler *bundler.Bundler,
@@ -36,7 +33,7 @@ func NewDebug(
 	entrypoint common.Address,
 	beneficiary common.Address,
 ) *Debug {
-	return &Debug{eoa, eth, mempool, rep, bundler, chainID, entrypoint, beneficiary}
+	return &Debug{eoa, mempool, rep, bundler, chainID, entrypoint, beneficiary}
 }
 
 // ClearState clears the bundler mempool and reputation data of paymasters/accounts/factories/aggregators.
@@ -48,9 +45,11 @@ func (d *Debug) ClearState() (string, error) {
 	return "ok", nil
 }
 
-// DumpMempool dumps the current UserOperations mempool in order of arrival.
-func (d *Debug) DumpMempool(ep string) ([]map[string]any, error) {
-	ops, err := d.mempool.Dump(common.HexToAddress(ep))
+// DumpMempool dumps the current UserOperations mempool in order of arrival. If withStatus is true, each item
+// will include a status field set to either "ready" or "queued" if it is waiting on a nonce gap to be filled.
+func (d *Debug) DumpMempool(ep string, withStatus bool) ([]map[string]any, error) {
+	epAddr := common.HexToAddress(ep)
+	ops, err := d.mempool.Dump(epAddr)
 	if err != nil {
 		return []map[string]any{}, err
 	}
@@ -66,6 +65,17 @@ func (d *Debug) DumpMempool(ep string) ([]map[string]any, error) {
 		if err := json.Unmarshal(data, &item); err != nil {
 			return []map[string]any{}, err
 		}
+		if withStatus {
+			ready, err := d.mempool.IsReady(epAddr, op)
+			if err != nil {
+				return []map[string]any{}, err
+			}
+
+			item["status"] = "queued"
+			if ready {
+				item["status"] = "ready"
+			}
+		}
 
 		res = append(res, item)
 	}
@@ -123,6 +133,11 @@ func (d *Debug) SetReputation(entries []any, ep string) (string, error) {
 
 		roArr = append(roArr, ro)
 	}
+
+	return d.setReputation(roArr)
+}
+
+func (d *Debug) setReputation(roArr []*entities.ReputationOverride) (string, error) {
 	if err := d.rep.Override(roArr); err != nil {
 		return "", err
 	}
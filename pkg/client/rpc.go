@@ -1,19 +1,29 @@
 package client
 
 import (
+	"context"
 	"errors"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
-// Named UserOperation type for jsonrpc package.
-type userOperation map[string]any
+// rpcVersion is the version of the JSON-RPC API reported by rpc.discover. It tracks the supported EntryPoint
+// version.
+const rpcVersion = "0.6.0"
 
-// Named StateOverride type for jsonrpc package.
-type optional_stateOverride map[string]any
+// rpcDumpMempoolOptions is the options param of debug_bundler_dumpMempool.
+type rpcDumpMempoolOptions struct {
+	WithStatus bool `json:"withStatus"`
+}
 
-// RpcAdapter is an adapter for routing JSON-RPC method calls to the correct client functions.
+// RpcAdapter is an adapter for routing JSON-RPC method calls to the correct client functions. See
+// NewRpcRegistry for the method names and params.
 type RpcAdapter struct {
 	client *Client
 	debug  *Debug
@@ -24,32 +34,96 @@ func NewRpcAdapter(client *Client, debug *Debug) *RpcAdapter {
 	return &RpcAdapter{client, debug}
 }
 
-// Eth_sendUserOperation routes method calls to *Client.SendUserOperation.
-func (r *RpcAdapter) Eth_sendUserOperation(op userOperation, ep string) (string, error) {
-	return r.client.SendUserOperation(op, ep)
+// NewRpcRegistry returns a JSON-RPC method registry with all the methods supported by the Client and Debug
+// APIs.
+func NewRpcRegistry(client *Client, debug *Debug) *jsonrpc.Registry {
+	r := NewRpcAdapter(client, debug)
+	reg := jsonrpc.NewRegistry(jsonrpc.Info{Title: "Stackup Bundler", Version: rpcVersion})
+	reg.Register(
+		jsonrpc.NewMethod(
+			"eth_sendUserOperation",
+			r.Eth_sendUserOperation,
+			jsonrpc.Param{Name: "userOperation"},
+			jsonrpc.Param{Name: "entryPoint"},
+		),
+		jsonrpc.NewMethod(
+			"eth_estimateUserOperationGas",
+			r.Eth_estimateUserOperationGas,
+			jsonrpc.Param{Name: "userOperation"},
+			jsonrpc.Param{Name: "entryPoint"},
+			jsonrpc.Param{Name: "stateOverride", Optional: true},
+		),
+		jsonrpc.NewMethod(
+			"eth_getUserOperationReceipt",
+			r.Eth_getUserOperationReceipt,
+			jsonrpc.Param{Name: "userOpHash"},
+		),
+		jsonrpc.NewMethod(
+			"eth_getUserOperationByHash",
+			r.Eth_getUserOperationByHash,
+			jsonrpc.Param{Name: "userOpHash"},
+		),
+		jsonrpc.NewMethod("eth_supportedEntryPoints", r.Eth_supportedEntryPoints),
+		jsonrpc.NewMethod("eth_chainId", r.Eth_chainId),
+		jsonrpc.NewMethod("debug_bundler_clearState", r.Debug_bundler_clearState),
+		jsonrpc.NewMethod(
+			"debug_bundler_dumpMempool",
+			r.Debug_bundler_dumpMempool,
+			jsonrpc.Param{Name: "entryPoint"},
+			jsonrpc.Param{Name: "options", Optional: true},
+		),
+		jsonrpc.NewMethod("debug_bundler_sendBundleNow", r.Debug_bundler_sendBundleNow),
+		jsonrpc.NewMethod(
+			"debug_bundler_setBundlingMode",
+			r.Debug_bundler_setBundlingMode,
+			jsonrpc.Param{Name: "mode"},
+		),
+		jsonrpc.NewMethod(
+			"debug_bundler_setReputation",
+			r.Debug_bundler_setReputation,
+			jsonrpc.Param{Name: "reputations"},
+			jsonrpc.Param{Name: "entryPoint"},
+		),
+		jsonrpc.NewMethod(
+			"debug_bundler_dumpReputation",
+			r.Debug_bundler_dumpReputation,
+			jsonrpc.Param{Name: "entryPoint"},
+		),
+	)
+	return reg
+}
+
+// Eth_sendUserOperation routes method calls to *Client.SendUserOperationWithContext.
+func (r *RpcAdapter) Eth_sendUserOperation(
+	ctx context.Context,
+	op *userop.UserOperation,
+	ep common.Address,
+) (string, error) {
+	return r.client.sendUserOperation(ctx, op, ep)
 }
 
-// Eth_estimateUserOperationGas routes method calls to *Client.EstimateUserOperationGas.
+// Eth_estimateUserOperationGas routes method calls to *Client.EstimateUserOperationGasWithContext.
 func (r *RpcAdapter) Eth_estimateUserOperationGas(
-	op userOperation,
-	ep string,
-	os optional_stateOverride,
+	ctx context.Context,
+	op *userop.UserOperation,
+	ep common.Address,
+	os state.OverrideSet,
 ) (*gas.GasEstimates, error) {
-	return r.client.EstimateUserOperationGas(op, ep, os)
+	return r.client.estimateUserOperationGas(ctx, op, ep, os)
 }
 
 // Eth_getUserOperationReceipt routes method calls to *Client.GetUserOperationReceipt.
 func (r *RpcAdapter) Eth_getUserOperationReceipt(
-	userOpHash string,
+	userOpHash common.Hash,
 ) (*filter.UserOperationReceipt, error) {
-	return r.client.GetUserOperationReceipt(userOpHash)
+	return r.client.GetUserOperationReceipt(userOpHash.Hex())
 }
 
 // Eth_getUserOperationByHash routes method calls to *Client.GetUserOperationByHash.
 func (r *RpcAdapter) Eth_getUserOperationByHash(
-	userOpHash string,
+	userOpHash common.Hash,
 ) (*filter.HashLookupResult, error) {
-	return r.client.GetUserOperationByHash(userOpHash)
+	return r.client.GetUserOperationByHash(userOpHash.Hex())
 }
 
 // Eth_supportedEntryPoints routes method calls to *Client.SupportedEntryPoints.
@@ -72,12 +146,15 @@ func (r *RpcAdapter) Debug_bundler_clearState() (string, error) {
 }
 
 // Debug_bundler_dumpMempool routes method calls to *Debug.DumpMempool.
-func (r *RpcAdapter) Debug_bundler_dumpMempool(ep string) ([]map[string]any, error) {
+func (r *RpcAdapter) Debug_bundler_dumpMempool(
+	ep common.Address,
+	opts rpcDumpMempoolOptions,
+) ([]map[string]any, error) {
 	if r.debug == nil {
 		return []map[string]any{}, errors.New("rpc: debug mode is not enabled")
 	}
 
-	return r.debug.DumpMempool(ep)
+	return r.debug.DumpMempool(ep.Hex(), opts.WithStatus)
 }
 
 // Debug_bundler_sendBundleNow routes method calls to *Debug.SendBundleNow.
@@ -99,19 +176,22 @@ func (r *RpcAdapter) Debug_bundler_setBundlingMode(mode string) (string, error)
 }
 
 // Debug_bundler_setReputation routes method calls to *Debug.SetReputation.
-func (r *RpcAdapter) Debug_bundler_setReputation(entries []any, ep string) (string, error) {
+func (r *RpcAdapter) Debug_bundler_setReputation(
+	entries []*entities.ReputationOverride,
+	ep common.Address,
+) (string, error) {
 	if r.debug == nil {
 		return "", errors.New("rpc: debug mode is not enabled")
 	}
 
-	return r.debug.SetReputation(entries, ep)
+	return r.debug.setReputation(entries)
 }
 
 // Debug_bundler_dumpReputation routes method calls to *Debug.DumpReputation.
-func (r *RpcAdapter) Debug_bundler_dumpReputation(ep string) ([]map[string]any, error) {
+func (r *RpcAdapter) Debug_bundler_dumpReputation(ep common.Address) ([]map[string]any, error) {
 	if r.debug == nil {
 		return []map[string]any{}, errors.New("rpc: debug mode is not enabled")
 	}
 
-	return r.debug.DumpReputation(ep)
+	return r.debug.DumpReputation(ep.Hex())
 }
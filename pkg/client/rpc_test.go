@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// callRpc sends a single JSON-RPC request to the registry and returns the raw result.
+func callRpc(t *testing.T, reg *jsonrpc.Registry, method string, params string) json.RawMessage {
+	t.Helper()
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":%s}`, method, params)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	jsonrpc.Controller(reg, nil)(ctx)
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  any             `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Error != nil {
+		t.Fatalf("got %v, want nil", res.Error)
+	}
+	return res.Result
+}
+
+// TestNewRpcRegistry calls client.NewRpcRegistry. Expect every method to match its handler and be listed
+// alongside rpc.discover.
+func TestNewRpcRegistry(t *testing.T) {
+	methods := NewRpcRegistry(nil, nil).Methods()
+	if len(methods) != 13 {
+		t.Fatalf("got %d methods, want 13", len(methods))
+	}
+
+	found := false
+	for _, m := range methods {
+		if m.Name == jsonrpc.DiscoverMethod {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("%s not registered", jsonrpc.DiscoverMethod)
+	}
+}
+
+// TestNewRpcRegistryDiscover calls rpc.discover on the registry from client.NewRpcRegistry. Expect the
+// userOperation and stateOverride params of eth_estimateUserOperationGas to describe their fields.
+func TestNewRpcRegistryDiscover(t *testing.T) {
+	res := callRpc(t, NewRpcRegistry(nil, nil), jsonrpc.DiscoverMethod, "[]")
+	var doc jsonrpc.OpenRPCDocument
+	if err := json.Unmarshal(res, &doc); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	var md *jsonrpc.MethodDescriptor
+	for _, m := range doc.Methods {
+		if m.Name == "eth_estimateUserOperationGas" {
+			md = m
+		}
+	}
+	if md == nil || len(md.Params) != 3 {
+		t.Fatalf("got %v, want eth_estimateUserOperationGas with 3 params", md)
+	}
+
+	op, _ := md.Params[0].Schema["properties"].(map[string]any)
+	if len(op) != 11 {
+		t.Fatalf("got %d userOperation properties, want 11", len(op))
+	}
+	if s, _ := op["nonce"].(map[string]any); s["type"] != "string" {
+		t.Fatalf("got nonce schema %v, want string", op["nonce"])
+	}
+
+	account, _ := md.Params[2].Schema["additionalProperties"].(map[string]any)
+	props, _ := account["properties"].(map[string]any)
+	if _, ok := props["stateDiff"]; !ok {
+		t.Fatalf("got stateOverride schema %v, want account properties", md.Params[2].Schema)
+	}
+}
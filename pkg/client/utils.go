@@ -6,6 +6,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
 	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
@@ -25,7 +26,7 @@ func getUserOpReceiptNoop() GetUserOpReceiptFunc {
 
 // GetUserOpReceiptWithEthClient returns an implementation of GetUserOpReceiptFunc that relies on an eth
 // client to fetch a UserOperationReceipt.
-func GetUserOpReceiptWithEthClient(eth *ethclient.Client) GetUserOpReceiptFunc {
+func GetUserOpReceiptWithEthClient(eth backend.EventReader) GetUserOpReceiptFunc {
 	return func(hash string, ep common.Address, blkRange uint64) (*filter.UserOperationReceipt, error) {
 		return filter.GetUserOperationReceipt(eth, hash, ep, blkRange)
 	}
@@ -45,27 +46,61 @@ func getGasPricesNoop() GetGasPricesFunc {
 
 // GetGasPricesWithEthClient returns an implementation of GetGasPricesFunc that relies on an eth client to
 // fetch values for maxFeePerGas and maxPriorityFeePerGas.
+//
+// Deprecated: Use GetGasPricesWithFeeOracle.
 func GetGasPricesWithEthClient(eth *ethclient.Client) GetGasPricesFunc {
 	return func() (*fees.GasPrices, error) {
 		return fees.NewGasPrices(eth)
 	}
 }
 
+// GetGasPricesWithFeeOracle returns an implementation of GetGasPricesFunc that relies on the cached fee
+// history of a fee Oracle.
+func GetGasPricesWithFeeOracle(o *fees.Oracle) GetGasPricesFunc {
+	return o.GasPrices
+}
+
+// GetFeeTiersFunc is a general interface for fetching suggested fees at different levels of inclusion
+// speed.
+type GetFeeTiersFunc = func() (*fees.FeeTiers, error)
+
+func getFeeTiersNoop() GetFeeTiersFunc {
+	return func() (*fees.FeeTiers, error) {
+		return nil, nil
+	}
+}
+
+// GetFeeTiersWithEthClient returns an implementation of GetFeeTiersFunc that relies on an eth client to
+// fetch suggested fees from the fee history of recent blocks.
+//
+// Deprecated: Use GetFeeTiersWithFeeOracle.
+func GetFeeTiersWithEthClient(eth *ethclient.Client) GetFeeTiersFunc {
+	return func() (*fees.FeeTiers, error) {
+		return fees.NewFeeTiers(eth)
+	}
+}
+
+// GetFeeTiersWithFeeOracle returns an implementation of GetFeeTiersFunc that relies on the cached fee
+// history of a fee Oracle.
+func GetFeeTiersWithFeeOracle(o *fees.Oracle) GetFeeTiersFunc {
+	return o.FeeTiers
+}
+
 // GetGasEstimateFunc is a general interface for fetching an estimate for verificationGasLimit and
 // callGasLimit given a userOp and EntryPoint address.
 type GetGasEstimateFunc = func(
 	ep common.Address,
 	op *userop.UserOperation,
 	sos state.OverrideSet,
-) (verificationGas uint64, callGas uint64, err error)
+) (*gas.Estimate, error)
 
 func getGasEstimateNoop() GetGasEstimateFunc {
 	return func(
 		ep common.Address,
 		op *userop.UserOperation,
 		sos state.OverrideSet,
-	) (verificationGas uint64, callGas uint64, err error) {
-		return 0, 0, nil
+	) (*gas.Estimate, error) {
+		return &gas.Estimate{}, nil
 	}
 }
 
@@ -82,7 +117,7 @@ func GetGasEstimateWithEthClient(
 		ep common.Address,
 		op *userop.UserOperation,
 		sos state.OverrideSet,
-	) (verificationGas uint64, callGas uint64, err error) {
+	) (*gas.Estimate, error) {
 		return gas.EstimateGas(&gas.EstimateInput{
 			Rpc:         rpc,
 			EntryPoint:  ep,
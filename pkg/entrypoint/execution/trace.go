@@ -35,6 +35,16 @@ type TraceInput struct {
 	TraceFeeCap *big.Int
 }
 
+// ExecutionRevert is the data field of an RPC error returned when the execution phase of a UserOperation
+// reverts.
+type ExecutionRevert struct {
+	// Target is the address of the contract that originated the revert. This is nil if it could not be traced.
+	Target *common.Address
+
+	// Data is the decoded reason, panic code, or raw revert data.
+	Data string
+}
+
 type TraceOutput struct {
 	Trace  *tracer.BundlerExecutionReturn
 	Result *reverts.ExecutionResultRevert
@@ -71,6 +81,23 @@ func parseUserOperationEvent(
 	return ev, nil
 }
 
+// getRevertTarget returns the contract that originated the last revert in the execution phase. Reverts are
+// recorded as each frame exits, so the origin is the innermost frame that reverted with the same output.
+func getRevertTarget(res *tracer.BundlerExecutionReturn) *common.Address {
+	if len(res.Reverts) == 0 || len(res.RevertTargets) != len(res.Reverts) {
+		return nil
+	}
+
+	last := res.Reverts[len(res.Reverts)-1]
+	for i, data := range res.Reverts {
+		if data == last {
+			addr := common.HexToAddress(res.RevertTargets[i])
+			return &addr
+		}
+	}
+	return nil
+}
+
 func TraceSimulateHandleOp(in *TraceInput) (*TraceOutput, error) {
 	ep, err := entrypoint.NewEntrypoint(in.EntryPoint, ethclient.NewClient(in.Rpc))
 	if err != nil {
@@ -143,11 +170,20 @@ func TraceSimulateHandleOp(in *TraceInput) (*TraceOutput, error) {
 			return out, err
 		}
 
+		target := getRevertTarget(&res)
 		if len(data) == 0 {
 			if res.ExecutionOOG {
-				return out, errors.NewRPCError(errors.EXECUTION_REVERTED, "execution OOG", nil)
+				return out, errors.NewRPCError(
+					errors.EXECUTION_REVERTED,
+					"execution OOG",
+					&ExecutionRevert{Target: target},
+				)
 			}
-			return out, errors.NewRPCError(errors.EXECUTION_REVERTED, "execution reverted", nil)
+			return out, errors.NewRPCError(
+				errors.EXECUTION_REVERTED,
+				"execution reverted",
+				&ExecutionRevert{Target: target},
+			)
 		}
 
 		reason, revErr := errors.DecodeRevert(data)
@@ -157,17 +193,21 @@ func TraceSimulateHandleOp(in *TraceInput) (*TraceOutput, error) {
 				return nil, errors.NewRPCError(
 					errors.EXECUTION_REVERTED,
 					"execution reverted with data",
-					hexutil.Encode(data),
+					&ExecutionRevert{Target: target, Data: hexutil.Encode(data)},
 				)
 			}
 
 			return out, errors.NewRPCError(
 				errors.EXECUTION_REVERTED,
 				fmt.Sprintf("panic encountered: %s", code),
-				code,
+				&ExecutionRevert{Target: target, Data: code},
 			)
 		}
-		return out, errors.NewRPCError(errors.EXECUTION_REVERTED, reason, reason)
+		return out, errors.NewRPCError(
+			errors.EXECUTION_REVERTED,
+			reason,
+			&ExecutionRevert{Target: target, Data: reason},
+		)
 	}
 
 	return out, nil
@@ -6,12 +6,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 )
 
 func filterUserOperationEvent(
-	eth *ethclient.Client,
+	eth backend.ContractCaller,
 	userOpHash string,
 	entryPoint common.Address,
 	blkRange uint64,
@@ -20,11 +20,11 @@ func filterUserOperationEvent(
 	if err != nil {
 		return nil, err
 	}
-	bn, err := eth.BlockNumber(context.Background())
+	head, err := eth.HeaderByNumber(context.Background(), nil)
 	if err != nil {
 		return nil, err
 	}
-	toBlk := big.NewInt(0).SetUint64(bn)
+	toBlk := big.NewInt(0).Set(head.Number)
 	startBlk := big.NewInt(0)
 	subBlkRange := big.NewInt(0).Sub(toBlk, big.NewInt(0).SetUint64(blkRange))
 	if subBlkRange.Cmp(startBlk) > 0 {
@@ -2,7 +2,6 @@ package filter
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -87,16 +86,7 @@ func GetUserOperationByHash(
 			}
 
 			for _, abiOp := range ops {
-				data, err := json.Marshal(abiOp)
-				if err != nil {
-					return nil, err
-				}
-
-				var op userop.UserOperation
-				if err = json.Unmarshal(data, &op); err != nil {
-					return nil, err
-				}
-
+				op := userop.UserOperation(abiOp)
 				if op.GetUserOpHash(entryPoint, chainID).String() == userOpHash {
 					return &HashLookupResult{
 						UserOperation:   &op,
@@ -8,7 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 )
 
 type parsedTransaction struct {
@@ -40,7 +40,7 @@ type UserOperationReceipt struct {
 // GetUserOperationReceipt filters the EntryPoint contract for UserOperationEvents and returns a receipt for
 // both the UserOperation and accompanying transaction.
 func GetUserOperationReceipt(
-	eth *ethclient.Client,
+	eth backend.EventReader,
 	userOpHash string,
 	entryPoint common.Address,
 	blkRange uint64,
@@ -3,6 +3,7 @@ package methods
 import (
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -24,14 +25,15 @@ var (
 		},
 		abi.Arguments{
 			{Name: "context", Type: bytes},
-			{Name: "deadline", Type: uint256},
+			{Name: "validationData", Type: uint256},
 		},
 	)
 	ValidatePaymasterUserOpSelector = hexutil.Encode(ValidatePaymasterUserOpMethod.ID)
 )
 
 type validatePaymasterUserOpOutput struct {
-	Context []byte
+	Context        []byte
+	ValidationData *big.Int
 }
 
 func DecodeValidatePaymasterUserOpOutput(ret any) (*validatePaymasterUserOpOutput, error) {
@@ -60,7 +62,13 @@ func DecodeValidatePaymasterUserOpOutput(ret any) (*validatePaymasterUserOpOutpu
 		return nil, errors.New("validatePaymasterUserOp: cannot assert type: hex is not of type string")
 	}
 
+	vd, ok := args[1].(*big.Int)
+	if !ok {
+		return nil, errors.New("validatePaymasterUserOp: cannot assert type: validationData is not of type *big.Int")
+	}
+
 	return &validatePaymasterUserOpOutput{
-		Context: ctx,
+		Context:        ctx,
+		ValidationData: vd,
 	}, nil
 }
@@ -0,0 +1,85 @@
+package nonce
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// Request identifies the EntryPoint nonce of a sender and 192 bit nonce key.
+type Request struct {
+	EntryPoint common.Address
+	Sender     common.Address
+	Key        *big.Int
+}
+
+// GetNoncesFunc provides a general interface for retrieving the next valid EntryPoint nonce of many requests
+// at once. The returned values are in the same order as the requests and a nil value means the nonce is
+// unknown.
+type GetNoncesFunc = func(reqs []*Request) ([]*big.Int, error)
+
+// GetNoncesWithFunc returns a GetNoncesFunc that calls a GetNonceFunc for each request.
+func GetNoncesWithFunc(fn GetNonceFunc) GetNoncesFunc {
+	return func(reqs []*Request) ([]*big.Int, error) {
+		out := make([]*big.Int, len(reqs))
+		for i, r := range reqs {
+			n, err := fn(r.EntryPoint, r.Sender, r.Key)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	}
+}
+
+// GetNoncesWithRpcClient returns a GetNoncesFunc that gets the current nonce of every request from the
+// EntryPoint in a single JSON-RPC batch.
+func GetNoncesWithRpcClient(client backend.BatchCaller) GetNoncesFunc {
+	return func(reqs []*Request) ([]*big.Int, error) {
+		if len(reqs) == 0 {
+			return []*big.Int{}, nil
+		}
+		ep, err := entrypoint.EntrypointMetaData.GetAbi()
+		if err != nil {
+			return nil, err
+		}
+
+		elems := make([]rpc.BatchElem, len(reqs))
+		for i, r := range reqs {
+			data, err := ep.Pack("getNonce", r.Sender, r.Key)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = rpc.BatchElem{
+				Method: "eth_call",
+				Args: []any{
+					map[string]any{"to": r.EntryPoint, "data": hexutil.Bytes(data)},
+					"latest",
+				},
+				Result: new(hexutil.Bytes),
+			}
+		}
+		if err := client.BatchCallContext(context.Background(), elems); err != nil {
+			return nil, err
+		}
+
+		out := make([]*big.Int, len(reqs))
+		for i, elem := range elems {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+			res, err := ep.Unpack("getNonce", *elem.Result.(*hexutil.Bytes))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = res[0].(*big.Int)
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,48 @@
+package nonce
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// batchCallerMock answers each eth_call in a batch with the index of the element as the nonce.
+type batchCallerMock struct {
+	calls int
+}
+
+func (b *batchCallerMock) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	b.calls++
+	for i := range elems {
+		*elems[i].Result.(*hexutil.Bytes) = common.BigToHash(big.NewInt(int64(i))).Bytes()
+	}
+	return nil
+}
+
+// TestGetNoncesWithRpcClient gets the nonces of several senders. Expect a single batch and the nonces in the
+// order of the requests.
+func TestGetNoncesWithRpcClient(t *testing.T) {
+	client := &batchCallerMock{}
+	reqs := []*Request{
+		{EntryPoint: testutils.ValidAddress1, Sender: testutils.ValidAddress2, Key: big.NewInt(0)},
+		{EntryPoint: testutils.ValidAddress1, Sender: testutils.ValidAddress3, Key: big.NewInt(1)},
+	}
+
+	nonces, err := GetNoncesWithRpcClient(client)(reqs)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if client.calls != 1 {
+		t.Fatalf("got %d calls, want 1", client.calls)
+	}
+	for i, n := range nonces {
+		if n.Int64() != int64(i) {
+			t.Fatalf("got %v, want %d", n, i)
+		}
+	}
+}
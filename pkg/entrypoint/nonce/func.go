@@ -0,0 +1,40 @@
+// Package nonce provides functions for retrieving the current EntryPoint nonce of an account.
+package nonce
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// GetNonceFunc provides a general interface for retrieving the next valid EntryPoint nonce for a given sender
+// and 192 bit nonce key.
+type GetNonceFunc = func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error)
+
+// GetNonceFuncNoop returns a nil nonce and nil error. This is used to signal that the current nonce is
+// unknown.
+func GetNonceFuncNoop() GetNonceFunc {
+	return func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		return nil, nil
+	}
+}
+
+// GetNonceWithEthClient returns a GetNonceFunc that relies on an eth client to get the current nonce from
+// the EntryPoint.
+func GetNonceWithEthClient(eth backend.ContractCaller) GetNonceFunc {
+	return func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		ep, err := entrypoint.NewEntrypoint(entryPoint, eth)
+		if err != nil {
+			return nil, err
+		}
+
+		return ep.GetNonce(nil, sender, key)
+	}
+}
+
+// GetKey returns the 192 bit key of a full EntryPoint nonce.
+func GetKey(nonce *big.Int) *big.Int {
+	return big.NewInt(0).Rsh(nonce, 64)
+}
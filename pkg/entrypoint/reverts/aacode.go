@@ -0,0 +1,46 @@
+package reverts
+
+import (
+	"regexp"
+)
+
+var (
+	aaCodeRegex = regexp.MustCompile(`^AA\d\d`)
+
+	// Entity names used to identify the source of an AA error code.
+	FactoryEntity    = "factory"
+	AccountEntity    = "account"
+	PaymasterEntity  = "paymaster"
+	EntryPointEntity = "entryPoint"
+)
+
+// GetAACode returns the error code prefix of an EntryPoint FailedOp reason (e.g. "AA23"). An empty string is
+// returned if the reason does not start with a code.
+func GetAACode(reason string) string {
+	return aaCodeRegex.FindString(reason)
+}
+
+// GetAACodeEntity returns the name of the entity responsible for an AA error code. The second digit of the
+// code denotes the category of the error as defined by the EntryPoint.
+func GetAACodeEntity(code string) string {
+	if len(code) != 4 {
+		return ""
+	}
+
+	switch code[2] {
+	case '1':
+		return FactoryEntity
+	case '2':
+		return AccountEntity
+	case '3':
+		return PaymasterEntity
+	case '5':
+		// AA50 is the only code in this category caused by a paymaster (i.e. postOp reverted).
+		if code == "AA50" {
+			return PaymasterEntity
+		}
+		return EntryPointEntity
+	default:
+		return EntryPointEntity
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -71,3 +72,13 @@ func NewFailedOp(err error) (*FailedOpRevert, error) {
 		Reason:  reason,
 	}, nil
 }
+
+// Data returns the ABI encoded FailedOp revert as a hex string.
+func (r *FailedOpRevert) Data() (string, error) {
+	failedOp := failedOp()
+	args, err := failedOp.Inputs.Pack(big.NewInt(int64(r.OpIndex)), r.Reason)
+	if err != nil {
+		return "", fmt.Errorf("failedOp: %s", err)
+	}
+	return hexutil.Encode(append(common.CopyBytes(failedOp.ID[:4]), args...)), nil
+}
@@ -52,3 +52,42 @@ func newCallStack(calls []tracer.CallInfo) []*callEntry {
 
 	return out
 }
+
+// findCallPath returns the address of each frame from the EntryPoint to the first call to addr made during
+// the validation of entity. If there is no such call, the path to entity is returned. Nil is returned if
+// entity was never called.
+func findCallPath(
+	ep common.Address,
+	calls []tracer.CallInfo,
+	entity common.Address,
+	addr common.Address,
+) []common.Address {
+	var entityPath []common.Address
+	path := []common.Address{ep}
+	entityDepth := 0
+	for _, call := range calls {
+		if call.Type == revertOpCode || call.Type == returnOpCode {
+			if len(path) == entityDepth {
+				entityDepth = 0
+			}
+			// The top level exit from the EntryPoint is also recorded.
+			if len(path) > 1 {
+				path = path[:len(path)-1]
+			}
+			continue
+		}
+
+		path = append(path, call.To)
+		if entityDepth == 0 && call.To == entity {
+			entityDepth = len(path)
+			if entityPath == nil {
+				entityPath = append([]common.Address{}, path...)
+			}
+		}
+		if entityDepth != 0 && call.To == addr {
+			return append([]common.Address{}, path...)
+		}
+	}
+
+	return entityPath
+}
@@ -15,6 +15,9 @@ var (
 	// 	2. During account simulation (i.e. before markerOpCode)
 	create2OpCode = "CREATE2"
 
+	// The GAS opcode is only allowed if immediately followed by a CALL.
+	gasOpCode = "GAS"
+
 	// List of opcodes not allowed during simulation for depth > 1 (i.e. account, paymaster, or contracts
 	// called by them).
 	bannedOpCodes = mapset.NewSet(
@@ -26,7 +29,7 @@ var (
 		"BLOCKHASH",
 		"NUMBER",
 		"ORIGIN",
-		"GAS",
+		gasOpCode,
 		"CREATE",
 		"COINBASE",
 		"SELFDESTRUCT",
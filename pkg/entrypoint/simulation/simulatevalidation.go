@@ -5,8 +5,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
@@ -16,11 +15,11 @@ import (
 // SimulateValidation makes a static call to Entrypoint.simulateValidation(userop) and returns the
 // results without any state changes.
 func SimulateValidation(
-	rpc *rpc.Client,
+	eth backend.ContractCaller,
 	entryPoint common.Address,
 	op *userop.UserOperation,
 ) (*reverts.ValidationResultRevert, error) {
-	ep, err := entrypoint.NewEntrypoint(entryPoint, ethclient.NewClient(rpc))
+	ep, err := entrypoint.NewEntrypoint(entryPoint, eth)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +54,7 @@ type storageSlotsValidator struct {
 	EntryPoint         common.Address
 	IsRIP7212Supported bool
 	AltMempools        *altmempools.Directory
+	Calls              []tracer.CallInfo
 
 	// Parameters of specific entities required for all validation
 	SenderSlots     storageSlots
@@ -85,6 +86,20 @@ func isRIP7212Call(isRIP7212Supported bool, addr common.Address) bool {
 	return isRIP7212Supported && addr == rip7212precompile
 }
 
+// entityStorageRule returns the rule that allows a staked entity to access slot in the storage of addr.
+func (v *storageSlotsValidator) entityStorageRule(
+	addr common.Address,
+	entitySlots storageSlots,
+	slot string,
+) string {
+	if addr == v.EntityAddr {
+		return ruleEntityStorage
+	} else if isAssociatedWith(entitySlots, slot) {
+		return ruleAssociatedStorage
+	}
+	return ruleExternalStorage
+}
+
 func (v *storageSlotsValidator) Process() ([]string, error) {
 	senderSlots := v.SenderSlots
 	if senderSlots == nil {
@@ -98,12 +113,23 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 
 	for ca, csi := range v.EntityContractSizeMap {
 		if ca != v.Op.Sender && csi.ContractSize == 0 && !isRIP7212Call(v.IsRIP7212Supported, ca) {
-			return altMempoolIds, fmt.Errorf(
-				"%s uses %s on an address with no deployed code: %s",
-				v.EntityName,
-				csi.Opcode,
-				ca,
-			)
+			return altMempoolIds, (&Violation{
+				Rule:    ruleNoCode,
+				Entity:  v.EntityName,
+				Address: ca,
+				Opcode:  csi.Opcode,
+				AltMempoolIds: v.AltMempools.HasForbiddenOpcodeException(
+					v.EntityName,
+					ca.String(),
+					csi.Opcode,
+				),
+				reason: fmt.Sprintf(
+					"%s uses %s on an address with no deployed code: %s",
+					v.EntityName,
+					csi.Opcode,
+					ca,
+				),
+			}).locate(v.EntryPoint, v.Calls, v.EntityAddr)
 		}
 	}
 
@@ -112,7 +138,7 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 			continue
 		}
 
-		var mustStakeSlot string
+		var mustStakeSlot, mustStakeRule string
 		accessTypes := map[string]any{
 			accessModeRead:  access.Reads,
 			accessModeWrite: access.Writes,
@@ -135,7 +161,7 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 				if isAssociatedWith(senderSlots, slot) {
 					if (len(v.Op.InitCode) > 0 && !v.FactoryIsStaked) ||
 						(len(v.Op.InitCode) > 0 && v.FactoryIsStaked && v.EntityAddr != v.Op.Sender) {
-						mustStakeSlot = slot
+						mustStakeSlot, mustStakeRule = slot, ruleSenderStorage
 					} else {
 						continue
 					}
@@ -144,28 +170,42 @@ func (v *storageSlotsValidator) Process() ([]string, error) {
 					addr2KnownEntity(v.Op, addr),
 					slot,
 				); (isAssociatedWith(entitySlots, slot) || mode == accessModeRead) && len(amIds) == 0 {
-					mustStakeSlot = slot
+					mustStakeSlot, mustStakeRule = slot, v.entityStorageRule(addr, entitySlots, slot)
 				} else if len(amIds) > 0 {
 					altMempoolIds = append(altMempoolIds, amIds...)
 				} else {
-					return altMempoolIds, fmt.Errorf(
-						"%s has forbidden %s to %s slot %s",
-						v.EntityName,
-						mode,
-						addr2KnownEntity(v.Op, addr),
-						slot,
-					)
+					return altMempoolIds, (&Violation{
+						Rule:    v.entityStorageRule(addr, entitySlots, slot),
+						Entity:  v.EntityName,
+						Address: addr,
+						Slot:    slot,
+						Access:  mode,
+						reason: fmt.Sprintf(
+							"%s has forbidden %s to %s slot %s",
+							v.EntityName,
+							mode,
+							addr2KnownEntity(v.Op, addr),
+							slot,
+						),
+					}).locate(v.EntryPoint, v.Calls, v.EntityAddr)
 				}
 			}
 		}
 
 		if mustStakeSlot != "" && !v.EntityIsStaked {
-			return altMempoolIds, fmt.Errorf(
-				"unstaked %s accessed %s slot %s",
-				v.EntityName,
-				addr2KnownEntity(v.Op, addr),
-				mustStakeSlot,
-			)
+			return altMempoolIds, (&Violation{
+				Rule:          mustStakeRule,
+				Entity:        v.EntityName,
+				Address:       addr,
+				Slot:          mustStakeSlot,
+				AltMempoolIds: notStakedExceptions(v.AltMempools, v.EntityName, v.EntityAddr),
+				reason: fmt.Sprintf(
+					"unstaked %s accessed %s slot %s",
+					v.EntityName,
+					addr2KnownEntity(v.Op, addr),
+					mustStakeSlot,
+				),
+			}).locate(v.EntryPoint, v.Calls, v.EntityAddr)
 		}
 	}
 
@@ -2,18 +2,14 @@ package simulation
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"math"
 	"math/big"
 
 	mapset "github.com/deckarep/golang-set/v2"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
@@ -23,7 +19,7 @@ import (
 )
 
 type TraceInput struct {
-	Rpc                *rpc.Client
+	Rpc                backend.TraceCaller
 	EntryPoint         common.Address
 	Op                 *userop.UserOperation
 	ChainID            *big.Int
@@ -34,24 +30,20 @@ type TraceInput struct {
 }
 
 type TraceOutput struct {
-	TouchedContracts []common.Address
-	AltMempoolIds    []string
+	TouchedContracts        []common.Address
+	AccessedStorage         []common.Address
+	AltMempoolIds           []string
+	PaymasterValidationData *ValidationData
 }
 
 // TraceSimulateValidation makes a debug_traceCall to Entrypoint.simulateValidation(userop) and returns
 // information related to the validation phase of a UserOperation.
 func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
-	ep, err := entrypoint.NewEntrypoint(in.EntryPoint, ethclient.NewClient(in.Rpc))
+	epAbi, err := entrypoint.EntrypointMetaData.GetAbi()
 	if err != nil {
 		return nil, err
 	}
-	auth, err := bind.NewKeyedTransactorWithChainID(utils.DummyPk, in.ChainID)
-	if err != nil {
-		return nil, err
-	}
-	auth.GasLimit = math.MaxUint64
-	auth.NoSend = true
-	tx, err := ep.SimulateValidation(auth, entrypoint.UserOperation(*in.Op))
+	data, err := epAbi.Pack("simulateValidation", entrypoint.UserOperation(*in.Op))
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +56,7 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 	req := utils.TraceCallReq{
 		From:         common.HexToAddress("0x"),
 		To:           in.EntryPoint,
-		Data:         tx.Data(),
+		Data:         data,
 		MaxFeePerGas: hexutil.Big(*in.Op.MaxFeePerGas),
 	}
 	opts := utils.TraceCallOpts{
@@ -82,20 +74,54 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 	}
 
 	ic := mapset.NewSet[common.Address]()
+	as := mapset.NewSet[common.Address]()
 	for title, entity := range knownEntity {
 		if entity.Info.OOG {
-			return nil, fmt.Errorf("%s OOG", title)
+			return nil, (&Violation{
+				Rule:    ruleOOG,
+				Entity:  title,
+				Address: entity.Address,
+				reason:  fmt.Sprintf("%s OOG", title),
+			}).locate(in.EntryPoint, res.Calls, entity.Address)
 		}
-		if _, ok := entity.Info.ExtCodeAccessInfo[in.EntryPoint]; ok {
-			return nil, fmt.Errorf("%s has forbidden EXTCODE* access to the EntryPoint", title)
+		if opcode, ok := entity.Info.ExtCodeAccessInfo[in.EntryPoint]; ok {
+			return nil, (&Violation{
+				Rule:    ruleEntryPointAccess,
+				Entity:  title,
+				Address: in.EntryPoint,
+				Opcode:  opcode,
+				reason:  fmt.Sprintf("%s has forbidden EXTCODE* access to the EntryPoint", title),
+			}).locate(in.EntryPoint, res.Calls, entity.Address)
 		}
 		for opcode := range entity.Info.Opcodes {
 			if bannedOpCodes.Contains(opcode) {
-				return nil, fmt.Errorf("%s uses banned opcode: %s", title, opcode)
+				rule := ruleBannedOpcode
+				if opcode == gasOpCode {
+					rule = ruleGasOpcode
+				}
+				return nil, (&Violation{
+					Rule:    rule,
+					Entity:  title,
+					Address: entity.Address,
+					Opcode:  opcode,
+					AltMempoolIds: in.AltMempools.HasForbiddenOpcodeException(
+						title,
+						entity.Address.String(),
+						opcode,
+					),
+					reason: fmt.Sprintf("%s uses banned opcode: %s", title, opcode),
+				}).atOpcodeFrame(entity.Info.OpcodeFrames).locate(in.EntryPoint, res.Calls, entity.Address)
 			}
 
 			if !entity.IsStaked && bannedUnstakedOpCodes.Contains(opcode) {
-				return nil, fmt.Errorf("unstaked %s uses banned opcode: %s", title, opcode)
+				return nil, (&Violation{
+					Rule:          ruleUnstakedBalance,
+					Entity:        title,
+					Address:       entity.Address,
+					Opcode:        opcode,
+					AltMempoolIds: notStakedExceptions(in.AltMempools, title, entity.Address),
+					reason:        fmt.Sprintf("unstaked %s uses banned opcode: %s", title, opcode),
+				}).atOpcodeFrame(entity.Info.OpcodeFrames).locate(in.EntryPoint, res.Calls, entity.Address)
 			}
 		}
 
@@ -103,19 +129,36 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 		for addr := range entity.Info.ContractSize {
 			ic.Add(addr)
 		}
+		for addr := range entity.Info.Access {
+			if addr != in.EntryPoint {
+				as.Add(addr)
+			}
+		}
 	}
 
 	create2Count, ok := knownEntity["factory"].Info.Opcodes[create2OpCode]
 	if ok && (create2Count > 1 || len(in.Op.InitCode) == 0) {
-		return nil, fmt.Errorf("factory with too many %s", create2OpCode)
+		return nil, (&Violation{
+			Rule:    ruleCreate2,
+			Entity:  "factory",
+			Address: knownEntity["factory"].Address,
+			Opcode:  create2OpCode,
+			reason:  fmt.Sprintf("factory with too many %s", create2OpCode),
+		}).
+			atOpcodeFrame(knownEntity["factory"].Info.OpcodeFrames).
+			locate(in.EntryPoint, res.Calls, knownEntity["factory"].Address)
 	}
-	_, ok = knownEntity["account"].Info.Opcodes[create2OpCode]
-	if ok {
-		return nil, fmt.Errorf("account uses banned opcode: %s", create2OpCode)
-	}
-	_, ok = knownEntity["paymaster"].Info.Opcodes[create2OpCode]
-	if ok {
-		return nil, fmt.Errorf("paymaster uses banned opcode: %s", create2OpCode)
+	for _, title := range []string{"account", "paymaster"} {
+		entity := knownEntity[title]
+		if _, ok := entity.Info.Opcodes[create2OpCode]; ok {
+			return nil, (&Violation{
+				Rule:    ruleCreate2,
+				Entity:  title,
+				Address: entity.Address,
+				Opcode:  create2OpCode,
+				reason:  fmt.Sprintf("%s uses banned opcode: %s", title, create2OpCode),
+			}).atOpcodeFrame(entity.Info.OpcodeFrames).locate(in.EntryPoint, res.Calls, entity.Address)
+		}
 	}
 
 	slotsByEntity := newStorageSlotsByEntity(in.Stakes, res.Keccak)
@@ -125,6 +168,7 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 			EntryPoint:            in.EntryPoint,
 			IsRIP7212Supported:    in.IsRIP7212Supported,
 			AltMempools:           in.AltMempools,
+			Calls:                 res.Calls,
 			SenderSlots:           slotsByEntity[in.Op.Sender],
 			FactoryIsStaked:       knownEntity["factory"].IsStaked,
 			EntityName:            title,
@@ -141,37 +185,79 @@ func TraceSimulateValidation(in *TraceInput) (*TraceOutput, error) {
 		}
 	}
 
+	var pmValidationData *ValidationData
 	callStack := newCallStack(res.Calls)
 	for _, call := range callStack {
 		if call.Method == methods.ValidatePaymasterUserOpSelector {
-			out, err := methods.DecodeValidatePaymasterUserOpOutput(call.Return)
+			vd, err := parsePaymasterValidation(
+				in.EntryPoint,
+				res.Calls,
+				in.AltMempools,
+				call,
+				knownEntity["paymaster"].IsStaked,
+			)
 			if err != nil {
-				return nil, fmt.Errorf(
-					"unexpected tracing result for op: %s, %s",
-					in.Op.GetUserOpHash(in.EntryPoint, in.ChainID),
-					err,
-				)
-			}
-
-			if len(out.Context) != 0 && !knownEntity["paymaster"].IsStaked {
-				return nil, errors.New("unstaked paymaster must not return context")
+				return nil, err
 			}
+			pmValidationData = vd
 		} else if call.To == in.EntryPoint && call.Method == methods.BalanceOfSelector {
-			return nil, fmt.Errorf(
-				"%s cannot call balanceOf on EntryPoint",
-				addr2KnownEntity(in.Op, call.From),
-			)
+			return nil, (&Violation{
+				Rule:    ruleEntryPointAccess,
+				Entity:  addr2KnownEntity(in.Op, call.From),
+				Address: call.To,
+				Opcode:  call.Type,
+				reason:  fmt.Sprintf("%s cannot call balanceOf on EntryPoint", addr2KnownEntity(in.Op, call.From)),
+			}).locate(in.EntryPoint, res.Calls, call.From)
 		} else if call.To != in.EntryPoint && call.Value.Cmp(common.Big0) == 1 {
-			return nil, fmt.Errorf(
-				"%s has a forbidden value transfer to %s",
-				addr2KnownEntity(in.Op, call.From),
-				addr2KnownEntity(in.Op, call.To),
-			)
+			return nil, (&Violation{
+				Rule:    ruleValueTransfer,
+				Entity:  addr2KnownEntity(in.Op, call.From),
+				Address: call.To,
+				Opcode:  call.Type,
+				reason: fmt.Sprintf(
+					"%s has a forbidden value transfer to %s",
+					addr2KnownEntity(in.Op, call.From),
+					addr2KnownEntity(in.Op, call.To),
+				),
+			}).locate(in.EntryPoint, res.Calls, call.From)
 		}
 	}
 
 	return &TraceOutput{
-		TouchedContracts: ic.ToSlice(),
-		AltMempoolIds:    altMempoolIds,
+		TouchedContracts:        ic.ToSlice(),
+		AccessedStorage:         as.ToSlice(),
+		AltMempoolIds:           altMempoolIds,
+		PaymasterValidationData: pmValidationData,
 	}, nil
 }
+
+// parsePaymasterValidation decodes the output of a call to validatePaymasterUserOp. The paymaster breaks
+// EREP-050 if the output cannot be decoded or if it is unstaked and returns a context.
+func parsePaymasterValidation(
+	ep common.Address,
+	calls []tracer.CallInfo,
+	dir *altmempools.Directory,
+	call *callEntry,
+	isStaked bool,
+) (*ValidationData, error) {
+	out, err := methods.DecodeValidatePaymasterUserOpOutput(call.Return)
+	if err != nil {
+		return nil, (&Violation{
+			Rule:    rulePaymasterContext,
+			Entity:  "paymaster",
+			Address: call.To,
+			reason:  fmt.Sprintf("paymaster returned an invalid validation output: %s", err),
+		}).locate(ep, calls, call.To)
+	}
+
+	if len(out.Context) != 0 && !isStaked {
+		return nil, (&Violation{
+			Rule:          rulePaymasterContext,
+			Entity:        "paymaster",
+			Address:       call.To,
+			AltMempoolIds: notStakedExceptions(dir, "paymaster", call.To),
+			reason:        "unstaked paymaster must not return context",
+		}).locate(ep, calls, call.To)
+	}
+	return ParseValidationData(out.ValidationData), nil
+}
@@ -0,0 +1,44 @@
+package simulation
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	maxUint48      = big.NewInt(0).Sub(big.NewInt(0).Lsh(common.Big1, 48), common.Big1)
+	validUntilBits = uint(common.AddressLength * 8)
+	validAfterBits = validUntilBits + 48
+)
+
+// ValidationData is the unpacked form of the uint256 value returned by an account's validateUserOp or a
+// paymaster's validatePaymasterUserOp.
+type ValidationData struct {
+	// Aggregator is the zero address for a valid signature, 0x...01 for a failed signature, or otherwise the
+	// address of a signature aggregator.
+	Aggregator common.Address
+	ValidAfter *big.Int
+	ValidUntil *big.Int
+}
+
+// ParseValidationData unpacks a uint256 validationData value in the same way as the EntryPoint. A validUntil
+// of 0 is treated as having no expiry and set to the max uint48 value.
+func ParseValidationData(data *big.Int) *ValidationData {
+	if data == nil {
+		data = big.NewInt(0)
+	}
+
+	aggregator := common.BigToAddress(data)
+	validUntil := big.NewInt(0).And(big.NewInt(0).Rsh(data, validUntilBits), maxUint48)
+	validAfter := big.NewInt(0).And(big.NewInt(0).Rsh(data, validAfterBits), maxUint48)
+	if validUntil.Cmp(common.Big0) == 0 {
+		validUntil = big.NewInt(0).Set(maxUint48)
+	}
+
+	return &ValidationData{
+		Aggregator: aggregator,
+		ValidAfter: validAfter,
+		ValidUntil: validUntil,
+	}
+}
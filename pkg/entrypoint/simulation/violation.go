@@ -0,0 +1,82 @@
+package simulation
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+)
+
+const (
+	// Codes of the ERC-7562 validation rules that are enforced during tracing. See
+	// https://eips.ethereum.org/EIPS/eip-7562.
+	ruleBannedOpcode      = "OP-011"
+	ruleGasOpcode         = "OP-012"
+	ruleOOG               = "OP-020"
+	ruleCreate2           = "OP-031"
+	ruleNoCode            = "OP-041"
+	ruleEntryPointAccess  = "OP-054"
+	ruleValueTransfer     = "OP-061"
+	ruleUnstakedBalance   = "OP-080"
+	ruleSenderStorage     = "STO-022"
+	ruleEntityStorage     = "STO-031"
+	ruleAssociatedStorage = "STO-032"
+	ruleExternalStorage   = "STO-033"
+	rulePaymasterContext  = "EREP-050"
+)
+
+// Violation is a validation rule broken by a UserOperation during tracing. It is returned as an error and
+// reported to the client in the data field of the RPC error.
+//
+// Address is the contract where the rule was broken. CallPath lists the address of each frame from the
+// EntryPoint down to Address and Depth is the depth of the frame, where the EntryPoint is at depth 1. For
+// opcode rules, Address and Depth are taken from the frame where the tracer first recorded the opcode.
+// Otherwise Depth is the length of CallPath.
+type Violation struct {
+	Rule          string           `json:"rule"`
+	Entity        string           `json:"entity"`
+	Address       common.Address   `json:"address"`
+	Opcode        string           `json:"opcode,omitempty"`
+	Slot          string           `json:"slot,omitempty"`
+	Access        string           `json:"access,omitempty"`
+	Depth         int              `json:"depth"`
+	CallPath      []common.Address `json:"callPath"`
+	AltMempoolIds []string         `json:"altMempoolIds"`
+
+	reason string
+}
+
+// Error returns a human readable description of the violation.
+func (v *Violation) Error() string {
+	return v.reason
+}
+
+// atOpcodeFrame sets the address and depth of the violation to the frame where the opcode was first used.
+func (v *Violation) atOpcodeFrame(frames tracer.OpcodeFrameMap) *Violation {
+	if frame, ok := frames[v.Opcode]; ok {
+		v.Address = frame.Address
+		v.Depth = frame.Depth
+	}
+	return v
+}
+
+// locate sets the call path of the violation from the calls made during the validation of entity. Alt mempool
+// ids are set to an empty array if there are none.
+func (v *Violation) locate(ep common.Address, calls []tracer.CallInfo, entity common.Address) *Violation {
+	v.CallPath = findCallPath(ep, calls, entity, v.Address)
+	if v.CallPath == nil {
+		v.CallPath = []common.Address{ep}
+	}
+	if v.Depth == 0 {
+		v.Depth = len(v.CallPath)
+	}
+	if v.AltMempoolIds == nil {
+		v.AltMempoolIds = []string{}
+	}
+	return v
+}
+
+// notStakedExceptions returns the ids of alt mempools that accept the entity without a stake, either by name
+// or by address.
+func notStakedExceptions(dir *altmempools.Directory, entity string, addr common.Address) []string {
+	return append(dir.HasNotStakedException(entity), dir.HasNotStakedException(addr.String())...)
+}
@@ -0,0 +1,265 @@
+package simulation
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+)
+
+var (
+	ep = testutils.ValidAddress5
+
+	// calls made during validation: EntryPoint -> account -> ValidAddress3, then EntryPoint -> paymaster ->
+	// ValidAddress2.
+	calls = []tracer.CallInfo{
+		{Type: "CALL", From: ep, To: testutils.ValidAddress4},
+		{Type: "STATICCALL", From: testutils.ValidAddress4, To: testutils.ValidAddress3},
+		{Type: returnOpCode},
+		{Type: returnOpCode},
+		{Type: "CALL", From: ep, To: testutils.ValidAddress1},
+		{Type: "STATICCALL", From: testutils.ValidAddress1, To: testutils.ValidAddress2},
+		{Type: returnOpCode},
+		{Type: returnOpCode},
+		{Type: revertOpCode},
+	}
+)
+
+// TestFindCallPath finds the path to a contract called by an entity. Expect the path from the EntryPoint to
+// the contract, only within the calls made by the entity.
+func TestFindCallPath(t *testing.T) {
+	got := findCallPath(ep, calls, testutils.ValidAddress1, testutils.ValidAddress2)
+	want := []common.Address{ep, testutils.ValidAddress1, testutils.ValidAddress2}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got = findCallPath(ep, calls, testutils.ValidAddress1, testutils.ValidAddress3)
+	want = []common.Address{ep, testutils.ValidAddress1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if got = findCallPath(ep, calls, common.HexToAddress("0x"), testutils.ValidAddress2); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+}
+
+// TestStorageSlotsViolation processes an unstaked paymaster reading the storage of an external contract.
+// Expect a violation of STO-033 located at the external contract with the alt mempools that accept an
+// unstaked paymaster.
+func TestStorageSlotsViolation(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = testutils.ValidAddress1.Bytes()
+	dir, err := altmempools.New(testutils.ChainID, []*altmempools.Config{
+		{
+			Id: "1",
+			Data: map[string]any{
+				"description": "Unstaked paymasters",
+				"chainIds":    []any{hexutil.EncodeBig(testutils.ChainID)},
+				"allowlist": []any{
+					map[string]any{
+						"description": "Mock notStaked rule",
+						"rule":        "notStaked",
+						"entity":      "paymaster",
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	v := &storageSlotsValidator{
+		Op:          op,
+		EntryPoint:  ep,
+		AltMempools: dir,
+		Calls:       calls,
+		EntityName:  "paymaster",
+		EntityAddr:  testutils.ValidAddress1,
+		EntityAccessMap: tracer.AccessMap{
+			testutils.ValidAddress2: {Reads: tracer.HexMap{"0x01": "0x00"}, Writes: tracer.Counts{}},
+		},
+	}
+	_, err = v.Process()
+	got, ok := err.(*Violation)
+	if !ok {
+		t.Fatalf("got err %v, want *Violation", err)
+	}
+	want := &Violation{
+		Rule:          ruleExternalStorage,
+		Entity:        "paymaster",
+		Address:       testutils.ValidAddress2,
+		Slot:          "0x01",
+		Depth:         3,
+		CallPath:      []common.Address{ep, testutils.ValidAddress1, testutils.ValidAddress2},
+		AltMempoolIds: []string{"1"},
+		reason:        err.Error(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestViolationJSON encodes a violation. Expect an empty array of alt mempool ids and no opcode or slot
+// fields if they are not set.
+func TestViolationJSON(t *testing.T) {
+	v := (&Violation{
+		Rule:    ruleOOG,
+		Entity:  "account",
+		Address: testutils.ValidAddress4,
+		reason:  "account OOG",
+	}).locate(ep, calls, testutils.ValidAddress4)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	want := map[string]any{
+		"rule":          "OP-020",
+		"entity":        "account",
+		"address":       strings.ToLower(testutils.ValidAddress4.Hex()),
+		"depth":         float64(2),
+		"callPath":      []any{strings.ToLower(ep.Hex()), strings.ToLower(testutils.ValidAddress4.Hex())},
+		"altMempoolIds": []any{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// TestOpcodeViolationAtFrame locates a banned opcode used by a contract called from the account. Expect the
+// address and depth of the frame recorded by the tracer and the call path to that frame.
+func TestOpcodeViolationAtFrame(t *testing.T) {
+	got := (&Violation{
+		Rule:    ruleBannedOpcode,
+		Entity:  "account",
+		Address: testutils.ValidAddress4,
+		Opcode:  "TIMESTAMP",
+	}).atOpcodeFrame(tracer.OpcodeFrameMap{
+		"TIMESTAMP": {Address: testutils.ValidAddress3, Depth: 3},
+	}).locate(ep, calls, testutils.ValidAddress4)
+
+	want := &Violation{
+		Rule:          ruleBannedOpcode,
+		Entity:        "account",
+		Address:       testutils.ValidAddress3,
+		Opcode:        "TIMESTAMP",
+		Depth:         3,
+		CallPath:      []common.Address{ep, testutils.ValidAddress4, testutils.ValidAddress3},
+		AltMempoolIds: []string{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func newAltMempoolDirectory(t *testing.T, rule map[string]any) *altmempools.Directory {
+	t.Helper()
+	rule["description"] = "Mock " + rule["rule"].(string) + " rule"
+	dir, err := altmempools.New(testutils.ChainID, []*altmempools.Config{
+		{
+			Id: "1",
+			Data: map[string]any{
+				"description": "Mock alt mempool",
+				"chainIds":    []any{hexutil.EncodeBig(testutils.ChainID)},
+				"allowlist":   []any{rule},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	return dir
+}
+
+// TestNoCodeViolation processes an account calling EXTCODESIZE on an address with no deployed code. Expect a
+// violation of OP-041 with the alt mempools that accept the opcode on that address.
+func TestNoCodeViolation(t *testing.T) {
+	v := &storageSlotsValidator{
+		Op:         testutils.MockValidInitUserOp(),
+		EntryPoint: ep,
+		AltMempools: newAltMempoolDirectory(t, map[string]any{
+			"rule":     "forbiddenOpcode",
+			"entity":   "account",
+			"contract": testutils.ValidAddress3.String(),
+			"opcode":   "EXTCODESIZE",
+		}),
+		Calls:      calls,
+		EntityName: "account",
+		EntityAddr: testutils.ValidAddress4,
+		EntityContractSizeMap: tracer.ContractSizeMap{
+			testutils.ValidAddress3: {Opcode: "EXTCODESIZE", ContractSize: 0},
+		},
+	}
+	_, err := v.Process()
+	got, ok := err.(*Violation)
+	if !ok {
+		t.Fatalf("got err %v, want *Violation", err)
+	}
+	if got.Rule != ruleNoCode {
+		t.Fatalf("got rule %s, want %s", got.Rule, ruleNoCode)
+	} else if !reflect.DeepEqual(got.AltMempoolIds, []string{"1"}) {
+		t.Fatalf("got alt mempool ids %v, want [1]", got.AltMempoolIds)
+	}
+}
+
+// TestPaymasterContextViolation parses the output of an unstaked paymaster that returns a context. Expect a
+// violation of EREP-050 located at the paymaster with the alt mempools that accept an unstaked paymaster.
+func TestPaymasterContextViolation(t *testing.T) {
+	out, err := methods.ValidatePaymasterUserOpMethod.Outputs.Pack([]byte{0x01}, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := newAltMempoolDirectory(t, map[string]any{"rule": "notStaked", "entity": "paymaster"})
+	call := &callEntry{From: ep, To: testutils.ValidAddress1, Return: hexutil.Encode(out)}
+
+	if _, err := parsePaymasterValidation(ep, calls, dir, call, true); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	_, err = parsePaymasterValidation(ep, calls, dir, call, false)
+	got, ok := err.(*Violation)
+	if !ok {
+		t.Fatalf("got err %v, want *Violation", err)
+	}
+	want := &Violation{
+		Rule:          rulePaymasterContext,
+		Entity:        "paymaster",
+		Address:       testutils.ValidAddress1,
+		Depth:         2,
+		CallPath:      []common.Address{ep, testutils.ValidAddress1},
+		AltMempoolIds: []string{"1"},
+		reason:        err.Error(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestPaymasterOutputViolation parses output from a paymaster that cannot be decoded. Expect a violation of
+// EREP-050 located at the paymaster.
+func TestPaymasterOutputViolation(t *testing.T) {
+	dir := newAltMempoolDirectory(t, map[string]any{"rule": "notStaked", "entity": "paymaster"})
+	call := &callEntry{From: ep, To: testutils.ValidAddress1, Return: "0x01"}
+
+	_, err := parsePaymasterValidation(ep, calls, dir, call, true)
+	got, ok := err.(*Violation)
+	if !ok {
+		t.Fatalf("got err %v, want *Violation", err)
+	} else if got.Rule != rulePaymasterContext || got.Address != testutils.ValidAddress1 {
+		t.Fatalf("got %+v, want %s at %s", got, rulePaymasterContext, testutils.ValidAddress1)
+	}
+}
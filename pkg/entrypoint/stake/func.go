@@ -2,7 +2,7 @@ package stake
 
 import (
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 )
 
@@ -17,7 +17,7 @@ func GetStakeFuncNoop() GetStakeFunc {
 
 // GetStakeWithEthClient returns a GetStakeFunc that relies on an eth client to get stake info from the
 // EntryPoint.
-func GetStakeWithEthClient(eth *ethclient.Client) GetStakeFunc {
+func GetStakeWithEthClient(eth backend.ContractCaller) GetStakeFunc {
 	return func(entryPoint, addr common.Address) (*entrypoint.IStakeManagerDepositInfo, error) {
 		if addr == common.HexToAddress("0x") {
 			return nil, nil
@@ -0,0 +1,181 @@
+package transaction
+
+import (
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// FeeStrategy determines the fees of a transaction to submit a batch of UserOperations to the EntryPoint.
+// Each method is given the fee values suggested by the network and the batch being submitted.
+type FeeStrategy interface {
+	// GasTipCap returns the Max Priority Fee for an EIP-1559 transaction.
+	GasTipCap(basefee *big.Int, tip *big.Int, batch []*userop.UserOperation) *big.Int
+
+	// GasFeeCap returns the Max Fee for an EIP-1559 transaction.
+	GasFeeCap(basefee *big.Int, tip *big.Int, batch []*userop.UserOperation) *big.Int
+
+	// GasPrice returns the Gas Price for a legacy transaction.
+	GasPrice(gasPrice *big.Int, batch []*userop.UserOperation) *big.Int
+}
+
+func suggestedGasFeeCap(basefee *big.Int, tip *big.Int) *big.Int {
+	return big.NewInt(0).Add(tip, big.NewInt(0).Mul(basefee, common.Big2))
+}
+
+func maxBigInt(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) == 1 {
+		return a
+	}
+	return b
+}
+
+func minBigInt(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) == -1 {
+		return a
+	}
+	return b
+}
+
+type meanFeeStrategy struct{}
+
+// MeanFeeStrategy returns a FeeStrategy that uses the larger value between the network suggested fees or the
+// average fees of the entire batch.
+func MeanFeeStrategy() FeeStrategy {
+	return &meanFeeStrategy{}
+}
+
+func (s *meanFeeStrategy) GasTipCap(basefee *big.Int, tip *big.Int, batch []*userop.UserOperation) *big.Int {
+	return SuggestMeanGasTipCap(tip, batch)
+}
+
+func (s *meanFeeStrategy) GasFeeCap(basefee *big.Int, tip *big.Int, batch []*userop.UserOperation) *big.Int {
+	return SuggestMeanGasFeeCap(basefee, tip, batch)
+}
+
+func (s *meanFeeStrategy) GasPrice(gasPrice *big.Int, batch []*userop.UserOperation) *big.Int {
+	return SuggestMeanGasPrice(gasPrice, batch)
+}
+
+type minProfitableFeeStrategy struct{}
+
+// MinProfitableFeeStrategy returns a FeeStrategy that uses the lowest fees offered by any UserOperation in
+// the batch. This ensures every UserOperation reimburses the bundler at a gas price no lower than the price
+// paid for the transaction.
+func MinProfitableFeeStrategy() FeeStrategy {
+	return &minProfitableFeeStrategy{}
+}
+
+func (s *minProfitableFeeStrategy) GasTipCap(
+	basefee *big.Int,
+	tip *big.Int,
+	batch []*userop.UserOperation,
+) *big.Int {
+	min := batch[0].GetDynamicGasPrice(basefee)
+	for _, op := range batch[1:] {
+		min = minBigInt(min, op.GetDynamicGasPrice(basefee))
+	}
+	if min.Cmp(basefee) != 1 {
+		return big.NewInt(0)
+	}
+	return big.NewInt(0).Sub(min, basefee)
+}
+
+func (s *minProfitableFeeStrategy) GasFeeCap(
+	basefee *big.Int,
+	tip *big.Int,
+	batch []*userop.UserOperation,
+) *big.Int {
+	min := batch[0].MaxFeePerGas
+	for _, op := range batch[1:] {
+		min = minBigInt(min, op.MaxFeePerGas)
+	}
+	return big.NewInt(0).Set(min)
+}
+
+func (s *minProfitableFeeStrategy) GasPrice(gasPrice *big.Int, batch []*userop.UserOperation) *big.Int {
+	return s.GasFeeCap(nil, nil, batch)
+}
+
+type networkFeeStrategy struct {
+	maxFee *big.Int
+}
+
+// NetworkFeeStrategy returns a FeeStrategy that uses the network suggested fees regardless of the batch. If
+// maxFee is not nil, the Max Fee and Gas Price will not exceed it.
+func NetworkFeeStrategy(maxFee *big.Int) FeeStrategy {
+	return &networkFeeStrategy{maxFee: maxFee}
+}
+
+func (s *networkFeeStrategy) cap(fee *big.Int) *big.Int {
+	if s.maxFee == nil {
+		return fee
+	}
+	return minBigInt(fee, s.maxFee)
+}
+
+func (s *networkFeeStrategy) GasTipCap(basefee *big.Int, tip *big.Int, batch []*userop.UserOperation) *big.Int {
+	return s.cap(tip)
+}
+
+func (s *networkFeeStrategy) GasFeeCap(basefee *big.Int, tip *big.Int, batch []*userop.UserOperation) *big.Int {
+	return s.cap(suggestedGasFeeCap(basefee, tip))
+}
+
+func (s *networkFeeStrategy) GasPrice(gasPrice *big.Int, batch []*userop.UserOperation) *big.Int {
+	return s.cap(gasPrice)
+}
+
+type percentileFeeStrategy struct {
+	percentile float64
+}
+
+// PercentileFeeStrategy returns a FeeStrategy that uses the larger value between the network suggested fees
+// or the given percentile of fees in the batch. The percentile is clamped between 0 and 100.
+func PercentileFeeStrategy(percentile float64) FeeStrategy {
+	return &percentileFeeStrategy{percentile: math.Max(0, math.Min(100, percentile))}
+}
+
+func (s *percentileFeeStrategy) pick(vals []*big.Int) *big.Int {
+	sort.Slice(vals, func(i, j int) bool { return vals[i].Cmp(vals[j]) == -1 })
+	i := int(math.Ceil(s.percentile/100*float64(len(vals)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return vals[i]
+}
+
+func (s *percentileFeeStrategy) GasTipCap(
+	basefee *big.Int,
+	tip *big.Int,
+	batch []*userop.UserOperation,
+) *big.Int {
+	vals := []*big.Int{}
+	for _, op := range batch {
+		vals = append(vals, op.MaxPriorityFeePerGas)
+	}
+	return maxBigInt(s.pick(vals), tip)
+}
+
+func (s *percentileFeeStrategy) GasFeeCap(
+	basefee *big.Int,
+	tip *big.Int,
+	batch []*userop.UserOperation,
+) *big.Int {
+	vals := []*big.Int{}
+	for _, op := range batch {
+		vals = append(vals, op.MaxFeePerGas)
+	}
+	return maxBigInt(s.pick(vals), suggestedGasFeeCap(basefee, tip))
+}
+
+func (s *percentileFeeStrategy) GasPrice(gasPrice *big.Int, batch []*userop.UserOperation) *big.Int {
+	vals := []*big.Int{}
+	for _, op := range batch {
+		vals = append(vals, op.MaxFeePerGas)
+	}
+	return maxBigInt(s.pick(vals), gasPrice)
+}
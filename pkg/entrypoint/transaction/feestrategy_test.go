@@ -0,0 +1,75 @@
+package transaction
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newFeeBatch(fees ...[2]int64) []*userop.UserOperation {
+	batch := []*userop.UserOperation{}
+	for _, f := range fees {
+		op := testutils.MockValidInitUserOp()
+		op.MaxFeePerGas = big.NewInt(f[0])
+		op.MaxPriorityFeePerGas = big.NewInt(f[1])
+		batch = append(batch, op)
+	}
+	return batch
+}
+
+// TestMinProfitableFeeStrategy verifies that the transaction is priced at the lowest effective fees in the
+// batch.
+func TestMinProfitableFeeStrategy(t *testing.T) {
+	batch := newFeeBatch([2]int64{30, 5}, [2]int64{20, 10}, [2]int64{40, 3})
+	bf := big.NewInt(15)
+	fs := MinProfitableFeeStrategy()
+
+	if tip := fs.GasTipCap(bf, big.NewInt(100), batch); tip.Int64() != 3 {
+		t.Fatalf("got tip %s, want 3", tip)
+	}
+	if mf := fs.GasFeeCap(bf, big.NewInt(100), batch); mf.Int64() != 20 {
+		t.Fatalf("got max fee %s, want 20", mf)
+	}
+	if gp := fs.GasPrice(big.NewInt(100), batch); gp.Int64() != 20 {
+		t.Fatalf("got gas price %s, want 20", gp)
+	}
+}
+
+// TestNetworkFeeStrategyWithCap verifies that network suggested fees are used up to the max fee.
+func TestNetworkFeeStrategyWithCap(t *testing.T) {
+	batch := newFeeBatch([2]int64{1000, 1000})
+	bf := big.NewInt(10)
+	tip := big.NewInt(2)
+
+	if mf := NetworkFeeStrategy(nil).GasFeeCap(bf, tip, batch); mf.Int64() != 22 {
+		t.Fatalf("got max fee %s, want 22", mf)
+	}
+	if mf := NetworkFeeStrategy(big.NewInt(15)).GasFeeCap(bf, tip, batch); mf.Int64() != 15 {
+		t.Fatalf("got max fee %s, want 15", mf)
+	}
+	if gp := NetworkFeeStrategy(big.NewInt(15)).GasPrice(big.NewInt(50), batch); gp.Int64() != 15 {
+		t.Fatalf("got gas price %s, want 15", gp)
+	}
+}
+
+// TestPercentileFeeStrategy verifies that the given percentile of the batch is used if it is above the
+// network suggested fees.
+func TestPercentileFeeStrategy(t *testing.T) {
+	batch := newFeeBatch([2]int64{10, 1}, [2]int64{20, 2}, [2]int64{30, 3}, [2]int64{40, 4})
+	bf := big.NewInt(1)
+
+	if tip := PercentileFeeStrategy(50).GasTipCap(bf, big.NewInt(0), batch); tip.Int64() != 2 {
+		t.Fatalf("got tip %s, want 2", tip)
+	}
+	if tip := PercentileFeeStrategy(90).GasTipCap(bf, big.NewInt(0), batch); tip.Int64() != 4 {
+		t.Fatalf("got tip %s, want 4", tip)
+	}
+	if tip := PercentileFeeStrategy(50).GasTipCap(bf, big.NewInt(7), batch); tip.Int64() != 7 {
+		t.Fatalf("got tip %s, want network tip 7", tip)
+	}
+	if mf := PercentileFeeStrategy(0).GasFeeCap(bf, big.NewInt(0), batch); mf.Int64() != 10 {
+		t.Fatalf("got max fee %s, want 10", mf)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
@@ -23,7 +23,7 @@ import (
 type Opts struct {
 	// Options for the network
 	EOA     *signer.EOA
-	Eth     *ethclient.Client
+	Eth     backend.TxSender
 	ChainID *big.Int
 
 	// Options for the EntryPoint
@@ -38,6 +38,10 @@ type Opts struct {
 	GasLimit    uint64
 	NoSend      bool
 	WaitTimeout time.Duration
+
+	// FeeStrategy determines the transaction fees from the suggested values and the batch. Defaults to
+	// MeanFeeStrategy if nil.
+	FeeStrategy FeeStrategy
 }
 
 func toAbiType(batch []*userop.UserOperation) []entrypoint.UserOperation {
@@ -111,11 +115,15 @@ func HandleOps(opts *Opts) (txn *types.Transaction, err error) {
 	}
 	auth.Nonce = big.NewInt(int64(nonce))
 
+	fs := opts.FeeStrategy
+	if fs == nil {
+		fs = MeanFeeStrategy()
+	}
 	if opts.BaseFee != nil && opts.Tip != nil {
-		auth.GasTipCap = SuggestMeanGasTipCap(opts.Tip, opts.Batch)
-		auth.GasFeeCap = SuggestMeanGasFeeCap(opts.BaseFee, opts.Tip, opts.Batch)
+		auth.GasFeeCap = fs.GasFeeCap(opts.BaseFee, opts.Tip, opts.Batch)
+		auth.GasTipCap = minBigInt(fs.GasTipCap(opts.BaseFee, opts.Tip, opts.Batch), auth.GasFeeCap)
 	} else if opts.GasPrice != nil {
-		auth.GasPrice = SuggestMeanGasPrice(opts.GasPrice, opts.Batch)
+		auth.GasPrice = fs.GasPrice(opts.GasPrice, opts.Batch)
 	} else {
 		return nil, errors.New("transaction: either the dynamic or legacy gas fees must be set")
 	}
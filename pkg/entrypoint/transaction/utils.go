@@ -9,7 +9,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 // ToRawTxHex Takes a Geth types.Transaction and returns the encoded raw hex string.
@@ -20,7 +19,7 @@ func ToRawTxHex(txn *types.Transaction) string {
 }
 
 // Wait blocks the process until a given transaction has been included on-chain or timeout has been reached.
-func Wait(txn *types.Transaction, eth *ethclient.Client, timeout time.Duration) (*types.Transaction, error) {
+func Wait(txn *types.Transaction, eth bind.DeployBackend, timeout time.Duration) (*types.Transaction, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	if receipt, err := bind.WaitMined(ctx, eth, txn); err != nil {
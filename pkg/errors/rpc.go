@@ -11,7 +11,14 @@ var (
 	INVALID_SIGNATURE          = -32507
 	INVALID_FIELDS             = -32602
 
+	// MEMPOOL_FULL is not defined by ERC-4337. It is a bundler specific extension returned when the mempool is
+	// at capacity and an incoming UserOperation does not outbid the ops that would need to be evicted.
+	MEMPOOL_FULL = -32509
+
 	EXECUTION_REVERTED = -32521
+
+	UNAUTHORIZED   = -32001
+	LIMIT_EXCEEDED = -32005
 )
 
 // RPCError is a custom error that fits the JSON-RPC error spec.
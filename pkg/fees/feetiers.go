@@ -0,0 +1,117 @@
+package fees
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	// FeeHistoryBlockCount is the number of recent blocks used to derive priority fees for each tier.
+	FeeHistoryBlockCount = uint64(20)
+
+	// FeeHistoryPercentiles are the reward percentiles requested for the slow, normal, and fast tiers.
+	FeeHistoryPercentiles = []float64{10, 50, 90}
+)
+
+// FeeTiers contains recommended gas fees for a UserOperation at different levels of inclusion speed.
+type FeeTiers struct {
+	Slow   *GasPrices
+	Normal *GasPrices
+	Fast   *GasPrices
+}
+
+// medianRewards returns the median priority fee across blocks for each requested percentile. Blocks with no
+// gas used are skipped since they report zero rewards. If no block has any rewards, nil is returned.
+func medianRewards(fh *ethereum.FeeHistory, percentiles int) []*big.Int {
+	samples := make([][]*big.Int, percentiles)
+	for i, rewards := range fh.Reward {
+		if i < len(fh.GasUsedRatio) && fh.GasUsedRatio[i] == 0 {
+			continue
+		}
+		if len(rewards) != percentiles {
+			continue
+		}
+		for j, r := range rewards {
+			samples[j] = append(samples[j], r)
+		}
+	}
+	if len(samples[0]) == 0 {
+		return nil
+	}
+
+	medians := make([]*big.Int, percentiles)
+	for i, s := range samples {
+		sort.Slice(s, func(a, b int) bool { return s[a].Cmp(s[b]) < 0 })
+		medians[i] = s[len(s)/2]
+	}
+
+	// Enforce that a faster tier never suggests a lower tip than a slower one.
+	for i := 1; i < len(medians); i++ {
+		if medians[i].Cmp(medians[i-1]) < 0 {
+			medians[i] = medians[i-1]
+		}
+	}
+	return medians
+}
+
+func newTier(baseFee *big.Int, tip *big.Int) *GasPrices {
+	return &GasPrices{
+		MaxFeePerGas:         big.NewInt(0).Add(tip, big.NewInt(0).Mul(baseFee, common.Big2)),
+		MaxPriorityFeePerGas: big.NewInt(0).Set(tip),
+	}
+}
+
+// NewFeeTiers returns an instance of FeeTiers derived from the fee history of recent blocks. Each tier
+// suggests a maxFeePerGas of twice the next block's base fee plus its priority fee. If the network does not
+// support EIP-1559, all tiers are set to the suggested legacy gas price.
+//
+// Deprecated: Use Oracle.FeeTiers which caches fee history across calls.
+func NewFeeTiers(eth *ethclient.Client) (*FeeTiers, error) {
+	head, err := eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if head.BaseFee == nil {
+		sgp, err := eth.SuggestGasPrice(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		gp := &GasPrices{MaxFeePerGas: sgp, MaxPriorityFeePerGas: sgp}
+		return &FeeTiers{Slow: gp, Normal: gp, Fast: gp}, nil
+	}
+
+	fh, err := eth.FeeHistory(
+		context.Background(),
+		FeeHistoryBlockCount,
+		head.Number,
+		FeeHistoryPercentiles,
+	)
+	if err != nil {
+		return nil, err
+	}
+	baseFee := head.BaseFee
+	if len(fh.BaseFee) > 0 {
+		// The last element is the base fee of the block after the newest block in the range.
+		baseFee = fh.BaseFee[len(fh.BaseFee)-1]
+	}
+
+	tips := medianRewards(fh, len(FeeHistoryPercentiles))
+	if tips == nil {
+		tip, err := eth.SuggestGasTipCap(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		tips = []*big.Int{tip, tip, tip}
+	}
+
+	return &FeeTiers{
+		Slow:   newTier(baseFee, tips[0]),
+		Normal: newTier(baseFee, tips[1]),
+		Fast:   newTier(baseFee, tips[2]),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package fees
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+func rewards(vals ...int64) []*big.Int {
+	r := []*big.Int{}
+	for _, v := range vals {
+		r = append(r, big.NewInt(v))
+	}
+	return r
+}
+
+// TestMedianRewards verifies that the median reward is taken for each percentile and that empty blocks are
+// ignored.
+func TestMedianRewards(t *testing.T) {
+	fh := &ethereum.FeeHistory{
+		Reward: [][]*big.Int{
+			rewards(1, 5, 9),
+			rewards(0, 0, 0),
+			rewards(2, 6, 10),
+			rewards(3, 7, 11),
+		},
+		GasUsedRatio: []float64{0.5, 0, 0.5, 0.5},
+	}
+
+	m := medianRewards(fh, 3)
+	if len(m) != 3 {
+		t.Fatalf("got length %d, want 3", len(m))
+	}
+	for i, want := range []int64{2, 6, 10} {
+		if m[i].Int64() != want {
+			t.Fatalf("percentile %d: got %s, want %d", i, m[i], want)
+		}
+	}
+}
+
+// TestMedianRewardsNoSamples verifies that nil is returned if all blocks in the range are empty.
+func TestMedianRewardsNoSamples(t *testing.T) {
+	fh := &ethereum.FeeHistory{
+		Reward:       [][]*big.Int{rewards(0, 0, 0)},
+		GasUsedRatio: []float64{0},
+	}
+
+	if m := medianRewards(fh, 3); m != nil {
+		t.Fatalf("got %v, want nil", m)
+	}
+}
+
+// TestMedianRewardsMonotonic verifies that a faster tier never has a lower tip than a slower tier.
+func TestMedianRewardsMonotonic(t *testing.T) {
+	fh := &ethereum.FeeHistory{
+		Reward:       [][]*big.Int{rewards(5, 3, 8)},
+		GasUsedRatio: []float64{0.5},
+	}
+
+	m := medianRewards(fh, 3)
+	if m[1].Cmp(m[0]) < 0 || m[2].Cmp(m[1]) < 0 {
+		t.Fatalf("got %v, want non-decreasing tips", m)
+	}
+}
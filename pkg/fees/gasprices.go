@@ -15,6 +15,8 @@ type GasPrices struct {
 }
 
 // NewGasPrices returns an instance of GasPrices with the latest suggested fees derived from an Eth Client.
+//
+// Deprecated: Use Oracle.GasPrices which caches fee history across calls.
 func NewGasPrices(eth *ethclient.Client) (*GasPrices, error) {
 	gp := GasPrices{}
 	if head, err := eth.HeaderByNumber(context.Background(), nil); err != nil {
@@ -0,0 +1,266 @@
+package fees
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// DefaultOracleCacheTTL is the duration that fee history is reused before the Oracle queries the node
+	// again.
+	DefaultOracleCacheTTL = 2 * time.Second
+
+	// oracleRefreshCount is the number of blocks requested when updating a window that has already been
+	// filled.
+	oracleRefreshCount = uint64(4)
+
+	// The EIP-1559 base fee change denominator and elasticity multiplier.
+	baseFeeChangeDenominator = big.NewInt(8)
+	elasticityMultiplier     = float64(2)
+
+	// Precision used when applying a float gasUsedRatio to integer base fee math.
+	ratioPrecision = float64(1e6)
+)
+
+// feeHistoryClient is the subset of the eth client used by the Oracle.
+type feeHistoryClient interface {
+	FeeHistory(
+		ctx context.Context,
+		blockCount uint64,
+		lastBlock *big.Int,
+		rewardPercentiles []float64,
+	) (*ethereum.FeeHistory, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+type blockFees struct {
+	number       uint64
+	baseFee      *big.Int
+	gasUsedRatio float64
+	rewards      []*big.Int
+}
+
+// Oracle estimates gas fees from the eth_feeHistory of a sliding window of recent blocks. Results are cached
+// so that the bundler and client can share a single source without querying the node on every call.
+type Oracle struct {
+	eth         feeHistoryClient
+	window      uint64
+	ttl         time.Duration
+	mu          sync.Mutex
+	blocks      []*blockFees
+	lastUpdated time.Time
+}
+
+// NewOracle returns an Oracle that tracks fee history over the last FeeHistoryBlockCount blocks.
+func NewOracle(eth feeHistoryClient) *Oracle {
+	return &Oracle{
+		eth:    eth,
+		window: FeeHistoryBlockCount,
+		ttl:    DefaultOracleCacheTTL,
+	}
+}
+
+// SetCacheTTL defines the duration that fee history is reused before it is refreshed.
+func (o *Oracle) SetCacheTTL(ttl time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ttl = ttl
+}
+
+func (o *Oracle) fetch(count uint64) ([]*blockFees, error) {
+	fh, err := o.eth.FeeHistory(context.Background(), count, nil, FeeHistoryPercentiles)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []*blockFees{}
+	for i, ratio := range fh.GasUsedRatio {
+		bf := &blockFees{
+			number:       fh.OldestBlock.Uint64() + uint64(i),
+			baseFee:      big.NewInt(0),
+			gasUsedRatio: ratio,
+		}
+		if i < len(fh.BaseFee) && fh.BaseFee[i] != nil {
+			bf.baseFee = fh.BaseFee[i]
+		}
+		if i < len(fh.Reward) {
+			bf.rewards = fh.Reward[i]
+		}
+		blocks = append(blocks, bf)
+	}
+	return blocks, nil
+}
+
+// update appends newly fetched blocks to the window and drops any that fall outside of it. If the new blocks
+// are not contiguous with the existing window, the window is replaced entirely.
+func (o *Oracle) update(blocks []*blockFees) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	if len(o.blocks) > 0 && blocks[0].number <= o.blocks[len(o.blocks)-1].number+1 {
+		next := blocks[0].number
+		for len(o.blocks) > 0 && o.blocks[len(o.blocks)-1].number >= next {
+			o.blocks = o.blocks[:len(o.blocks)-1]
+		}
+		o.blocks = append(o.blocks, blocks...)
+	} else {
+		o.blocks = blocks
+	}
+
+	if uint64(len(o.blocks)) > o.window {
+		o.blocks = o.blocks[uint64(len(o.blocks))-o.window:]
+	}
+}
+
+// refresh fetches recent fee history if the cache has expired. Once the window is filled, only the most
+// recent blocks are requested unless a gap is detected.
+func (o *Oracle) refresh() error {
+	if len(o.blocks) > 0 && time.Since(o.lastUpdated) < o.ttl {
+		return nil
+	}
+
+	count := o.window
+	if len(o.blocks) > 0 {
+		count = oracleRefreshCount
+	}
+	blocks, err := o.fetch(count)
+	if err != nil {
+		return err
+	}
+	if len(o.blocks) > 0 && len(blocks) > 0 && blocks[0].number > o.blocks[len(o.blocks)-1].number+1 {
+		blocks, err = o.fetch(o.window)
+		if err != nil {
+			return err
+		}
+	}
+
+	o.update(blocks)
+	o.lastUpdated = time.Now()
+	return nil
+}
+
+func (o *Oracle) latest() *blockFees {
+	if len(o.blocks) == 0 {
+		return nil
+	}
+	return o.blocks[len(o.blocks)-1]
+}
+
+func (o *Oracle) isLegacy() bool {
+	l := o.latest()
+	return l == nil || l.baseFee.Cmp(common.Big0) == 0
+}
+
+func (o *Oracle) tips() ([]*big.Int, error) {
+	fh := &ethereum.FeeHistory{}
+	for _, b := range o.blocks {
+		fh.Reward = append(fh.Reward, b.rewards)
+		fh.GasUsedRatio = append(fh.GasUsedRatio, b.gasUsedRatio)
+	}
+	if tips := medianRewards(fh, len(FeeHistoryPercentiles)); tips != nil {
+		return tips, nil
+	}
+
+	tip, err := o.eth.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return []*big.Int{tip, tip, tip}, nil
+}
+
+// PredictBaseFee returns the base fee of the next block given the base fee and gasUsedRatio of its parent
+// using the EIP-1559 formula.
+func PredictBaseFee(baseFee *big.Int, gasUsedRatio float64) *big.Int {
+	// delta = baseFee * (gasUsed - target) / target / 8, where gasUsed / target = gasUsedRatio * elasticity.
+	diff := int64((gasUsedRatio*elasticityMultiplier - 1) * ratioPrecision)
+	if diff == 0 {
+		return big.NewInt(0).Set(baseFee)
+	}
+
+	delta := big.NewInt(0).Mul(baseFee, big.NewInt(diff))
+	delta.Div(delta, big.NewInt(int64(ratioPrecision)))
+	delta.Div(delta, baseFeeChangeDenominator)
+	if diff > 0 && delta.Cmp(common.Big1) < 0 {
+		delta = big.NewInt(1)
+	}
+
+	next := big.NewInt(0).Add(baseFee, delta)
+	if next.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return next
+}
+
+// BaseFee returns the predicted base fee of the next block. It returns nil if the network does not support
+// EIP-1559.
+func (o *Oracle) BaseFee() (*big.Int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.refresh(); err != nil {
+		return nil, err
+	}
+	if o.isLegacy() {
+		return nil, nil
+	}
+	l := o.latest()
+	return PredictBaseFee(l.baseFee, l.gasUsedRatio), nil
+}
+
+// GasTip returns the median priority fee paid at the normal tier percentile over the window.
+func (o *Oracle) GasTip() (*big.Int, error) {
+	ft, err := o.FeeTiers()
+	if err != nil {
+		return nil, err
+	}
+	return ft.Normal.MaxPriorityFeePerGas, nil
+}
+
+// GasPrices returns the suggested fees for the normal tier.
+func (o *Oracle) GasPrices() (*GasPrices, error) {
+	ft, err := o.FeeTiers()
+	if err != nil {
+		return nil, err
+	}
+	return ft.Normal, nil
+}
+
+// FeeTiers returns suggested fees for each tier based on the percentile tips over the window and the
+// predicted base fee of the next block. If the network does not support EIP-1559, all tiers are set to the
+// suggested legacy gas price.
+func (o *Oracle) FeeTiers() (*FeeTiers, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.refresh(); err != nil {
+		return nil, err
+	}
+	if o.isLegacy() {
+		sgp, err := o.eth.SuggestGasPrice(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		gp := &GasPrices{MaxFeePerGas: sgp, MaxPriorityFeePerGas: sgp}
+		return &FeeTiers{Slow: gp, Normal: gp, Fast: gp}, nil
+	}
+
+	tips, err := o.tips()
+	if err != nil {
+		return nil, err
+	}
+	l := o.latest()
+	bf := PredictBaseFee(l.baseFee, l.gasUsedRatio)
+	return &FeeTiers{
+		Slow:   newTier(bf, tips[0]),
+		Normal: newTier(bf, tips[1]),
+		Fast:   newTier(bf, tips[2]),
+	}, nil
+}
@@ -0,0 +1,138 @@
+package fees
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+type mockFeeHistoryClient struct {
+	head     uint64
+	baseFee  *big.Int
+	calls    []uint64
+	tip      *big.Int
+	gasPrice *big.Int
+}
+
+func (m *mockFeeHistoryClient) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*ethereum.FeeHistory, error) {
+	m.calls = append(m.calls, blockCount)
+	oldest := m.head - blockCount + 1
+	fh := &ethereum.FeeHistory{OldestBlock: big.NewInt(int64(oldest))}
+	for n := oldest; n <= m.head; n++ {
+		fh.BaseFee = append(fh.BaseFee, m.baseFee)
+		fh.GasUsedRatio = append(fh.GasUsedRatio, 0.5)
+		fh.Reward = append(fh.Reward, rewards(int64(n), int64(n)*2, int64(n)*3))
+	}
+	fh.BaseFee = append(fh.BaseFee, m.baseFee)
+	return fh, nil
+}
+
+func (m *mockFeeHistoryClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return m.tip, nil
+}
+
+func (m *mockFeeHistoryClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return m.gasPrice, nil
+}
+
+func newMockOracle(m *mockFeeHistoryClient, window uint64) *Oracle {
+	o := NewOracle(m)
+	o.window = window
+	o.SetCacheTTL(0)
+	return o
+}
+
+// TestPredictBaseFee verifies the EIP-1559 base fee formula for full, empty, and target blocks.
+func TestPredictBaseFee(t *testing.T) {
+	bf := big.NewInt(1000)
+	tests := map[float64]int64{
+		0.5: 1000,
+		1:   1125,
+		0:   875,
+	}
+	for ratio, want := range tests {
+		if got := PredictBaseFee(bf, ratio); got.Int64() != want {
+			t.Fatalf("ratio %v: got %s, want %d", ratio, got, want)
+		}
+	}
+
+	if got := PredictBaseFee(big.NewInt(1), 0.75); got.Int64() != 2 {
+		t.Fatalf("got %s, want minimum increase of 1", got)
+	}
+}
+
+// TestOracleSlidingWindow verifies that the Oracle fills its window once and then only requests recent
+// blocks, dropping blocks that fall outside of the window.
+func TestOracleSlidingWindow(t *testing.T) {
+	m := &mockFeeHistoryClient{head: 100, baseFee: big.NewInt(10)}
+	o := newMockOracle(m, 5)
+
+	if _, err := o.FeeTiers(); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	m.head = 102
+	ft, err := o.FeeTiers()
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if len(m.calls) != 2 || m.calls[0] != 5 || m.calls[1] != oracleRefreshCount {
+		t.Fatalf("got calls %v, want [5 %d]", m.calls, oracleRefreshCount)
+	}
+	if len(o.blocks) != 5 || o.blocks[0].number != 98 || o.latest().number != 102 {
+		t.Fatalf("got window [%d, %d], want [98, 102]", o.blocks[0].number, o.latest().number)
+	}
+
+	// Median of blocks 98 to 102 for each percentile, plus twice the predicted base fee.
+	if ft.Slow.MaxPriorityFeePerGas.Int64() != 100 || ft.Fast.MaxPriorityFeePerGas.Int64() != 300 {
+		t.Fatalf("got tips %s and %s, want 100 and 300", ft.Slow.MaxPriorityFeePerGas, ft.Fast.MaxPriorityFeePerGas)
+	}
+	if ft.Normal.MaxFeePerGas.Int64() != 220 {
+		t.Fatalf("got maxFeePerGas %s, want 220", ft.Normal.MaxFeePerGas)
+	}
+}
+
+// TestOracleGap verifies that the window is refilled if more blocks have passed than a refresh covers.
+func TestOracleGap(t *testing.T) {
+	m := &mockFeeHistoryClient{head: 100, baseFee: big.NewInt(10)}
+	o := newMockOracle(m, 5)
+
+	if _, err := o.GasTip(); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	m.head = 120
+	if _, err := o.GasTip(); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if len(m.calls) != 3 || m.calls[2] != 5 {
+		t.Fatalf("got calls %v, want full window refetch", m.calls)
+	}
+	if o.blocks[0].number != 116 || o.latest().number != 120 {
+		t.Fatalf("got window [%d, %d], want [116, 120]", o.blocks[0].number, o.latest().number)
+	}
+}
+
+// TestOracleLegacy verifies that the suggested gas price is used if the network has no base fee.
+func TestOracleLegacy(t *testing.T) {
+	m := &mockFeeHistoryClient{head: 100, baseFee: big.NewInt(0), gasPrice: big.NewInt(42)}
+	o := newMockOracle(m, 5)
+
+	gp, err := o.GasPrices()
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if gp.MaxFeePerGas.Int64() != 42 || gp.MaxPriorityFeePerGas.Int64() != 42 {
+		t.Fatalf("got %s and %s, want 42", gp.MaxFeePerGas, gp.MaxPriorityFeePerGas)
+	}
+	if bf, _ := o.BaseFee(); bf != nil {
+		t.Fatalf("got base fee %s, want nil", bf)
+	}
+}
@@ -0,0 +1,36 @@
+package gas
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
+)
+
+// ErrPreVerificationGasTooLow is returned when a UserOperation's preVerificationGas does not cover its own
+// overhead regardless of the bundle size.
+var ErrPreVerificationGasTooLow = errors.New("preVerificationGas: too low for any bundle size")
+
+// ExpectedBundleSizeFunc defines an interface for a function that returns the number of UserOperations
+// expected in a bundle.
+type ExpectedBundleSizeFunc = func() float64
+
+func expectedBundleSizeNoop(size float64) ExpectedBundleSizeFunc {
+	return func() float64 {
+		return size
+	}
+}
+
+// calcBundleCallDataCost returns the calldata cost of calling handleOps with an empty batch. A beneficiary
+// with no zero bytes is assumed.
+func (ov *Overhead) calcBundleCallDataCost() float64 {
+	args, err := methods.HandleOpsMethod.Inputs.Pack(
+		[]entrypoint.UserOperation{},
+		common.HexToAddress("0xffffffffffffffffffffffffffffffffffffffff"),
+	)
+	if err != nil {
+		return 0
+	}
+	return ov.calcBytesCost(append(methods.HandleOpsMethod.ID, args...))
+}
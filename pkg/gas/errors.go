@@ -0,0 +1,126 @@
+package gas
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/execution"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	prefundNotPaidCodes = map[string]bool{
+		"AA21": true,
+		"AA31": true,
+	}
+	validationOOGCodes = map[string]bool{
+		"AA13": true,
+		"AA23": true,
+		"AA33": true,
+		"AA40": true,
+		"AA41": true,
+		"AA51": true,
+	}
+)
+
+// EstimateRevert is the data field of an RPC error returned when a UserOperation fails during gas estimation.
+type EstimateRevert struct {
+	// Code is the EntryPoint error code (e.g. AA23). This is empty if the revert occurred during execution.
+	Code string `json:"code,omitempty"`
+
+	// Reason is the decoded revert reason.
+	Reason string `json:"reason"`
+
+	// Entity is the role of the contract responsible for the revert (e.g. account, factory, or paymaster).
+	Entity string `json:"entity,omitempty"`
+
+	// Target is the address of the contract responsible for the revert.
+	Target *common.Address `json:"target,omitempty"`
+
+	// Data is the raw revert data for EntryPoint errors, or the panic code or raw revert data for execution
+	// reverts that could not be decoded into a reason.
+	Data any `json:"data,omitempty"`
+}
+
+// getAACode returns the AA error code from an error returned during simulation.
+func getAACode(err error) string {
+	if rpcErr, ok := err.(*errors.RPCError); ok {
+		if fo, ok := rpcErr.Data().(*reverts.FailedOpRevert); ok {
+			return reverts.GetAACode(fo.Reason)
+		}
+	}
+	return reverts.GetAACode(err.Error())
+}
+
+func isPrefundNotPaid(err error) bool {
+	return prefundNotPaidCodes[getAACode(err)]
+}
+
+func isValidationOOG(err error) bool {
+	return validationOOGCodes[getAACode(err)] ||
+		strings.Contains(err.Error(), "return data out of bounds") ||
+		strings.Contains(err.Error(), "validation OOG")
+}
+
+func isExecutionOOG(err error) bool {
+	return strings.Contains(err.Error(), "execution OOG")
+}
+
+func isExecutionReverted(err error) bool {
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+func getEntityAddress(entity string, entryPoint common.Address, op *userop.UserOperation) *common.Address {
+	var addr common.Address
+	switch entity {
+	case reverts.FactoryEntity:
+		addr = op.GetFactory()
+	case reverts.AccountEntity:
+		addr = op.Sender
+	case reverts.PaymasterEntity:
+		addr = op.GetPaymaster()
+	case reverts.EntryPointEntity:
+		addr = entryPoint
+	default:
+		return nil
+	}
+	return &addr
+}
+
+// newEstimateError converts an error from simulation into an RPC error with a structured EstimateRevert as
+// the data field. Errors that are not from simulation are returned as is.
+func newEstimateError(err error, entryPoint common.Address, op *userop.UserOperation) error {
+	rpcErr, ok := err.(*errors.RPCError)
+	if !ok {
+		return err
+	}
+
+	rev := &EstimateRevert{Reason: rpcErr.Error()}
+	if code := getAACode(err); code != "" {
+		rev.Code = code
+		rev.Entity = reverts.GetAACodeEntity(code)
+		rev.Target = getEntityAddress(rev.Entity, entryPoint, op)
+		if fo, ok := rpcErr.Data().(*reverts.FailedOpRevert); ok {
+			if data, err := fo.Data(); err == nil {
+				rev.Data = data
+			}
+		}
+	} else if rpcErr.Code() == errors.EXECUTION_REVERTED && !strings.Contains(rpcErr.Error(), "validation OOG") {
+		// Reverts during the execution phase are attributed to the sender unless the trace shows the contract
+		// that originated the revert.
+		rev.Entity = reverts.AccountEntity
+		rev.Target = getEntityAddress(rev.Entity, entryPoint, op)
+		if er, ok := rpcErr.Data().(*execution.ExecutionRevert); ok {
+			if er.Target != nil {
+				rev.Target = er.Target
+			}
+			if er.Data != "" && er.Data != rpcErr.Error() {
+				rev.Data = er.Data
+			}
+		}
+	}
+
+	return errors.NewRPCError(rpcErr.Code(), rpcErr.Error(), rev)
+}
@@ -0,0 +1,97 @@
+package gas
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/execution"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+func getEstimateRevert(t *testing.T, err error) *EstimateRevert {
+	t.Helper()
+	rpcErr, ok := err.(*errors.RPCError)
+	if !ok {
+		t.Fatalf("got %T, want *errors.RPCError", err)
+	}
+	rev, ok := rpcErr.Data().(*EstimateRevert)
+	if !ok {
+		t.Fatalf("got data %T, want *EstimateRevert", rpcErr.Data())
+	}
+	return rev
+}
+
+// TestEstimateErrorFromFailedOp verifies that a FailedOp revert is converted into an EstimateRevert with the
+// AA code, the responsible paymaster, and the raw revert data.
+func TestEstimateErrorFromFailedOp(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	err := errors.NewRPCError(
+		errors.REJECTED_BY_EP_OR_ACCOUNT,
+		"AA33 reverted (or OOG)",
+		&reverts.FailedOpRevert{Reason: "AA33 reverted (or OOG)"},
+	)
+	rev := getEstimateRevert(t, newEstimateError(err, testutils.ValidAddress1, op))
+
+	if rev.Code != "AA33" {
+		t.Fatalf("code: got %s, want AA33", rev.Code)
+	} else if rev.Entity != reverts.PaymasterEntity {
+		t.Fatalf("entity: got %s, want %s", rev.Entity, reverts.PaymasterEntity)
+	} else if rev.Target == nil || *rev.Target != op.GetPaymaster() {
+		t.Fatalf("target: got %v, want %s", rev.Target, op.GetPaymaster())
+	} else if want := mockFailedOp(t, "AA33 reverted (or OOG)"); rev.Data != want {
+		t.Fatalf("data: got %v, want %s", rev.Data, want)
+	}
+}
+
+// TestEstimateErrorFromExecutionRevert verifies that an execution revert is attributed to the sender.
+func TestEstimateErrorFromExecutionRevert(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	err := errors.NewRPCError(errors.EXECUTION_REVERTED, "insufficient balance", "insufficient balance")
+	rev := getEstimateRevert(t, newEstimateError(err, testutils.ValidAddress1, op))
+
+	if rev.Code != "" {
+		t.Fatalf("code: got %s, want empty", rev.Code)
+	} else if rev.Reason != "insufficient balance" {
+		t.Fatalf("reason: got %s, want insufficient balance", rev.Reason)
+	} else if rev.Target == nil || *rev.Target != op.Sender {
+		t.Fatalf("target: got %v, want %s", rev.Target, op.Sender)
+	} else if rev.Data != nil {
+		t.Fatalf("data: got %v, want nil", rev.Data)
+	}
+}
+
+// TestEstimateErrorFromExecutionRevertTarget verifies that an execution revert with undecoded data is
+// attributed to the contract that originated it.
+func TestEstimateErrorFromExecutionRevertTarget(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	err := errors.NewRPCError(
+		errors.EXECUTION_REVERTED,
+		"execution reverted with data",
+		&execution.ExecutionRevert{Target: &testutils.ValidAddress5, Data: "0xdeadbeef"},
+	)
+	rev := getEstimateRevert(t, newEstimateError(err, testutils.ValidAddress1, op))
+
+	if rev.Entity != reverts.AccountEntity {
+		t.Fatalf("entity: got %s, want %s", rev.Entity, reverts.AccountEntity)
+	} else if rev.Target == nil || *rev.Target != testutils.ValidAddress5 {
+		t.Fatalf("target: got %v, want %s", rev.Target, testutils.ValidAddress5)
+	} else if rev.Data != "0xdeadbeef" {
+		t.Fatalf("data: got %v, want 0xdeadbeef", rev.Data)
+	}
+}
+
+// TestIsValidationOOG verifies that validation OOG errors are detected by AA code.
+func TestIsValidationOOG(t *testing.T) {
+	err := errors.NewRPCError(
+		errors.REJECTED_BY_EP_OR_ACCOUNT,
+		"AA40 over verificationGasLimit",
+		&reverts.FailedOpRevert{Reason: "AA40 over verificationGasLimit"},
+	)
+	if !isValidationOOG(err) {
+		t.Fatal("got false, want true")
+	}
+	if isPrefundNotPaid(err) {
+		t.Fatal("got true, want false")
+	}
+}
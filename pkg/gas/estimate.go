@@ -1,14 +1,16 @@
 package gas
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/execution"
 	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
@@ -16,31 +18,11 @@ var (
 	fallBackBinarySearchCutoff = int64(30000)
 	maxRetries                 = int64(7)
 	baseVGLBuffer              = int64(25)
-)
-
-func isPrefundNotPaid(err error) bool {
-	return strings.Contains(err.Error(), "AA21 didn't pay prefund") ||
-		strings.Contains(err.Error(), "AA31 paymaster deposit too low")
-}
-
-func isValidationOOG(err error) bool {
-	return strings.Contains(err.Error(), "AA40 over verificationGasLimit") ||
-		strings.Contains(err.Error(), "AA41 too little verificationGas") ||
-		strings.Contains(err.Error(), "AA51 prefund below actualGasCost") ||
-		strings.Contains(err.Error(), "AA13 initCode failed or OOG") ||
-		strings.Contains(err.Error(), "AA23 reverted") ||
-		strings.Contains(err.Error(), "AA33 reverted") ||
-		strings.Contains(err.Error(), "return data out of bounds") ||
-		strings.Contains(err.Error(), "validation OOG")
-}
 
-func isExecutionOOG(err error) bool {
-	return strings.Contains(err.Error(), "execution OOG")
-}
-
-func isExecutionReverted(err error) bool {
-	return strings.Contains(err.Error(), "execution reverted")
-}
+	// errTracerGasUnavailable is returned when the tracer cannot measure the gas required by a UserOperation.
+	// Only in this case will estimates fall back to binary search.
+	errTracerGasUnavailable = errors.New("estimate: tracer could not measure gas")
+)
 
 type EstimateInput struct {
 	Rpc         *rpc.Client
@@ -56,12 +38,39 @@ type EstimateInput struct {
 	lastVGL  int64
 }
 
+// Estimate is the result of gas estimation for a UserOperation. Along with the final gas limits, it includes
+// a breakdown of the gas used by each entity during simulation. The breakdown values are 0 if the entity was
+// not called or the tracer does not report it.
+type Estimate struct {
+	VerificationGasLimit uint64 `json:"verificationGasLimit"`
+	CallGasLimit         uint64 `json:"callGasLimit"`
+
+	FactoryGas               uint64 `json:"factoryGas"`
+	AccountVerificationGas   uint64 `json:"accountVerificationGas"`
+	PaymasterVerificationGas uint64 `json:"paymasterVerificationGas"`
+	PostOpGas                uint64 `json:"postOpGas"`
+}
+
+func newEstimate(vgl uint64, cgl uint64, trace *tracer.BundlerExecutionReturn) *Estimate {
+	est := &Estimate{
+		VerificationGasLimit: vgl,
+		CallGasLimit:         cgl,
+	}
+	if trace != nil {
+		est.FactoryGas = uint64(trace.FactoryGasUsed)
+		est.AccountVerificationGas = uint64(trace.AccountValidationGasUsed)
+		est.PaymasterVerificationGas = uint64(trace.PaymasterValidationGasUsed)
+		est.PostOpGas = uint64(trace.PostOpGasUsed)
+	}
+	return est
+}
+
 // retryEstimateGas will recursively call estimateGas if execution has caused VGL to be under estimated. This
 // can occur for edge cases where a paymaster's postOp > gas required during verification or if verification
 // has a dependency on CGL. Reset the estimate with a higher buffer on VGL.
-func retryEstimateGas(err error, vgl int64, in *EstimateInput) (uint64, uint64, error) {
+func retryEstimateGas(err error, vgl int64, in *EstimateInput) (*Estimate, error) {
 	if isValidationOOG(err) && in.attempts < maxRetries {
-		return EstimateGas(&EstimateInput{
+		return estimateGas(&EstimateInput{
 			Rpc:         in.Rpc,
 			EntryPoint:  in.EntryPoint,
 			Op:          in.Op,
@@ -74,16 +83,110 @@ func retryEstimateGas(err error, vgl int64, in *EstimateInput) (uint64, uint64,
 			lastVGL:     vgl,
 		})
 	}
-	return 0, 0, err
+	return nil, err
 }
 
 // EstimateGas uses the simulateHandleOp method on the EntryPoint to derive an estimate for
-// verificationGasLimit and callGasLimit.
-func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err error) {
+// verificationGasLimit and callGasLimit. If the UserOperation reverts, the returned RPC error will contain an
+// EstimateRevert with the decoded reason and the contract responsible.
+func EstimateGas(in *EstimateInput) (*Estimate, error) {
+	est, err := estimateGasWithTracer(in)
+	if errors.Is(err, errTracerGasUnavailable) {
+		// Fallback to binary search if a single trace is not sufficient. Any other error is a revert that
+		// binary search would also run into.
+		est, err = estimateGas(in)
+	}
+	if err != nil {
+		return nil, newEstimateError(err, in.EntryPoint, in.Op)
+	}
+	return est, nil
+}
+
+// estimateGasWithTracer derives verificationGasLimit and callGasLimit from the gas measured by the tracer in
+// a single simulation with max gas limits and a gas price of 0. The 63/64 rule is applied by the tracer and
+// the final estimate is confirmed with a second simulation using the actual gas price. If the tracer does not
+// report gas or the measured values are not sufficient, errTracerGasUnavailable is returned.
+func estimateGasWithTracer(in *EstimateInput) (*Estimate, error) {
+	data, err := in.Op.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	data["maxFeePerGas"] = hexutil.EncodeBig(big.NewInt(0))
+	data["maxPriorityFeePerGas"] = hexutil.EncodeBig(big.NewInt(0))
+	data["verificationGasLimit"] = hexutil.EncodeBig(in.MaxGasLimit)
+	data["callGasLimit"] = hexutil.EncodeBig(in.MaxGasLimit)
+	simOp, err := userop.New(data)
+	if err != nil {
+		return nil, err
+	}
+	out, err := execution.TraceSimulateHandleOp(&execution.TraceInput{
+		Rpc:         in.Rpc,
+		EntryPoint:  in.EntryPoint,
+		Op:          simOp,
+		Sos:         in.Sos,
+		ChainID:     in.ChainID,
+		TraceFeeCap: in.Op.MaxFeePerGas,
+		Tracer:      in.Tracer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Trace.ValidationGasLimit == 0 {
+		return nil, errTracerGasUnavailable
+	}
+
+	// The postOp call is also bounded by verificationGasLimit.
+	vgl := int64(out.Trace.ValidationGasLimit)
+	if postOp := (int64(out.Trace.PostOpGasUsed)*64 + 62) / 63; postOp > vgl {
+		vgl = postOp
+	}
+	vgl = (vgl * (100 + baseVGLBuffer)) / 100
+	cgl := big.NewInt(int64(out.Trace.ExecutionGasLimit))
+	if cgl.Cmp(in.Ov.NonZeroValueCall()) < 0 {
+		cgl = in.Ov.NonZeroValueCall()
+	}
+
+	// Confirm the estimate with actual gas fees.
+	sosCpy, err := state.Copy(in.Sos)
+	if err != nil {
+		return nil, err
+	}
+	if in.Op.GetPaymaster() == common.HexToAddress("0x") {
+		sosCpy = state.WithMaxBalanceOverride(in.Op.Sender, sosCpy)
+	}
+	data["maxFeePerGas"] = hexutil.EncodeBig(in.Op.MaxFeePerGas)
+	data["maxPriorityFeePerGas"] = hexutil.EncodeBig(in.Op.MaxFeePerGas)
+	data["verificationGasLimit"] = hexutil.EncodeBig(big.NewInt(vgl))
+	data["callGasLimit"] = hexutil.EncodeBig(cgl)
+	simOp, err = userop.New(data)
+	if err != nil {
+		return nil, err
+	}
+	final, err := execution.TraceSimulateHandleOp(&execution.TraceInput{
+		Rpc:        in.Rpc,
+		EntryPoint: in.EntryPoint,
+		Op:         simOp,
+		Sos:        sosCpy,
+		ChainID:    in.ChainID,
+		Tracer:     in.Tracer,
+	})
+	if err != nil {
+		// The op succeeded with max gas limits so running out of gas or reverting here means the measured
+		// values were not sufficient, e.g. a contract passing manual gas limits with a static discount.
+		if isValidationOOG(err) || isExecutionOOG(err) || isExecutionReverted(err) {
+			return nil, fmt.Errorf("%w: %s", errTracerGasUnavailable, err)
+		}
+		return nil, err
+	}
+
+	return newEstimate(simOp.VerificationGasLimit.Uint64(), simOp.CallGasLimit.Uint64(), final.Trace), nil
+}
+
+func estimateGas(in *EstimateInput) (*Estimate, error) {
 	// Set the initial conditions.
 	data, err := in.Op.ToMap()
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 	data["maxPriorityFeePerGas"] = hexutil.EncodeBig(in.Op.MaxFeePerGas)
 	data["verificationGasLimit"] = hexutil.EncodeBig(big.NewInt(0))
@@ -93,7 +196,7 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 	// max uint96. This ensures gas estimation is not blocked by insufficient funds.
 	sosCpy, err := state.Copy(in.Sos)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 	if in.Op.GetPaymaster() == common.HexToAddress("0x") {
 		sosCpy = state.WithMaxBalanceOverride(in.Op.Sender, sosCpy)
@@ -112,7 +215,7 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 		data["verificationGasLimit"] = hexutil.EncodeBig(big.NewInt(int64(m)))
 		simOp, err := userop.New(data)
 		if err != nil {
-			return 0, 0, err
+			return nil, err
 		}
 		_, err = execution.SimulateHandleOp(&execution.SimulateInput{
 			Rpc:        in.Rpc,
@@ -137,11 +240,11 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 			l = m + 1
 			continue
 		} else {
-			return 0, 0, err
+			return nil, err
 		}
 	}
 	if f == 0 {
-		return 0, 0, simErr
+		return nil, simErr
 	}
 	f = (f * (100 + baseVGLBuffer)) / 100
 	data["verificationGasLimit"] = hexutil.EncodeBig(big.NewInt(int64(f)))
@@ -154,7 +257,7 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 	data["callGasLimit"] = hexutil.EncodeBig(in.MaxGasLimit)
 	simOp, err := userop.New(data)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 	out, err := execution.TraceSimulateHandleOp(&execution.TraceInput{
 		Rpc:         in.Rpc,
@@ -183,9 +286,9 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 	data["callGasLimit"] = hexutil.EncodeBig(cgl)
 	simOp, err = userop.New(data)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
-	_, err = execution.TraceSimulateHandleOp(&execution.TraceInput{
+	final, err := execution.TraceSimulateHandleOp(&execution.TraceInput{
 		Rpc:        in.Rpc,
 		EntryPoint: in.EntryPoint,
 		Op:         simOp,
@@ -208,9 +311,9 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 				data["callGasLimit"] = hexutil.EncodeBig(big.NewInt(int64(m)))
 				simOp, err := userop.New(data)
 				if err != nil {
-					return 0, 0, err
+					return nil, err
 				}
-				_, err = execution.TraceSimulateHandleOp(&execution.TraceInput{
+				bsOut, err := execution.TraceSimulateHandleOp(&execution.TraceInput{
 					Rpc:        in.Rpc,
 					EntryPoint: in.EntryPoint,
 					Op:         simOp,
@@ -224,6 +327,7 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 					r = m - 1
 					// Set final.
 					f = m
+					final = bsOut
 					continue
 				} else if isPrefundNotPaid(err) {
 					// CGL too high, go lower.
@@ -234,15 +338,15 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 					continue
 				} else {
 					// Unexpected error.
-					return 0, 0, err
+					return nil, err
 				}
 			}
 			if f == 0 {
-				return 0, 0, simErr
+				return nil, simErr
 			}
-			return simOp.VerificationGasLimit.Uint64(), big.NewInt(f).Uint64(), nil
+			return newEstimate(simOp.VerificationGasLimit.Uint64(), big.NewInt(f).Uint64(), final.Trace), nil
 		}
 		return retryEstimateGas(err, simOp.VerificationGasLimit.Int64(), in)
 	}
-	return simOp.VerificationGasLimit.Uint64(), simOp.CallGasLimit.Uint64(), nil
+	return newEstimate(simOp.VerificationGasLimit.Uint64(), simOp.CallGasLimit.Uint64(), final.Trace), nil
 }
@@ -0,0 +1,50 @@
+package gas_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/e2e"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+)
+
+// BenchmarkEstimateGas compares the single trace estimator against the binary search estimator for a
+// UserOperation that deploys a SimpleAccount on a simulated chain.
+func BenchmarkEstimateGas(b *testing.B) {
+	h := e2e.New(b)
+	acc := h.NewAccount()
+	head, err := h.Sim.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tip := big.NewInt(1000000000)
+	maxFee := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	op := acc.UserOp(big.NewInt(0), []byte{}, maxFee, tip)
+
+	estimators := map[string]func(*gas.EstimateInput) (*gas.Estimate, error){
+		"Tracer":       gas.EstimateGasWithTracer,
+		"BinarySearch": gas.EstimateGasWithBinarySearch,
+	}
+	for name, fn := range estimators {
+		b.Run(name, func(b *testing.B) {
+			in := &gas.EstimateInput{
+				Rpc:         h.Rpc,
+				EntryPoint:  h.EntryPoint,
+				Op:          op,
+				Sos:         state.OverrideSet{},
+				Ov:          gas.NewDefaultOverhead(),
+				ChainID:     e2e.ChainID,
+				MaxGasLimit: big.NewInt(int64(e2e.GasLimit)),
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := fn(in); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package gas
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+)
+
+func mockExecutionResult(t testing.TB) string {
+	t.Helper()
+	uint256, _ := abi.NewType("uint256", "", nil)
+	uint48, _ := abi.NewType("uint48", "", nil)
+	boolean, _ := abi.NewType("bool", "", nil)
+	bytes, _ := abi.NewType("bytes", "", nil)
+	args := abi.Arguments{{Type: uint256}, {Type: uint256}, {Type: uint48}, {Type: uint48}, {Type: boolean}, {Type: bytes}}
+	data, err := args.Pack(big.NewInt(100000), big.NewInt(0), big.NewInt(0), big.NewInt(0), true, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := crypto.Keccak256([]byte("ExecutionResult(uint256,uint256,uint48,uint48,bool,bytes)"))[:4]
+	return hexutil.Encode(append(id, data...))
+}
+
+func mockFailedOp(t testing.TB, reason string) string {
+	t.Helper()
+	uint256, _ := abi.NewType("uint256", "", nil)
+	str, _ := abi.NewType("string", "", nil)
+	data, err := abi.Arguments{{Type: uint256}, {Type: str}}.Pack(big.NewInt(0), reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := crypto.Keccak256([]byte("FailedOp(uint256,string)"))[:4]
+	return hexutil.Encode(append(id, data...))
+}
+
+func mockRevertReason(t testing.TB, reason string) string {
+	t.Helper()
+	str, _ := abi.NewType("string", "", nil)
+	data, err := abi.Arguments{{Type: str}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := crypto.Keccak256([]byte("Error(string)"))[:4]
+	return hexutil.Encode(append(id, data...))
+}
+
+func mockUserOperationEvent(t testing.TB, success bool) *tracer.LogInfo {
+	t.Helper()
+	epAbi, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := epAbi.Events["UserOperationEvent"]
+	data, err := ev.Inputs.NonIndexed().Pack(big.NewInt(0), success, big.NewInt(0), big.NewInt(100000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tracer.LogInfo{
+		Topics: []string{
+			ev.ID.Hex(),
+			testutils.MockHash,
+			common.BytesToHash(testutils.ValidAddress1.Bytes()).Hex(),
+			common.Hash{}.Hex(),
+		},
+		Data: hexutil.Encode(data),
+	}
+}
+
+func mockExecutionTrace(t testing.TB) *tracer.BundlerExecutionReturn {
+	t.Helper()
+	return &tracer.BundlerExecutionReturn{
+		Reverts:            []string{},
+		ExecutionGasLimit:  50000,
+		ValidationGasUsed:  90000,
+		ValidationGasLimit: 91000,
+		UserOperationEvent: mockUserOperationEvent(t, true),
+		Output:             mockExecutionResult(t),
+	}
+}
+
+// estimateRpcMock returns a node that responds to debug_traceCall with the given trace and to eth_call with a
+// successful ExecutionResult. The number of eth_call requests made is counted in calls.
+func estimateRpcMock(t testing.TB, trace *tracer.BundlerExecutionReturn, calls *int64) *httptest.Server {
+	t.Helper()
+	output := mockExecutionResult(t)
+	results := map[string]any{
+		"eth_gasPrice":            "0x1",
+		"eth_getTransactionCount": "0x1",
+		"eth_getBlockByNumber":    testutils.NewBlockMock(),
+		"debug_traceCall":         trace,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			panic(err)
+		}
+
+		res := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if req.Method == "eth_call" {
+			atomic.AddInt64(calls, 1)
+			res["error"] = map[string]any{"code": 3, "message": "execution reverted", "data": output}
+		} else if result, ok := results[req.Method]; ok {
+			res["result"] = result
+		} else {
+			res["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+}
+
+func newEstimateInput(t testing.TB, url string) *EstimateInput {
+	t.Helper()
+	rpc, err := rpc.Dial(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = []byte{}
+	return &EstimateInput{
+		Rpc:         rpc,
+		EntryPoint:  testutils.ValidAddress1,
+		Op:          op,
+		Ov:          NewDefaultOverhead(),
+		ChainID:     testutils.ChainID,
+		MaxGasLimit: big.NewInt(18000000),
+	}
+}
+
+// TestEstimateGasWithTracer verifies that the single trace estimator applies the verificationGasLimit buffer
+// to the gas required by the validation phase.
+func TestEstimateGasWithTracer(t *testing.T) {
+	var calls int64
+	srv := estimateRpcMock(t, mockExecutionTrace(t), &calls)
+	defer srv.Close()
+
+	est, err := estimateGasWithTracer(newEstimateInput(t, srv.URL))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if want := uint64(91000 * (100 + baseVGLBuffer) / 100); est.VerificationGasLimit != want {
+		t.Fatalf("verificationGasLimit: got %d, want %d", est.VerificationGasLimit, want)
+	}
+	if est.CallGasLimit != 50000 {
+		t.Fatalf("callGasLimit: got %d, want 50000", est.CallGasLimit)
+	}
+}
+
+// TestEstimateGasFallsBackWithoutTracerGas calls EstimateGas with a tracer that does not report validation
+// gas. Expect the estimate to fall back to binary search.
+func TestEstimateGasFallsBackWithoutTracerGas(t *testing.T) {
+	var calls int64
+	trace := mockExecutionTrace(t)
+	trace.ValidationGasLimit = 0
+	srv := estimateRpcMock(t, trace, &calls)
+	defer srv.Close()
+
+	if _, err := EstimateGas(newEstimateInput(t, srv.URL)); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if atomic.LoadInt64(&calls) == 0 {
+		t.Fatal("got 0 eth_call requests, want binary search")
+	}
+}
+
+// TestEstimateGasReturnsRevert calls EstimateGas on a UserOperation that fails validation. Expect the revert
+// to be returned without falling back to binary search.
+func TestEstimateGasReturnsRevert(t *testing.T) {
+	var calls int64
+	trace := mockExecutionTrace(t)
+	trace.Output = mockFailedOp(t, "AA23 reverted (or OOG)")
+	srv := estimateRpcMock(t, trace, &calls)
+	defer srv.Close()
+
+	_, err := EstimateGas(newEstimateInput(t, srv.URL))
+	rpcErr, ok := err.(*errors.RPCError)
+	if !ok {
+		t.Fatalf("got %v, want RPCError", err)
+	}
+	if rev, ok := rpcErr.Data().(*EstimateRevert); !ok || rev.Code != "AA23" {
+		t.Fatalf("got %+v, want AA23 revert", rpcErr.Data())
+	}
+	if n := atomic.LoadInt64(&calls); n != 0 {
+		t.Fatalf("got %d eth_call requests, want 0", n)
+	}
+}
+
+// TestEstimateGasReturnsRevertTarget calls EstimateGas on a UserOperation where a contract called by the
+// account reverts and the account bubbles up the revert. Expect the target to be the originating contract.
+func TestEstimateGasReturnsRevertTarget(t *testing.T) {
+	var calls int64
+	reason := mockRevertReason(t, "insufficient balance")
+	trace := mockExecutionTrace(t)
+	trace.UserOperationEvent = mockUserOperationEvent(t, false)
+	trace.Reverts = []string{reason, reason}
+	trace.RevertTargets = []string{testutils.ValidAddress5.Hex(), testutils.ValidAddress2.Hex()}
+	srv := estimateRpcMock(t, trace, &calls)
+	defer srv.Close()
+
+	_, err := EstimateGas(newEstimateInput(t, srv.URL))
+	rev := getEstimateRevert(t, err)
+	if rev.Reason != "insufficient balance" {
+		t.Fatalf("reason: got %s, want insufficient balance", rev.Reason)
+	} else if rev.Entity != reverts.AccountEntity {
+		t.Fatalf("entity: got %s, want %s", rev.Entity, reverts.AccountEntity)
+	} else if rev.Target == nil || *rev.Target != testutils.ValidAddress5 {
+		t.Fatalf("target: got %v, want %s", rev.Target, testutils.ValidAddress5)
+	} else if rev.Data != nil {
+		t.Fatalf("data: got %v, want nil", rev.Data)
+	}
+}
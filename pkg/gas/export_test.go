@@ -0,0 +1,7 @@
+package gas
+
+// Unexported estimators used by benchmarks in the gas_test package.
+var (
+	EstimateGasWithTracer       = estimateGasWithTracer
+	EstimateGasWithBinarySearch = estimateGas
+)
@@ -0,0 +1,30 @@
+package gas
+
+import (
+	"math/big"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newFeeEstimate(op *userop.UserOperation, gp *fees.GasPrices) *FeeEstimate {
+	data := *op
+	data.MaxFeePerGas = gp.MaxFeePerGas
+	data.MaxPriorityFeePerGas = gp.MaxPriorityFeePerGas
+
+	return &FeeEstimate{
+		MaxFeePerGas:         big.NewInt(0).Set(gp.MaxFeePerGas),
+		MaxPriorityFeePerGas: big.NewInt(0).Set(gp.MaxPriorityFeePerGas),
+		RequiredPrefund:      data.GetMaxPrefund(),
+	}
+}
+
+// NewFeeEstimates returns the suggested fees for each tier along with the prefund required by the
+// UserOperation. The given op is expected to already have its gas limits set to the estimated values.
+func NewFeeEstimates(op *userop.UserOperation, ft *fees.FeeTiers) *FeeEstimates {
+	return &FeeEstimates{
+		Slow:   newFeeEstimate(op, ft.Slow),
+		Normal: newFeeEstimate(op, ft.Normal),
+		Fast:   newFeeEstimate(op, ft.Fast),
+	}
+}
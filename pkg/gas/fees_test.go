@@ -0,0 +1,32 @@
+package gas
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
+)
+
+// TestNewFeeEstimatesPrefund verifies that the required prefund for each tier is based on the tier's
+// maxFeePerGas and the gas limits of the op.
+func TestNewFeeEstimatesPrefund(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = []byte{}
+	op.PreVerificationGas = big.NewInt(50000)
+	op.VerificationGasLimit = big.NewInt(100000)
+	op.CallGasLimit = big.NewInt(200000)
+	tier := func(fee int64) *fees.GasPrices {
+		return &fees.GasPrices{MaxFeePerGas: big.NewInt(fee), MaxPriorityFeePerGas: big.NewInt(1)}
+	}
+
+	est := NewFeeEstimates(op, &fees.FeeTiers{Slow: tier(10), Normal: tier(20), Fast: tier(30)})
+	for fee, fe := range map[int64]*FeeEstimate{10: est.Slow, 20: est.Normal, 30: est.Fast} {
+		if want := big.NewInt(350000 * fee); fe.RequiredPrefund.Cmp(want) != 0 {
+			t.Fatalf("got prefund %s, want %s", fe.RequiredPrefund, want)
+		}
+	}
+	if op.MaxFeePerGas.Cmp(big.NewInt(10)) == 0 {
+		t.Fatal("op maxFeePerGas was modified")
+	}
+}
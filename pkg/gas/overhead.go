@@ -14,6 +14,7 @@ import (
 // Overhead provides helper methods for calculating gas limits based on pre-defined parameters.
 type Overhead struct {
 	intrinsicFixed      float64
+	bundleCallDataFixed float64
 	perUserOpFixed      float64
 	perUserOpMultiplier float64
 	zeroByte            float64
@@ -28,11 +29,12 @@ type Overhead struct {
 	sanitizedCGL        *big.Int
 	calcPVGFunc         CalcPreVerificationGasFunc
 	pvgBufferFactor     int64
+	expectedBundleSize  ExpectedBundleSizeFunc
 }
 
 // NewDefaultOverhead returns an instance of Overhead using parameters defined by the Ethereum protocol.
 func NewDefaultOverhead() *Overhead {
-	return &Overhead{
+	ov := &Overhead{
 		intrinsicFixed:      21000,
 		perUserOpFixed:      22874,
 		perUserOpMultiplier: 25,
@@ -49,6 +51,9 @@ func NewDefaultOverhead() *Overhead {
 		calcPVGFunc:         calcPVGFuncNoop(),
 		pvgBufferFactor:     0,
 	}
+	ov.bundleCallDataFixed = ov.calcBundleCallDataCost()
+	ov.expectedBundleSize = expectedBundleSizeNoop(ov.minBundleSize)
+	return ov
 }
 
 // SetCalcPreVerificationGasFunc allows a custom function to be defined that can control how it calculates
@@ -65,11 +70,16 @@ func (ov *Overhead) SetPreVerificationGasBufferFactor(factor int64) {
 	ov.pvgBufferFactor = factor
 }
 
-// CalcCallDataCost calculates the additional gas cost required to serialize the userOp when making the
-// transaction to submit the entire batch.
-func (ov *Overhead) CalcCallDataCost(op *userop.UserOperation) float64 {
+// SetExpectedBundleSizeFunc allows a custom function to be defined that returns the number of UserOperations
+// expected in a bundle. The fixed overhead of the bundle transaction is amortized across this many ops when
+// calculating PVG. Defaults to a bundle size of 1.
+func (ov *Overhead) SetExpectedBundleSizeFunc(fn ExpectedBundleSizeFunc) {
+	ov.expectedBundleSize = fn
+}
+
+func (ov *Overhead) calcBytesCost(data []byte) float64 {
 	cost := float64(0)
-	for _, b := range op.Pack() {
+	for _, b := range data {
 		if b == byte(0) {
 			cost += ov.zeroByte
 		} else {
@@ -80,6 +90,12 @@ func (ov *Overhead) CalcCallDataCost(op *userop.UserOperation) float64 {
 	return cost
 }
 
+// CalcCallDataCost calculates the additional gas cost required to serialize the userOp when making the
+// transaction to submit the entire batch.
+func (ov *Overhead) CalcCallDataCost(op *userop.UserOperation) float64 {
+	return ov.calcBytesCost(op.Pack())
+}
+
 // CalcPerUserOpCost calculates the gas overhead from processing a UserOperation's validation and execution
 // phase. This overhead is not constant and is correlated to the number of 32 byte words in the UserOperation.
 // It can be summarized in the equation perUserOpMultiplier * lenInWord + perUserOpFixed.
@@ -93,8 +109,13 @@ func (ov *Overhead) CalcPerUserOpCost(op *userop.UserOperation) float64 {
 	return cost
 }
 
-// CalcPreVerificationGas returns an expected gas cost for processing a UserOperation from a batch.
-func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int, error) {
+// CalcBundleFixedCost returns the gas overhead shared by all UserOperations in a batch. This includes the
+// intrinsic transaction gas and the calldata cost of calling handleOps without any ops.
+func (ov *Overhead) CalcBundleFixedCost() float64 {
+	return ov.intrinsicFixed + ov.bundleCallDataFixed
+}
+
+func (ov *Overhead) sanitize(op *userop.UserOperation) (*userop.UserOperation, error) {
 	// Sanitize fields to reduce as much variability due to length and zero bytes
 	data, err := op.ToMap()
 	if err != nil {
@@ -104,21 +125,48 @@ func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int,
 	data["verificationGasLimit"] = hexutil.EncodeBig(ov.sanitizedVGL)
 	data["callGasLimit"] = hexutil.EncodeBig(ov.sanitizedCGL)
 	data["signature"] = hexutil.Encode(bytes.Repeat([]byte{1}, len(op.Signature)))
-	tmp, err := userop.New(data)
+	return userop.New(data)
+}
+
+// calcVariableCost returns the per userOp overhead excluding calldata. The perUserOpFixed constant was derived
+// from single op bundles and therefore already includes the calldata cost of calling handleOps. This is
+// subtracted so that it is only counted once as part of the fixed bundle cost.
+func (ov *Overhead) calcVariableCost(op *userop.UserOperation) float64 {
+	return ov.CalcPerUserOpCost(op) - ov.bundleCallDataFixed
+}
+
+func (ov *Overhead) getBundleSize() float64 {
+	return math.Max(ov.minBundleSize, ov.expectedBundleSize())
+}
+
+// CalcPreVerificationGas returns an expected gas cost for processing a UserOperation from a batch.
+func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int, error) {
+	return ov.calcPreVerificationGas(op, ov.getBundleSize())
+}
+
+// CalcPreVerificationGasForBundleSize returns the gas cost for processing a UserOperation from a batch of a
+// known size. This is used by the Bundler to check ops against the batch it is about to send.
+func (ov *Overhead) CalcPreVerificationGasForBundleSize(op *userop.UserOperation, size int) (*big.Int, error) {
+	return ov.calcPreVerificationGas(op, math.Max(ov.minBundleSize, float64(size)))
+}
+
+func (ov *Overhead) calcPreVerificationGas(op *userop.UserOperation, bundleSize float64) (*big.Int, error) {
+	tmp, err := ov.sanitize(op)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate the additional gas for adding this userOp to a batch.
-	batchOv := (ov.intrinsicFixed / ov.minBundleSize) + ov.CalcCallDataCost(tmp)
+	// Calculate the additional gas for adding this userOp to a batch. The fixed bundle cost is amortized
+	// across the bundle size.
+	batchOv := (ov.CalcBundleFixedCost() / bundleSize) + ov.CalcCallDataCost(tmp)
 
 	// The total PVG is the sum of the batch overhead and the overhead for this userOp's validation and
 	// execution.
-	pvg := batchOv + ov.CalcPerUserOpCost(tmp)
+	pvg := batchOv + ov.calcVariableCost(tmp)
 	static := big.NewInt(int64(math.Round(pvg)))
 
 	// Use value from CalcPreVerificationGasFunc if set, otherwise return the static value.
-	g, err := ov.calcPVGFunc(tmp, static)
+	g, err := ov.calcPVGFunc(tmp, static, bundleSize)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +176,23 @@ func (ov *Overhead) CalcPreVerificationGas(op *userop.UserOperation) (*big.Int,
 	return static, nil
 }
 
+// CalcMinBundleSize returns the smallest number of UserOperations a batch must have for the op's
+// preVerificationGas to cover its share of the fixed bundle overhead. This only considers the static
+// overhead and not any L1 component added by a custom CalcPreVerificationGasFunc.
+func (ov *Overhead) CalcMinBundleSize(op *userop.UserOperation) (int, error) {
+	tmp, err := ov.sanitize(op)
+	if err != nil {
+		return 0, err
+	}
+
+	variable := ov.CalcCallDataCost(tmp) + ov.calcVariableCost(tmp)
+	avail := float64(op.PreVerificationGas.Int64()) - variable
+	if avail <= 0 {
+		return 0, ErrPreVerificationGasTooLow
+	}
+	return int(math.Max(ov.minBundleSize, math.Ceil(ov.CalcBundleFixedCost()/avail))), nil
+}
+
 // CalcPreVerificationGasWithBuffer returns CalcPreVerificationGas increased by the set PVG buffer factor.
 func (ov *Overhead) CalcPreVerificationGasWithBuffer(op *userop.UserOperation) (*big.Int, error) {
 	pvg, err := ov.CalcPreVerificationGas(op)
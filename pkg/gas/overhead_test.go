@@ -0,0 +1,76 @@
+package gas
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestCalcPreVerificationGasAmortized verifies that a larger expected bundle size reduces PVG by amortizing
+// the fixed bundle cost.
+func TestCalcPreVerificationGasAmortized(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ov := NewDefaultOverhead()
+	single, err := ov.CalcPreVerificationGas(op)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	ov.SetExpectedBundleSizeFunc(func() float64 { return 4 })
+	amortized, err := ov.CalcPreVerificationGas(op)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	saved := int64(ov.CalcBundleFixedCost() * 3 / 4)
+	if diff := big.NewInt(0).Sub(single, amortized).Int64(); diff < saved-1 || diff > saved+1 {
+		t.Fatalf("got reduction of %d, want %d", diff, saved)
+	}
+}
+
+// TestCalcMinBundleSize verifies the smallest bundle size at which an op's PVG covers its share of the fixed
+// bundle cost.
+func TestCalcMinBundleSize(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ov := NewDefaultOverhead()
+
+	ov.SetExpectedBundleSizeFunc(func() float64 { return 5 })
+	pvg, err := ov.CalcPreVerificationGas(op)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	op.PreVerificationGas = pvg
+	if min, err := ov.CalcMinBundleSize(op); err != nil || min != 5 {
+		t.Fatalf("got %d and err %v, want 5 and nil", min, err)
+	}
+
+	op.PreVerificationGas = big.NewInt(1)
+	if _, err := ov.CalcMinBundleSize(op); err != ErrPreVerificationGasTooLow {
+		t.Fatalf("got err %v, want %v", err, ErrPreVerificationGasTooLow)
+	}
+}
+
+// TestCalcPreVerificationGasFuncBundleSize verifies that the CalcPreVerificationGasFunc is given the expected
+// bundle size during estimation and the actual bundle size when checking a batch.
+func TestCalcPreVerificationGasFuncBundleSize(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	ov := NewDefaultOverhead()
+	ov.SetExpectedBundleSizeFunc(func() float64 { return 4 })
+
+	var got float64
+	ov.SetCalcPreVerificationGasFunc(
+		func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
+			got = bundleSize
+			return static, nil
+		},
+	)
+
+	if _, err := ov.CalcPreVerificationGas(op); err != nil || got != 4 {
+		t.Fatalf("got %v and err %v, want 4 and nil", got, err)
+	}
+	if _, err := ov.CalcPreVerificationGasForBundleSize(op, 1); err != nil || got != 1 {
+		t.Fatalf("got %v and err %v, want 1 and nil", got, err)
+	}
+}
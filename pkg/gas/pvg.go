@@ -8,6 +8,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -15,17 +16,24 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/linea"
 	"github.com/stackup-wallet/stackup-bundler/pkg/optimism/gaspriceoracle"
+	"github.com/stackup-wallet/stackup-bundler/pkg/scroll/l1gasoracle"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
-// CalcPreVerificationGasFunc defines an interface for a function to calculate PVG given a userOp and a static
-// value. The static input is the value derived from the default overheads.
-type CalcPreVerificationGasFunc = func(op *userop.UserOperation, static *big.Int) (*big.Int, error)
+// CalcPreVerificationGasFunc defines an interface for a function to calculate PVG given a userOp, a static
+// value, and a bundle size. The static input is the value derived from the default overheads with the fixed
+// bundle cost amortized across the bundle size.
+type CalcPreVerificationGasFunc = func(
+	op *userop.UserOperation,
+	static *big.Int,
+	bundleSize float64,
+) (*big.Int, error)
 
 func calcPVGFuncNoop() CalcPreVerificationGasFunc {
-	return func(op *userop.UserOperation, static *big.Int) (*big.Int, error) {
+	return func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
 		return nil, nil
 	}
 }
@@ -39,7 +47,7 @@ func CalcArbitrumPVGWithEthClient(
 ) CalcPreVerificationGasFunc {
 	pk, _ := crypto.GenerateKey()
 	dummy, _ := signer.New(hexutil.Encode(crypto.FromECDSA(pk))[2:])
-	return func(op *userop.UserOperation, static *big.Int) (*big.Int, error) {
+	return func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
 		// Sanitize paymasterAndData.
 		// TODO: Figure out why variability in this field is causing Arbitrum's precompile to return different
 		// values.
@@ -96,8 +104,92 @@ func CalcArbitrumPVGWithEthClient(
 	}
 }
 
+// amortizeL1Fee splits the portion of an L1 fee attributed to the shared transaction overhead across the
+// number of ops in a batch. The fee is assumed to scale linearly with the size of the transaction. The bundle
+// size can be fractional and is rounded to 3 decimal places.
+func amortizeL1Fee(l1fee *big.Int, txLen int, opLen int, bundleSize float64) *big.Int {
+	if bundleSize <= 1 || txLen <= opLen {
+		return l1fee
+	}
+
+	n := big.NewInt(int64(math.Round(bundleSize * 1000)))
+	fixed := big.NewInt(int64(txLen-opLen) * 1000)
+	share := big.NewInt(0).Mul(big.NewInt(int64(opLen)), n)
+	share.Add(share, fixed)
+	fee := big.NewInt(0).Mul(l1fee, share)
+	return fee.Div(fee, big.NewInt(0).Mul(big.NewInt(int64(txLen)), n))
+}
+
+// packedOpLen returns the number of bytes a userOp adds to the handleOps calldata.
+func packedOpLen(op *userop.UserOperation, beneficiary common.Address) (int, error) {
+	with, err := methods.HandleOpsMethod.Inputs.Pack(
+		[]entrypoint.UserOperation{entrypoint.UserOperation(*op)},
+		beneficiary,
+	)
+	if err != nil {
+		return 0, err
+	}
+	without, err := methods.HandleOpsMethod.Inputs.Pack([]entrypoint.UserOperation{}, beneficiary)
+	if err != nil {
+		return 0, err
+	}
+	return len(with) - len(without), nil
+}
+
+// newRawHandleOpsTx returns the latest block header and a signed raw transaction to call handleOps with a
+// single userOp.
+func newRawHandleOpsTx(
+	rpc *rpc.Client,
+	eoa *signer.EOA,
+	chainID *big.Int,
+	entryPoint common.Address,
+	op *userop.UserOperation,
+) (*types.Header, []byte, error) {
+	eth := ethclient.NewClient(rpc)
+	head, err := eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	tip, err := eth.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := transaction.HandleOps(&transaction.Opts{
+		EOA:         eoa,
+		Eth:         eth,
+		ChainID:     chainID,
+		EntryPoint:  entryPoint,
+		Batch:       []*userop.UserOperation{op},
+		Beneficiary: eoa.Address,
+		BaseFee:     head.BaseFee,
+		Tip:         tip,
+		GasLimit:    math.MaxUint64,
+		NoSend:      true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := hexutil.Decode(transaction.ToRawTxHex(tx))
+	if err != nil {
+		return nil, nil, err
+	}
+	return head, data, nil
+}
+
+// getL2Price returns the effective gas price paid by the userOp on L2.
+func getL2Price(op *userop.UserOperation, baseFee *big.Int) *big.Int {
+	l2price := op.MaxFeePerGas
+	l2priority := big.NewInt(0).Add(op.MaxPriorityFeePerGas, baseFee)
+	if l2priority.Cmp(l2price) == -1 {
+		l2price = l2priority
+	}
+	return l2price
+}
+
 // CalcOptimismPVGWithEthClient uses Optimism's Gas Price Oracle precompile to get an estimate for
-// preVerificationGas that takes into account the L1 gas component.
+// preVerificationGas that takes into account the L1 gas component. The L1 fee is calculated locally based on
+// the active fork (Bedrock, Ecotone, or Fjord) with the fixed transaction overhead amortized across the
+// bundle size. Oracle values are fetched at most once per block.
 func CalcOptimismPVGWithEthClient(
 	rpc *rpc.Client,
 	chainID *big.Int,
@@ -105,66 +197,93 @@ func CalcOptimismPVGWithEthClient(
 ) CalcPreVerificationGasFunc {
 	pk, _ := crypto.GenerateKey()
 	dummy, _ := signer.New(hexutil.Encode(crypto.FromECDSA(pk))[2:])
-	return func(op *userop.UserOperation, static *big.Int) (*big.Int, error) {
+	cache := gaspriceoracle.NewL1FeeParamsCache(rpc)
+	return func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
 		// Create Raw HandleOps Transaction
-		eth := ethclient.NewClient(rpc)
-		head, err := eth.HeaderByNumber(context.Background(), nil)
+		head, data, err := newRawHandleOpsTx(rpc, dummy, chainID, entryPoint, op)
 		if err != nil {
 			return nil, err
 		}
-		tip, err := eth.SuggestGasTipCap(context.Background())
+
+		// Calculate the L1Fee for the active fork and amortize the shared overhead.
+		params, err := cache.Get(head.Number)
 		if err != nil {
 			return nil, err
 		}
-		tx, err := transaction.HandleOps(&transaction.Opts{
-			EOA:         dummy,
-			Eth:         eth,
-			ChainID:     chainID,
-			EntryPoint:  entryPoint,
-			Batch:       []*userop.UserOperation{op},
-			Beneficiary: dummy.Address,
-			BaseFee:     head.BaseFee,
-			Tip:         tip,
-			GasLimit:    math.MaxUint64,
-			NoSend:      true,
-		})
+		opLen, err := packedOpLen(op, dummy.Address)
 		if err != nil {
 			return nil, err
 		}
+		l1fee := amortizeL1Fee(params.L1Fee(data), len(data), opLen, bundleSize)
 
-		// Encode function data for GetL1Fee
-		data, err := hexutil.Decode(transaction.ToRawTxHex(tx))
+		// Return static + L1 buffer as PVG. L1 buffer is equal to L1Fee/L2Price.
+		return big.NewInt(0).Add(static, big.NewInt(0).Div(l1fee, getL2Price(op, head.BaseFee))), nil
+	}
+}
+
+// CalcScrollPVGWithEthClient uses Scroll's L1GasPriceOracle precompile to get an estimate for
+// preVerificationGas that takes into account the L1 gas component.
+func CalcScrollPVGWithEthClient(
+	rpc *rpc.Client,
+	chainID *big.Int,
+	entryPoint common.Address,
+) CalcPreVerificationGasFunc {
+	pk, _ := crypto.GenerateKey()
+	dummy, _ := signer.New(hexutil.Encode(crypto.FromECDSA(pk))[2:])
+	return func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
+		// Create Raw HandleOps Transaction
+		head, data, err := newRawHandleOpsTx(rpc, dummy, chainID, entryPoint, op)
 		if err != nil {
 			return nil, err
 		}
-		ge, err := gaspriceoracle.GetL1FeeMethod.Inputs.Pack(data)
+
+		// Encode function data for GetL1Fee
+		ge, err := l1gasoracle.GetL1FeeMethod.Inputs.Pack(data)
 		if err != nil {
 			return nil, err
 		}
 
-		// Use eth_call to call the Gas Price Oracle precompile
+		// Use eth_call to call the L1GasPriceOracle precompile
 		req := map[string]any{
 			"from": common.HexToAddress("0x"),
-			"to":   gaspriceoracle.PrecompileAddress,
-			"data": hexutil.Encode(append(gaspriceoracle.GetL1FeeMethod.ID, ge...)),
+			"to":   l1gasoracle.PrecompileAddress,
+			"data": hexutil.Encode(append(l1gasoracle.GetL1FeeMethod.ID, ge...)),
 		}
 		var out any
 		if err := rpc.Call(&out, "eth_call", &req, "latest"); err != nil {
 			return nil, err
 		}
+		l1fee, err := l1gasoracle.DecodeGetL1FeeMethodOutput(out)
+		if err != nil {
+			return nil, err
+		}
+
+		// Return static + L1 buffer as PVG. L1 buffer is equal to L1Fee/L2Price.
+		return big.NewInt(0).Add(static, big.NewInt(0).Div(l1fee, getL2Price(op, head.BaseFee))), nil
+	}
+}
 
-		// Get L1Fee and L2Price
-		l1fee, err := gaspriceoracle.DecodeGetL1FeeMethodOutput(out)
+// CalcLineaPVGWithEthClient uses the pricing variables published in Linea's block extraData to get an
+// estimate for preVerificationGas that takes into account the L1 data cost. The uncompressed size of the packed
+// userOp is used as an upper bound for the compressed size posted by the sequencer.
+func CalcLineaPVGWithEthClient(rpc *rpc.Client) CalcPreVerificationGasFunc {
+	return func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
+		eth := ethclient.NewClient(rpc)
+		head, err := eth.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return nil, err
+		}
+		extra, err := linea.ParseExtraData(head.Extra)
 		if err != nil {
 			return nil, err
 		}
-		l2price := op.MaxFeePerGas
-		l2priority := big.NewInt(0).Add(op.MaxPriorityFeePerGas, head.BaseFee)
-		if l2priority.Cmp(l2price) == -1 {
-			l2price = l2priority
+		baseFee := head.BaseFee
+		if baseFee == nil {
+			baseFee = big.NewInt(0)
 		}
 
-		// Return static + L1 buffer as PVG. L1 buffer is equal to L1Fee/L2Price.
-		return big.NewInt(0).Add(static, big.NewInt(0).Div(l1fee, l2price)), nil
+		// Return static + L1 buffer as PVG. L1 buffer is equal to DataFee/L2Price.
+		l1fee := extra.DataFee(len(op.Pack()))
+		return big.NewInt(0).Add(static, big.NewInt(0).Div(l1fee, getL2Price(op, baseFee))), nil
 	}
 }
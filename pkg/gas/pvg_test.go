@@ -0,0 +1,216 @@
+package gas
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/linea"
+	"github.com/stackup-wallet/stackup-bundler/pkg/scroll/l1gasoracle"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// pvgFixture holds the node responses used by an L2 PVG calculator at a block of a chain. L1Fee is the raw
+// eth_call output of the Scroll L1GasPriceOracle for a handleOps transaction with
+// testutils.MockValidInitUserOp.
+type pvgFixture struct {
+	ChainID              *hexutil.Big    `json:"chainId"`
+	Header               json.RawMessage `json:"header"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	L1Fee                string          `json:"l1Fee,omitempty"`
+}
+
+func readPVGFixture(t *testing.T, name string) *pvgFixture {
+	t.Helper()
+	var f pvgFixture
+	testutils.ReadFixture(t, name, &f)
+	return &f
+}
+
+func (f *pvgFixture) header(t *testing.T) *types.Header {
+	t.Helper()
+	var h types.Header
+	if err := json.Unmarshal(f.Header, &h); err != nil {
+		t.Fatal(err)
+	}
+	return &h
+}
+
+// pvgRpcMock returns a client for a node that serves the fixture responses. Calls to eth_call are only
+// answered if they are for getL1Fee on the Scroll L1GasPriceOracle.
+func pvgRpcMock(t *testing.T, f *pvgFixture) *rpc.Client {
+	t.Helper()
+	results := map[string]any{
+		"eth_getBlockByNumber":     f.Header,
+		"eth_maxPriorityFeePerGas": f.MaxPriorityFeePerGas,
+		"eth_getTransactionCount":  "0x0",
+	}
+	oracle := strings.ToLower(l1gasoracle.PrecompileAddress.Hex())
+	selector := hexutil.Encode(l1gasoracle.GetL1FeeMethod.ID)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			panic(err)
+		}
+
+		res := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if req.Method == "eth_call" {
+			var call struct {
+				To   string `json:"to"`
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(req.Params[0], &call); err != nil {
+				panic(err)
+			}
+			if f.L1Fee != "" && strings.ToLower(call.To) == oracle && strings.HasPrefix(call.Data, selector) {
+				res["result"] = f.L1Fee
+			} else {
+				res["error"] = map[string]any{"code": -32000, "message": "execution reverted"}
+			}
+		} else if result, ok := results[req.Method]; ok {
+			res["result"] = result
+		} else {
+			res["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := rpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// recordPVGFixture saves the latest header and priority fee from the node given with -record-rpc. If
+// withL1Fee is set, the L1GasPriceOracle fee for a handleOps transaction with testutils.MockValidInitUserOp is
+// also saved.
+func recordPVGFixture(t *testing.T, name string, withL1Fee bool) {
+	t.Helper()
+	testutils.SkipUnlessRecording(t)
+	c := testutils.DialRecordRpc(t)
+	chainID, err := ethclient.NewClient(c).ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &pvgFixture{ChainID: (*hexutil.Big)(chainID), MaxPriorityFeePerGas: new(hexutil.Big)}
+	if err := c.Call(&f.Header, "eth_getBlockByNumber", "latest", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Call(f.MaxPriorityFeePerGas, "eth_maxPriorityFeePerGas"); err != nil {
+		t.Fatal(err)
+	}
+
+	if withL1Fee {
+		pk, _ := crypto.GenerateKey()
+		dummy, _ := signer.New(hexutil.Encode(crypto.FromECDSA(pk))[2:])
+		head, data, err := newRawHandleOpsTx(
+			c, dummy, chainID, testutils.ValidAddress1, testutils.MockValidInitUserOp(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ge, err := l1gasoracle.GetL1FeeMethod.Inputs.Pack(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := map[string]any{
+			"to":   l1gasoracle.PrecompileAddress,
+			"data": hexutil.Encode(append(l1gasoracle.GetL1FeeMethod.ID, ge...)),
+		}
+		if err := c.Call(&f.L1Fee, "eth_call", &req, hexutil.EncodeBig(head.Number)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testutils.WriteFixture(t, name, f)
+}
+
+// TestRecordScrollPVGFixture saves the Scroll fixture from the node given with -record-rpc.
+func TestRecordScrollPVGFixture(t *testing.T) {
+	recordPVGFixture(t, "scroll", true)
+}
+
+// TestRecordLineaPVGFixture saves the Linea fixture from the node given with -record-rpc.
+func TestRecordLineaPVGFixture(t *testing.T) {
+	recordPVGFixture(t, "linea", false)
+}
+
+// TestAmortizeL1Fee verifies that only the fixed overhead of the transaction is split across the bundle
+// size.
+func TestAmortizeL1Fee(t *testing.T) {
+	l1fee := big.NewInt(1000)
+
+	if fee := amortizeL1Fee(l1fee, 1000, 600, 1); fee.Cmp(l1fee) != 0 {
+		t.Fatalf("batch size 1: got %s, want %s", fee, l1fee)
+	}
+	// 600 bytes from the op + 400 bytes of overhead / 4 ops.
+	if fee := amortizeL1Fee(l1fee, 1000, 600, 4); fee.Int64() != 700 {
+		t.Fatalf("batch size 4: got %s, want 700", fee)
+	}
+	// 600 bytes from the op + 400 bytes of overhead / 2.5 ops.
+	if fee := amortizeL1Fee(l1fee, 1000, 600, 2.5); fee.Int64() != 760 {
+		t.Fatalf("batch size 2.5: got %s, want 760", fee)
+	}
+}
+
+// TestCalcScrollPVGWithEthClient calls CalcScrollPVGWithEthClient against the Scroll fixture. Expect PVG to
+// be the static value plus the oracle's L1 fee divided by the userOp's L2 gas price.
+func TestCalcScrollPVGWithEthClient(t *testing.T) {
+	f := readPVGFixture(t, "scroll")
+	op := testutils.MockValidInitUserOp()
+	static := big.NewInt(50000)
+
+	calc := CalcScrollPVGWithEthClient(pvgRpcMock(t, f), testutils.ChainID, testutils.ValidAddress1)
+	pvg, err := calc(op, static, 1)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	l1fee := new(big.Int).SetBytes(common.FromHex(f.L1Fee))
+	l1gas := new(big.Int).Div(l1fee, getL2Price(op, f.header(t).BaseFee))
+	if want := new(big.Int).Add(static, l1gas); pvg.Cmp(want) != 0 || l1gas.Sign() == 0 {
+		t.Fatalf("got %s, want %s", pvg, want)
+	}
+}
+
+// TestCalcLineaPVGWithEthClient calls CalcLineaPVGWithEthClient against the Linea fixture. Expect PVG to be
+// the static value plus the data fee for the uncompressed userOp divided by the userOp's L2 gas price.
+func TestCalcLineaPVGWithEthClient(t *testing.T) {
+	f := readPVGFixture(t, "linea")
+	head := f.header(t)
+	op := testutils.MockValidInitUserOp()
+	static := big.NewInt(50000)
+
+	pvg, err := CalcLineaPVGWithEthClient(pvgRpcMock(t, f))(op, static, 1)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	extra, err := linea.ParseExtraData(head.Extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l1gas := new(big.Int).Div(extra.DataFee(len(op.Pack())), getL2Price(op, head.BaseFee))
+	if want := new(big.Int).Add(static, l1gas); pvg.Cmp(want) != 0 || l1gas.Sign() == 0 {
+		t.Fatalf("got %s, want %s", pvg, want)
+	}
+}
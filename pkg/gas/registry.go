@@ -0,0 +1,128 @@
+package gas
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// NoPVGCalculator is the name used to disable any chain specific PVGCalculator.
+const NoPVGCalculator = "none"
+
+// PVGCalculatorInput contains the network parameters passed to a PVGCalculator.
+type PVGCalculatorInput struct {
+	Rpc        *rpc.Client
+	ChainID    *big.Int
+	EntryPoint common.Address
+}
+
+// PVGCalculator describes how preVerificationGas is calculated on a network with an L1 data fee.
+type PVGCalculator struct {
+	// New returns the CalcPreVerificationGasFunc for the network.
+	New func(in *PVGCalculatorInput) CalcPreVerificationGasFunc
+
+	// BufferFactor is the percentage to increase preVerificationGas by during an estimation.
+	BufferFactor int64
+}
+
+// Apply sets the calculator's CalcPreVerificationGasFunc and buffer factor on the given Overhead.
+func (c *PVGCalculator) Apply(ov *Overhead, in *PVGCalculatorInput) {
+	ov.SetCalcPreVerificationGasFunc(c.New(in))
+	ov.SetPreVerificationGasBufferFactor(c.BufferFactor)
+}
+
+// PVGRegistry maps chain IDs to the PVGCalculator used on that network. Calculators are registered under a
+// name so that they can also be selected explicitly.
+type PVGRegistry struct {
+	calculators map[string]*PVGCalculator
+	chains      map[uint64]string
+}
+
+// NewPVGRegistry returns an empty PVGRegistry.
+func NewPVGRegistry() *PVGRegistry {
+	return &PVGRegistry{
+		calculators: make(map[string]*PVGCalculator),
+		chains:      make(map[uint64]string),
+	}
+}
+
+// Register adds a named PVGCalculator and sets it as the default for the given chain IDs. Registering a
+// chain ID again will override the previous calculator for that chain.
+func (r *PVGRegistry) Register(name string, calc *PVGCalculator, chainIDs ...uint64) {
+	r.calculators[name] = calc
+	for _, id := range chainIDs {
+		r.chains[id] = name
+	}
+}
+
+// Names returns the names of all registered calculators in alphabetical order.
+func (r *PVGRegistry) Names() []string {
+	names := []string{}
+	for name := range r.calculators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find returns the PVGCalculator to use for a network. If name is not empty, the calculator registered under
+// that name is returned regardless of chain ID. Otherwise the calculator registered for the chain ID is
+// returned. A nil calculator means the default static overhead should be used. This can be forced with the
+// name NoPVGCalculator.
+func (r *PVGRegistry) Find(name string, chainID uint64) (*PVGCalculator, error) {
+	if name == "" {
+		name = r.chains[chainID]
+	}
+	if name == "" || name == NoPVGCalculator {
+		return nil, nil
+	}
+
+	calc, ok := r.calculators[name]
+	if !ok {
+		return nil, fmt.Errorf("pvg: unknown calculator %s, expected one of %v", name, r.Names())
+	}
+	return calc, nil
+}
+
+// ArbitrumPVGCalculator uses CalcArbitrumPVGWithEthClient.
+func ArbitrumPVGCalculator() *PVGCalculator {
+	return &PVGCalculator{
+		New: func(in *PVGCalculatorInput) CalcPreVerificationGasFunc {
+			return CalcArbitrumPVGWithEthClient(in.Rpc, in.EntryPoint)
+		},
+		BufferFactor: 16,
+	}
+}
+
+// OptimismPVGCalculator uses CalcOptimismPVGWithEthClient.
+func OptimismPVGCalculator() *PVGCalculator {
+	return &PVGCalculator{
+		New: func(in *PVGCalculatorInput) CalcPreVerificationGasFunc {
+			return CalcOptimismPVGWithEthClient(in.Rpc, in.ChainID, in.EntryPoint)
+		},
+		BufferFactor: 1,
+	}
+}
+
+// ScrollPVGCalculator uses CalcScrollPVGWithEthClient.
+func ScrollPVGCalculator() *PVGCalculator {
+	return &PVGCalculator{
+		New: func(in *PVGCalculatorInput) CalcPreVerificationGasFunc {
+			return CalcScrollPVGWithEthClient(in.Rpc, in.ChainID, in.EntryPoint)
+		},
+		BufferFactor: 1,
+	}
+}
+
+// LineaPVGCalculator uses CalcLineaPVGWithEthClient.
+func LineaPVGCalculator() *PVGCalculator {
+	return &PVGCalculator{
+		New: func(in *PVGCalculatorInput) CalcPreVerificationGasFunc {
+			return CalcLineaPVGWithEthClient(in.Rpc)
+		},
+		BufferFactor: 1,
+	}
+}
@@ -0,0 +1,44 @@
+package gas
+
+import (
+	"testing"
+)
+
+func newTestRegistry() (*PVGRegistry, *PVGCalculator, *PVGCalculator) {
+	a := &PVGCalculator{BufferFactor: 1}
+	b := &PVGCalculator{BufferFactor: 2}
+	r := NewPVGRegistry()
+	r.Register("a", a, 1, 2)
+	r.Register("b", b, 3)
+	return r, a, b
+}
+
+// TestPVGRegistryFindByChainID verifies that the calculator registered for a chain ID is returned by default.
+func TestPVGRegistryFindByChainID(t *testing.T) {
+	r, a, b := newTestRegistry()
+
+	if calc, err := r.Find("", 2); err != nil || calc != a {
+		t.Fatalf("got %v and err %v, want calculator a", calc, err)
+	}
+	if calc, err := r.Find("", 3); err != nil || calc != b {
+		t.Fatalf("got %v and err %v, want calculator b", calc, err)
+	}
+	if calc, err := r.Find("", 4); err != nil || calc != nil {
+		t.Fatalf("got %v and err %v, want nil", calc, err)
+	}
+}
+
+// TestPVGRegistryFindByName verifies that a named calculator overrides the chain ID default.
+func TestPVGRegistryFindByName(t *testing.T) {
+	r, _, b := newTestRegistry()
+
+	if calc, err := r.Find("b", 1); err != nil || calc != b {
+		t.Fatalf("got %v and err %v, want calculator b", calc, err)
+	}
+	if calc, err := r.Find(NoPVGCalculator, 1); err != nil || calc != nil {
+		t.Fatalf("got %v and err %v, want nil", calc, err)
+	}
+	if _, err := r.Find("c", 1); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
@@ -2,6 +2,21 @@ package gas
 
 import "math/big"
 
+// FeeEstimate provides suggested values for maxFeePerGas and maxPriorityFeePerGas along with the max amount
+// of wei required to prefund the UserOperation at those fees.
+type FeeEstimate struct {
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+	RequiredPrefund      *big.Int `json:"requiredPrefund"`
+}
+
+// FeeEstimates provides a FeeEstimate for each level of inclusion speed.
+type FeeEstimates struct {
+	Slow   *FeeEstimate `json:"slow"`
+	Normal *FeeEstimate `json:"normal"`
+	Fast   *FeeEstimate `json:"fast"`
+}
+
 // GasEstimates provides estimate values for all gas fields in a UserOperation.
 type GasEstimates struct {
 	PreVerificationGas   *big.Int `json:"preVerificationGas"`
@@ -10,4 +25,14 @@ type GasEstimates struct {
 
 	// TODO: Deprecate in v0.7
 	VerificationGas *big.Int `json:"verificationGas"`
+
+	// Gas used by each entity during simulation. A value is 0 if the entity was not part of the
+	// UserOperation or was not measured by the estimator.
+	FactoryGas               *big.Int `json:"factoryGas"`
+	AccountVerificationGas   *big.Int `json:"accountVerificationGas"`
+	PaymasterVerificationGas *big.Int `json:"paymasterVerificationGas"`
+	PostOpGas                *big.Int `json:"postOpGas"`
+
+	// Suggested fees based on recent fee history. This is omitted if fee tiers are not available.
+	Fees *FeeEstimates `json:"fees,omitempty"`
 }
@@ -0,0 +1,67 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+const (
+	callsKey = "json-rpc-calls"
+	batchKey = "json-rpc-batch"
+)
+
+// BeforeCallFunc is run for each valid JSON-RPC request before its method is called. Returning an error will
+// skip the method and respond with the error instead. For batch requests, it may be run concurrently.
+type BeforeCallFunc = func(c *gin.Context, call *Call) error
+
+// ComposeBeforeCallFuncs combines many BeforeCallFuncs into one. They are run in order until one returns an
+// error.
+func ComposeBeforeCallFuncs(fns ...BeforeCallFunc) BeforeCallFunc {
+	return func(c *gin.Context, call *Call) error {
+		for _, fn := range fns {
+			if err := fn(c, call); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Opts contains optional settings for the Controller.
+type Opts struct {
+	// MaxBatchConcurrency is the maximum number of elements in a batch request that are run at the same time.
+	// Defaults to 1 which runs each element sequentially.
+	MaxBatchConcurrency int
+
+	// BeforeCall is an optional hook that can reject individual requests, for example to apply rate limits.
+	BeforeCall BeforeCallFunc
+}
+
+// Call is a record of a single JSON-RPC request handled by the Controller. A batch request results in one
+// Call per element.
+type Call struct {
+	Method  string
+	ID      any
+	Params  []json.RawMessage
+	Error   *errors.RPCError
+	Latency time.Duration
+}
+
+// GetCalls returns the records of all JSON-RPC requests handled for the current HTTP request. If the
+// Controller has not run or the HTTP request could not be parsed, nil is returned.
+func GetCalls(c *gin.Context) []*Call {
+	calls, ok := c.Get(callsKey)
+	if !ok {
+		return nil
+	}
+	return calls.([]*Call)
+}
+
+// IsBatch returns true if the HTTP request contained a batch of JSON-RPC requests.
+func IsBatch(c *gin.Context) bool {
+	_, ok := c.Get(batchKey)
+	return ok
+}
@@ -2,30 +2,19 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
-	"reflect"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
-var (
-	optionalTypePrefix = "optional_"
-)
-
-func formatConversionErrMsg(i int, call *reflect.Value) string {
-	s, _ := strings.CutPrefix(call.Type().In(i).Name(), optionalTypePrefix)
-	return fmt.Sprintf("Param [%d] can't be converted to %s", i, s)
-}
-
-func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
-	c.JSON(http.StatusOK, gin.H{
+func jsonrpcErrorObject(code int, message string, data any, id any) gin.H {
+	return gin.H{
 		"jsonrpc": "2.0",
 		"error": gin.H{
 			"code":    code,
@@ -33,410 +22,157 @@ func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
 			"data":    data,
 		},
 		"id": id,
-	})
+	}
+}
+
+func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
+	c.JSON(http.StatusOK, jsonrpcErrorObject(code, message, data, id))
 	c.Abort()
 }
 
+// toRPCError converts errors that are not an RPCError to one.
+func toRPCError(err error) *errors.RPCError {
+	rpcErr, ok := err.(*errors.RPCError)
+	if !ok {
+		rpcErr = errors.NewRPCError(-32601, err.Error(), err.Error()).(*errors.RPCError)
+	}
+	return rpcErr
+}
+
+// AbortWithError responds with a single JSON-RPC error object and a null id. This can be used by middleware
+// that rejects the HTTP request before it reaches the Controller.
+func AbortWithError(c *gin.Context, err error) {
+	rpcErr := toRPCError(err)
+	jsonrpcError(c, rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), nil)
+}
+
 // parseRequestId checks if the JSON-RPC request contains an id field that is either NULL, Number, or String.
-func parseRequestId(data map[string]any) (any, bool) {
-	id, ok := data["id"]
+func parseRequestId(data map[string]json.RawMessage) (any, bool) {
+	raw, ok := data["id"]
+	if !ok {
+		return nil, false
+	}
+
+	var id any
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return nil, false
+	}
 	_, isFloat64 := id.(float64)
 	_, isStr := id.(string)
-
-	if ok && (id == nil || isFloat64 || isStr) {
+	if id == nil || isFloat64 || isStr {
 		return id, true
 	}
 	return nil, false
 }
 
-// hasOptionalInput checks if the API method has defined an optional final input:
-//  1. The input must start with the "optional_" prefix in its name.
-//  2. The input must be of kind Map.
-func hasOptionalInput(numIn int, call *reflect.Value) bool {
-	return numIn > 0 &&
-		strings.HasPrefix(call.Type().In(numIn-1).Name(), optionalTypePrefix) &&
-		call.Type().In(numIn-1).Kind() == reflect.Map
-}
-
-// hasValidParamLength checks if the number of parameters in the request is correct:
-//  1. Ok if the number of params equals number of method inputs.
-//  2. Ok if optional input is defined and number of params is one less the number of method inputs.
-func hasValidParamLength(numParams, numIn int, hasOptional bool) bool {
-	return numParams == numIn || (hasOptional && numParams == numIn-1)
-}
-
-// isOptionalParamUndefined checks if the optional input has been left unset in the request.
-func isOptionalParamUndefined(numParams, numIn int, hasOptional bool) bool {
-	return hasOptional && numParams == numIn-1
-}
+// parseRequest validates the fields of a single JSON-RPC request and fills in the call record with its id,
+// method, and params.
+func parseRequest(raw json.RawMessage, call *Call) error {
+	data := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return errors.NewRPCError(-32600, "Invalid Request", "Request is not an object")
+	}
 
-// handleRequest includes the core logic for parsing individual JSON-RPC requests and returning its id,
-// result, and success flag.
-func handleRequest(api interface{}, c *gin.Context, data map[string]any) (id any, result any, success bool) {
 	id, ok := parseRequestId(data)
 	if !ok {
-		jsonrpcError(c, -32600, "Invalid Request", "No or invalid 'id' in request", nil)
-		return id, nil, false
+		return errors.NewRPCError(-32600, "Invalid Request", "No or invalid 'id' in request")
 	}
+	call.ID = id
 
-	if data["jsonrpc"] != "2.0" {
-		jsonrpcError(c, -32600, "Invalid Request", "Version of jsonrpc is not 2.0", &id)
-		return id, nil, false
+	var version string
+	if err := json.Unmarshal(data["jsonrpc"], &version); err != nil || version != "2.0" {
+		return errors.NewRPCError(-32600, "Invalid Request", "Version of jsonrpc is not 2.0")
 	}
 
-	method, ok := data["method"].(string)
-	if !ok {
-		jsonrpcError(c, -32600, "Invalid Request", "No or invalid 'method' in request", &id)
-		return id, nil, false
+	if err := json.Unmarshal(data["method"], &call.Method); err != nil || call.Method == "" {
+		return errors.NewRPCError(-32600, "Invalid Request", "No or invalid 'method' in request")
 	}
 
-	params, ok := data["params"].([]interface{})
-	if !ok {
-		jsonrpcError(c, -32602, "Invalid params", "No or invalid 'params' in request", &id)
-		return id, nil, false
+	if params, ok := data["params"]; ok {
+		if err := json.Unmarshal(params, &call.Params); err != nil {
+			return errors.NewRPCError(-32602, "Invalid params", "No or invalid 'params' in request")
+		}
 	}
+	return nil
+}
 
-	call := reflect.ValueOf(api).MethodByName(cases.Title(language.Und, cases.NoLower).String(method))
-	if !call.IsValid() {
-		jsonrpcError(c, -32601, "Method not found", "Method not found", &id)
-		return id, nil, false
+// handleRequest includes the core logic for parsing individual JSON-RPC requests and calling the registered
+// method.
+func handleRequest(c *gin.Context, r *Registry, opts *Opts, raw json.RawMessage, call *Call) (any, error) {
+	if err := parseRequest(raw, call); err != nil {
+		return nil, err
 	}
 
-	numIn := call.Type().NumIn()
-	numParams := len(params)
-	hasOptional := hasOptionalInput(numIn, &call)
-	if !hasValidParamLength(numParams, numIn, hasOptional) {
-		jsonrpcError(c, -32602, "Invalid params", "Invalid number of params", &id)
-		return id, nil, false
+	m, ok := r.methods[call.Method]
+	if !ok {
+		return nil, errors.NewRPCError(-32601, "Method not found", "Method not found")
 	}
-	if isOptionalParamUndefined(numParams, numIn, hasOptional) {
-		params = append(params, map[string]any{})
-		numParams++
+	if opts.BeforeCall != nil {
+		if err := opts.BeforeCall(c, call); err != nil {
+			return nil, err
+		}
 	}
+	return m.call(c.Request.Context(), call.Params)
+}
 
-	args := make([]reflect.Value, numParams)
-	for i, arg := range params {
-		switch call.Type().In(i).Kind() {
-		case reflect.Float32:
-			val, ok := arg.(float32)
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Float64:
-			val, ok := arg.(float64)
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Int:
-			val, ok := arg.(int)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = int(fval)
-				}
-			}
-
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Int8:
-			val, ok := arg.(int8)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = int8(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Int16:
-			val, ok := arg.(int16)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = int16(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Int32:
-			val, ok := arg.(int32)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = int32(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Int64:
-			val, ok := arg.(int64)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = int64(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Interface:
-			args[i] = reflect.ValueOf(arg)
-
-		case reflect.Map:
-			val, ok := arg.(map[string]any)
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Slice:
-			val, ok := arg.([]interface{})
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.String:
-			val, ok := arg.(string)
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Uint:
-			val, ok := arg.(uint)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = uint(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Uint8:
-			val, ok := arg.(uint8)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = uint8(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Uint16:
-			val, ok := arg.(uint16)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = uint16(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Uint32:
-			val, ok := arg.(uint32)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = uint32(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		case reflect.Uint64:
-			val, ok := arg.(uint64)
-			if !ok {
-				var fval float64
-				fval, ok = arg.(float64)
-				if ok {
-					val = uint64(fval)
-				}
-			}
-			if !ok {
-				jsonrpcError(
-					c,
-					-32602,
-					"Invalid params",
-					formatConversionErrMsg(i, &call),
-					&id,
-				)
-				return id, nil, false
-			}
-			args[i] = reflect.ValueOf(val)
-
-		default:
-			if !ok {
-				jsonrpcError(c, -32603, "Internal error", "Invalid method definition", &id)
-				return id, nil, false
-			}
-		}
+// handleCall runs a single JSON-RPC request and returns a record of the call along with its response object.
+// Errors returned by a method that are not an RPCError are converted to one.
+func handleCall(c *gin.Context, r *Registry, opts *Opts, raw json.RawMessage) (*Call, gin.H) {
+	start := time.Now()
+	call := &Call{}
+	res, err := handleRequest(c, r, opts, raw, call)
+	call.Latency = time.Since(start)
+
+	if err != nil {
+		rpcErr := toRPCError(err)
+		call.Error = rpcErr
+		return call, jsonrpcErrorObject(rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), call.ID)
 	}
+	return call, gin.H{
+		"jsonrpc": "2.0",
+		"id":      call.ID,
+		"result":  res,
+	}
+}
 
-	c.Set("json-rpc-request", data)
-	value := call.Call(args)
-	if err, ok := value[len(value)-1].Interface().(error); ok && err != nil {
-		rpcErr, ok := err.(*errors.RPCError)
+// handleBatch runs each element of a batch request and returns the responses in the same order. Each element
+// carries its own result or error. Up to opts.MaxBatchConcurrency elements are run at the same time.
+func handleBatch(c *gin.Context, r *Registry, opts *Opts, batch []json.RawMessage) ([]*Call, []gin.H) {
+	maxConcurrency := opts.MaxBatchConcurrency
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
 
-		if ok {
-			jsonrpcError(c, rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), &id)
-		} else {
-			jsonrpcError(c, -32601, err.Error(), err.Error(), &id)
-		}
-		return id, nil, false
-	} else if len(value) > 0 {
-		return id, value[0].Interface(), true
-	} else {
-		return id, nil, true
+	calls := make([]*Call, len(batch))
+	results := make([]gin.H, len(batch))
+	sem := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for i, raw := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, raw json.RawMessage) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			calls[i], results[i] = handleCall(c, r, opts, raw)
+		}(i, raw)
 	}
+	wg.Wait()
+
+	return calls, results
 }
 
 // Controller returns a custom Gin middleware that handles incoming JSON-RPC requests via HTTP. It maps the
-// RPC method name to struct methods on the given api. For example, if the RPC request has the method field
-// set to "namespace_methodName" then the controller will make a call to api.Namespace_methodName with the
-// params spread as arguments.
-//
-// If request is valid it will also set the data on the Gin context with the key "json-rpc-request".
+// RPC method name to a Method in the given registry and decodes the params into the Method's typed inputs.
 //
-// NOTE: For batched requests in the current version, "json-rpc-request" on the Gin context contains only the
-// last request in the array.
-func Controller(api interface{}) gin.HandlerFunc {
+// Batched requests return an array with a result or error for each element in the order they were received.
+// A record of every call is set on the Gin context and can be retrieved with GetCalls.
+func Controller(r *Registry, opts *Opts) gin.HandlerFunc {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
 	return func(c *gin.Context) {
 		if c.Request.Method != "POST" {
 			jsonrpcError(c, -32700, "Parse error", "POST method excepted", nil)
@@ -454,36 +190,26 @@ func Controller(api interface{}) gin.HandlerFunc {
 			return
 		}
 
-		data := make(map[string]any)
-		err = json.Unmarshal(body, &data)
-		if err != nil {
-			var batch []map[string]any
-			err = json.Unmarshal(body, &batch)
-			if err != nil {
-				jsonrpcError(c, -32700, "Parse error", "Error parsing json request", nil)
+		if !json.Valid(body) {
+			jsonrpcError(c, -32700, "Parse error", "Error parsing json request", nil)
+			return
+		}
+
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+				jsonrpcError(c, -32600, "Invalid Request", "Empty batch", nil)
 				return
 			}
 
-			var result []gin.H
-			for _, data := range batch {
-				id, res, success := handleRequest(api, c, data)
-				if !success {
-					return
-				}
-
-				result = append(result, gin.H{
-					"jsonrpc": "2.0",
-					"id":      id,
-					"result":  res,
-				})
-			}
-			c.JSON(http.StatusOK, result)
-		} else if id, res, success := handleRequest(api, c, data); success {
-			c.JSON(http.StatusOK, gin.H{
-				"jsonrpc": "2.0",
-				"id":      id,
-				"result":  res,
-			})
+			calls, results := handleBatch(c, r, opts, batch)
+			c.Set(callsKey, calls)
+			c.Set(batchKey, true)
+			c.JSON(http.StatusOK, results)
+		} else {
+			call, res := handleCall(c, r, opts, body)
+			c.Set(callsKey, []*Call{call})
+			c.JSON(http.StatusOK, res)
 		}
 	}
 }
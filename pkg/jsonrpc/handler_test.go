@@ -0,0 +1,198 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+type mockApi struct {
+	running    atomic.Int32
+	maxRunning atomic.Int32
+}
+
+func (m *mockApi) Test_echo(s string) (string, error) {
+	return s, nil
+}
+
+func (m *mockApi) Test_fail() (any, error) {
+	return nil, errors.NewRPCError(errors.INVALID_FIELDS, "bad field", "data")
+}
+
+func (m *mockApi) Test_null() (any, error) {
+	return nil, nil
+}
+
+func (m *mockApi) Test_sleep() (bool, error) {
+	n := m.running.Add(1)
+	defer m.running.Add(-1)
+	for {
+		max := m.maxRunning.Load()
+		if n <= max || m.maxRunning.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return true, nil
+}
+
+func (m *mockApi) registry() *Registry {
+	r := NewRegistry(Info{Title: "test", Version: "1.0.0"})
+	r.Register(
+		NewMethod("test_echo", m.Test_echo, Param{Name: "s"}),
+		NewMethod("test_fail", m.Test_fail),
+		NewMethod("test_null", m.Test_null),
+		NewMethod("test_sleep", m.Test_sleep),
+	)
+	return r
+}
+
+func serve(t *testing.T, api *mockApi, opts *Opts, body string) (*gin.Context, []byte) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	Controller(api.registry(), opts)(c)
+	return c, w.Body.Bytes()
+}
+
+func request(id int, method string, params ...string) string {
+	p, _ := json.Marshal(params)
+	if params == nil {
+		p = []byte("[]")
+	}
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s","params":%s}`, id, method, p)
+}
+
+// TestControllerBatchPerItemErrors sends a batch where some elements fail. Expect an array with a result or
+// error for every element in order.
+func TestControllerBatchPerItemErrors(t *testing.T) {
+	body := "[" + strings.Join([]string{
+		request(1, "test_echo", "hello"),
+		request(2, "test_fail"),
+		`"not an object"`,
+		request(4, "test_unknown"),
+		request(5, "test_null"),
+	}, ",") + "]"
+	c, out := serve(t, &mockApi{}, nil, body)
+
+	var res []map[string]any
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("response is not a batch: %s", out)
+	}
+	if len(res) != 5 {
+		t.Fatalf("got %d responses, want 5", len(res))
+	}
+
+	if res[0]["result"] != "hello" || res[0]["id"] != float64(1) {
+		t.Fatalf("element 0: got %v", res[0])
+	}
+	if e, ok := res[1]["error"].(map[string]any); !ok || e["code"] != float64(errors.INVALID_FIELDS) {
+		t.Fatalf("element 1: got %v", res[1])
+	}
+	if e, ok := res[2]["error"].(map[string]any); !ok || e["code"] != float64(-32600) || res[2]["id"] != nil {
+		t.Fatalf("element 2: got %v", res[2])
+	}
+	if e, ok := res[3]["error"].(map[string]any); !ok || e["code"] != float64(-32601) {
+		t.Fatalf("element 3: got %v", res[3])
+	}
+	if r, ok := res[4]["result"]; !ok || r != nil {
+		t.Fatalf("element 4: got %v, want null result", res[4])
+	}
+
+	calls := GetCalls(c)
+	if !IsBatch(c) || len(calls) != 5 {
+		t.Fatalf("got %d calls, want 5 in a batch", len(calls))
+	} else if calls[1].Method != "test_fail" || calls[1].Error == nil || calls[0].Error != nil {
+		t.Fatal("got incorrect call records")
+	}
+}
+
+// TestControllerBatchConcurrency sends a batch with a concurrency cap. Expect elements to run in parallel
+// without exceeding the cap.
+func TestControllerBatchConcurrency(t *testing.T) {
+	reqs := []string{}
+	for i := 0; i < 8; i++ {
+		reqs = append(reqs, request(i, "test_sleep"))
+	}
+	api := &mockApi{}
+	_, out := serve(t, api, &Opts{MaxBatchConcurrency: 3}, "["+strings.Join(reqs, ",")+"]")
+
+	var res []map[string]any
+	if err := json.Unmarshal(out, &res); err != nil || len(res) != 8 {
+		t.Fatalf("got invalid batch response: %s", out)
+	}
+	for i, r := range res {
+		if r["id"] != float64(i) {
+			t.Fatalf("element %d: got id %v, want responses in request order", i, r["id"])
+		}
+	}
+	if max := api.maxRunning.Load(); max < 2 || max > 3 {
+		t.Fatalf("got max concurrency %d, want between 2 and 3", max)
+	}
+}
+
+// TestControllerSingleRequest sends a single failing request. Expect a single error object and a call record
+// that is not flagged as a batch.
+func TestControllerSingleRequest(t *testing.T) {
+	c, out := serve(t, &mockApi{}, nil, request(7, "test_fail"))
+
+	var res map[string]any
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("response is not an object: %s", out)
+	}
+	if e, ok := res["error"].(map[string]any); !ok || e["message"] != "bad field" || res["id"] != float64(7) {
+		t.Fatalf("got %v", res)
+	}
+	if IsBatch(c) || len(GetCalls(c)) != 1 {
+		t.Fatal("got incorrect call records")
+	}
+}
+
+// TestControllerEmptyBatch sends an empty array. Expect a single invalid request error.
+func TestControllerEmptyBatch(t *testing.T) {
+	_, out := serve(t, &mockApi{}, nil, "[]")
+
+	var res map[string]any
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatalf("response is not an object: %s", out)
+	}
+	if e, ok := res["error"].(map[string]any); !ok || e["code"] != float64(-32600) {
+		t.Fatalf("got %v", res)
+	}
+}
+
+// TestControllerBeforeCall sends a batch with a BeforeCall hook that rejects one method. Expect only that
+// element to return the hook's error.
+func TestControllerBeforeCall(t *testing.T) {
+	opts := &Opts{
+		BeforeCall: func(c *gin.Context, call *Call) error {
+			if call.Method == "test_null" {
+				return errors.NewRPCError(errors.LIMIT_EXCEEDED, "rate limit exceeded", nil)
+			}
+			return nil
+		},
+	}
+	body := "[" + request(1, "test_echo", "hello") + "," + request(2, "test_null") + "]"
+	_, out := serve(t, &mockApi{}, opts, body)
+
+	var res []map[string]any
+	if err := json.Unmarshal(out, &res); err != nil || len(res) != 2 {
+		t.Fatalf("got invalid batch response: %s", out)
+	}
+	if res[0]["result"] != "hello" {
+		t.Fatalf("element 0: got %v", res[0])
+	}
+	if e, ok := res[1]["error"].(map[string]any); !ok || e["code"] != float64(errors.LIMIT_EXCEEDED) {
+		t.Fatalf("element 1: got %v", res[1])
+	}
+}
@@ -0,0 +1,169 @@
+package jsonrpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+const (
+	// DiscoverMethod is the method name that serves the OpenRPC document of a Registry.
+	DiscoverMethod = "rpc.discover"
+
+	openRPCVersion = "1.2.6"
+)
+
+var (
+	describerType     = reflect.TypeOf((*Describer)(nil)).Elem()
+	bigIntType        = reflect.TypeOf(big.Int{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Info is the metadata of the API described by an OpenRPC document.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Schema is a JSON Schema object.
+type Schema map[string]any
+
+// Describer is implemented by param and result types with a custom JSON encoding to describe the encoding in
+// rpc.discover. JSONSchema is called on the zero value of the type.
+type Describer interface {
+	JSONSchema() map[string]any
+}
+
+// ContentDescriptor describes a param or result of a method in an OpenRPC document.
+type ContentDescriptor struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+	Schema   Schema `json:"schema"`
+}
+
+// MethodDescriptor describes a method in an OpenRPC document.
+type MethodDescriptor struct {
+	Name   string               `json:"name"`
+	Params []*ContentDescriptor `json:"params"`
+	Result *ContentDescriptor   `json:"result"`
+}
+
+// OpenRPCDocument is the response of rpc.discover. See https://spec.open-rpc.org.
+type OpenRPCDocument struct {
+	OpenRPC string              `json:"openrpc"`
+	Info    Info                `json:"info"`
+	Methods []*MethodDescriptor `json:"methods"`
+}
+
+func newOpenRPCDocument(info Info, methods []*Method) *OpenRPCDocument {
+	doc := &OpenRPCDocument{OpenRPC: openRPCVersion, Info: info, Methods: []*MethodDescriptor{}}
+	for _, m := range methods {
+		md := &MethodDescriptor{
+			Name:   m.Name,
+			Params: []*ContentDescriptor{},
+			Result: &ContentDescriptor{Name: "result", Schema: schemaOf(m.resultType(), nil)},
+		}
+		for i, p := range m.Params {
+			md.Params = append(md.Params, &ContentDescriptor{
+				Name:     p.Name,
+				Required: !p.Optional,
+				Schema:   schemaOf(m.paramType(i), nil),
+			})
+		}
+		doc.Methods = append(doc.Methods, md)
+	}
+	return doc
+}
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// schemaOf returns the JSON Schema for values of type t when encoded with encoding/json. Types with a custom
+// JSON encoding are described by their Describer implementation if any. Otherwise they and types that refer to
+// themselves are described with an empty schema.
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	if seen == nil {
+		seen = make(map[reflect.Type]bool)
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case implements(t, describerType):
+		return Schema(reflect.New(t).Interface().(Describer).JSONSchema())
+	case t == bigIntType:
+		return Schema{"type": "integer"}
+	case implements(t, textMarshalerType):
+		return Schema{"type": "string"}
+	case implements(t, jsonMarshalerType):
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string"}
+		}
+		return Schema{"type": "array", "items": schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return Schema{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := Schema{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, skip := jsonFieldName(f)
+			if skip {
+				continue
+			}
+
+			fs := schemaOf(f.Type, seen)
+			if embedded, ok := fs["properties"].(Schema); ok && f.Anonymous && f.Tag.Get("json") == "" {
+				for k, v := range embedded {
+					props[k] = v
+				}
+				continue
+			}
+			props[name] = fs
+		}
+		return Schema{"type": "object", "properties": props}
+	default:
+		return Schema{}
+	}
+}
+
+// jsonFieldName returns the name of a struct field when encoded with encoding/json and whether it is
+// skipped.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", true
+	}
+
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, false
+	}
+	return f.Name, false
+}
@@ -1,20 +1,43 @@
 package jsonrpc
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+func callAttributes(call *Call) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("jsonrpc_method", call.Method),
+		attribute.String("jsonrpc_id", fmt.Sprint(call.ID)),
+		attribute.Int64("jsonrpc_latency_ms", call.Latency.Milliseconds()),
+	}
+	if call.Error != nil {
+		attrs = append(attrs, attribute.Int("jsonrpc_error_code", call.Error.Code()))
+	}
+	return attrs
+}
+
 // WithOTELTracerAttributes adds custom opentelemetry attributes relating to the JSON-RPC method call for the
-// current span.
+// current span. For batch requests, the batch size is added to the span along with an event for each call.
 func WithOTELTracerAttributes() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		req, ok := ctx.Get("json-rpc-request")
-		if ok {
-			json := req.(map[string]any)
-			span := trace.SpanFromContext(ctx.Request.Context())
-			span.SetAttributes(attribute.String("jsonrpc_method", json["method"].(string)))
+		calls := GetCalls(ctx)
+		if calls == nil {
+			return
+		}
+
+		span := trace.SpanFromContext(ctx.Request.Context())
+		if !IsBatch(ctx) {
+			span.SetAttributes(callAttributes(calls[0])...)
+			return
+		}
+
+		span.SetAttributes(attribute.Int("jsonrpc_batch_size", len(calls)))
+		for _, call := range calls {
+			span.AddEvent("jsonrpc_call", trace.WithAttributes(callAttributes(call)...))
 		}
 	}
 }
@@ -0,0 +1,156 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// Param declares a positional parameter of a Method. The Go type of the parameter is taken from the
+// corresponding input of the Method's handler.
+type Param struct {
+	Name     string
+	Optional bool
+}
+
+// Method is a JSON-RPC method with typed params and result.
+type Method struct {
+	Name       string
+	Params     []Param
+	fn         reflect.Value
+	hasContext bool
+}
+
+// NewMethod returns a Method that calls fn with its params decoded through encoding/json. fn must have one
+// input for each Param and return a result and an error. If the first input of fn is a context.Context, it is
+// passed the context of the HTTP request and is not counted as a Param. Optional params must come after all
+// required params and are set to the zero value of their type if left out of the request. NewMethod panics if
+// fn does not match the given params.
+func NewMethod(name string, fn any, params ...Param) *Method {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func {
+		panic(fmt.Sprintf("jsonrpc: %s handler is not a func", name))
+	}
+	hasContext := t.NumIn() > 0 && t.In(0) == contextType
+	numIn := t.NumIn()
+	if hasContext {
+		numIn--
+	}
+	if numIn != len(params) {
+		panic(fmt.Sprintf("jsonrpc: %s handler has %d inputs, expected %d params", name, numIn, len(params)))
+	}
+	if t.NumOut() != 2 || !t.Out(1).Implements(errorType) || !isNillable(t.Out(1)) {
+		panic(fmt.Sprintf("jsonrpc: %s handler must return a result and an error", name))
+	}
+	for i := 1; i < len(params); i++ {
+		if params[i-1].Optional && !params[i].Optional {
+			panic(fmt.Sprintf("jsonrpc: %s required param %s follows an optional param", name, params[i].Name))
+		}
+	}
+
+	return &Method{Name: name, Params: params, fn: v, hasContext: hasContext}
+}
+
+func isNillable(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface || t.Kind() == reflect.Pointer
+}
+
+func (m *Method) paramType(i int) reflect.Type {
+	if m.hasContext {
+		return m.fn.Type().In(i + 1)
+	}
+	return m.fn.Type().In(i)
+}
+
+func (m *Method) resultType() reflect.Type {
+	return m.fn.Type().Out(0)
+}
+
+func (m *Method) numRequired() int {
+	n := 0
+	for _, p := range m.Params {
+		if !p.Optional {
+			n++
+		}
+	}
+	return n
+}
+
+// call decodes the raw params into the handler's input types and calls it.
+func (m *Method) call(ctx context.Context, params []json.RawMessage) (any, error) {
+	if len(params) < m.numRequired() || len(params) > len(m.Params) {
+		return nil, errors.NewRPCError(-32602, "Invalid params", "Invalid number of params")
+	}
+
+	args := make([]reflect.Value, len(m.Params))
+	for i := range m.Params {
+		arg := reflect.New(m.paramType(i))
+		if i < len(params) {
+			if err := json.Unmarshal(params[i], arg.Interface()); err != nil {
+				return nil, errors.NewRPCError(
+					-32602,
+					"Invalid params",
+					fmt.Sprintf("Param [%d] can't be converted to %s: %s", i, m.Params[i].Name, err),
+				)
+			}
+		}
+		args[i] = arg.Elem()
+	}
+
+	if m.hasContext {
+		args = append([]reflect.Value{reflect.ValueOf(ctx)}, args...)
+	}
+	out := m.fn.Call(args)
+	if !out[1].IsNil() {
+		return nil, out[1].Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
+
+// Registry holds the set of JSON-RPC methods served by the Controller. Every Registry serves an OpenRPC
+// document describing its methods at rpc.discover.
+type Registry struct {
+	info    Info
+	methods map[string]*Method
+}
+
+// NewRegistry returns a Registry with only the rpc.discover method.
+func NewRegistry(info Info) *Registry {
+	r := &Registry{info: info, methods: make(map[string]*Method)}
+	r.Register(NewMethod(DiscoverMethod, r.discover))
+	return r
+}
+
+// Register adds methods to the registry. It panics if a method with the same name already exists.
+func (r *Registry) Register(methods ...*Method) {
+	for _, m := range methods {
+		if _, ok := r.methods[m.Name]; ok {
+			panic(fmt.Sprintf("jsonrpc: method %s already registered", m.Name))
+		}
+		r.methods[m.Name] = m
+	}
+}
+
+// Methods returns all registered methods sorted by name.
+func (r *Registry) Methods() []*Method {
+	methods := []*Method{}
+	for _, m := range r.methods {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+func (r *Registry) discover() (*OpenRPCDocument, error) {
+	return newOpenRPCDocument(r.info, r.Methods()), nil
+}
@@ -0,0 +1,189 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+)
+
+type mockTransfer struct {
+	To     common.Address `json:"to"`
+	Amount *big.Int       `json:"amount"`
+	Memo   string         `json:"memo,omitempty"`
+	secret string
+}
+
+func sumTransfers(transfers []mockTransfer, min *big.Int, opts map[string]any) (*big.Int, error) {
+	sum := big.NewInt(0)
+	for _, t := range transfers {
+		if min == nil || t.Amount.Cmp(min) >= 0 {
+			sum.Add(sum, t.Amount)
+		}
+	}
+	if opts["double"] == true {
+		sum.Mul(sum, big.NewInt(2))
+	}
+	return sum, nil
+}
+
+func newTransferMethod() *Method {
+	return NewMethod(
+		"test_sumTransfers",
+		sumTransfers,
+		Param{Name: "transfers"},
+		Param{Name: "min"},
+		Param{Name: "options", Optional: true},
+	)
+}
+
+func rawParams(t *testing.T, params ...string) []json.RawMessage {
+	t.Helper()
+	raw := []json.RawMessage{}
+	for _, p := range params {
+		raw = append(raw, json.RawMessage(p))
+	}
+	return raw
+}
+
+// TestMethodCallTypedParams calls a Method with struct, slice, and *big.Int params. Expect values larger than
+// a float64 to be decoded without loss of precision.
+func TestMethodCallTypedParams(t *testing.T) {
+	m := newTransferMethod()
+	transfers := `[{"to":"0x0000000000000000000000000000000000000001","amount":100000000000000000001},
+		{"to":"0x0000000000000000000000000000000000000002","amount":5}]`
+
+	res, err := m.call(context.Background(), rawParams(t, transfers, "10"))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	want, _ := big.NewInt(0).SetString("100000000000000000001", 10)
+	if res.(*big.Int).Cmp(want) != 0 {
+		t.Fatalf("got %s, want %s", res, want)
+	}
+
+	res, err = m.call(context.Background(), rawParams(t, transfers, "null", `{"double":true}`))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	want.Add(want, big.NewInt(5)).Mul(want, big.NewInt(2))
+	if res.(*big.Int).Cmp(want) != 0 {
+		t.Fatalf("got %s, want %s", res, want)
+	}
+}
+
+// TestMethodCallInvalidParams calls a Method with the wrong number or type of params. Expect an invalid
+// params error.
+func TestMethodCallInvalidParams(t *testing.T) {
+	m := newTransferMethod()
+	cases := map[string][]json.RawMessage{
+		"too_few":    rawParams(t, "[]"),
+		"too_many":   rawParams(t, "[]", "1", "{}", "{}"),
+		"wrong_type": rawParams(t, `{"to":"0x1"}`, "1"),
+		"bad_big":    rawParams(t, "[]", `"abc"`),
+	}
+	for name, params := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.call(context.Background(), params)
+			if rpcErr, ok := err.(*errors.RPCError); !ok || rpcErr.Code() != -32602 {
+				t.Fatalf("got err %v, want invalid params", err)
+			}
+		})
+	}
+}
+
+// TestNewMethodInvalidDefinition calls NewMethod with params that do not match the handler. Expect a panic.
+func TestNewMethodInvalidDefinition(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("got no panic, want panic")
+		}
+	}()
+	NewMethod("test_sumTransfers", sumTransfers, Param{Name: "transfers"})
+}
+
+// TestRegistryDiscover calls rpc.discover on a Registry. Expect an OpenRPC document describing the params and
+// result of each method.
+func TestRegistryDiscover(t *testing.T) {
+	r := NewRegistry(Info{Title: "test", Version: "1.0.0"})
+	r.Register(newTransferMethod())
+
+	res, err := r.methods[DiscoverMethod].call(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	doc := res.(*OpenRPCDocument)
+	if doc.OpenRPC != openRPCVersion || doc.Info.Title != "test" || len(doc.Methods) != 2 {
+		t.Fatalf("got invalid document %+v", doc)
+	}
+
+	md := doc.Methods[1]
+	if md.Name != "test_sumTransfers" || len(md.Params) != 3 {
+		t.Fatalf("got method %s with %d params", md.Name, len(md.Params))
+	}
+	if !md.Params[0].Required || md.Params[2].Required {
+		t.Fatal("got incorrect required flags")
+	}
+
+	items := md.Params[0].Schema["items"].(Schema)
+	props := items["properties"].(Schema)
+	if len(props) != 3 {
+		t.Fatalf("got %d properties, want 3", len(props))
+	}
+	if props["to"].(Schema)["type"] != "string" || props["amount"].(Schema)["type"] != "integer" {
+		t.Fatalf("got incorrect property schemas %v", props)
+	}
+	if md.Result.Schema["type"] != "integer" {
+		t.Fatalf("got result schema %v, want integer", md.Result.Schema)
+	}
+}
+
+// mockHex is encoded as a hex string and describes itself in rpc.discover.
+type mockHex struct {
+	N int
+}
+
+func (h mockHex) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("0x%x", h.N))
+}
+
+func (h mockHex) JSONSchema() map[string]any {
+	return map[string]any{"type": "string", "pattern": "^0x[0-9a-f]*$"}
+}
+
+// TestRegistryDiscoverDescriber calls rpc.discover on a Registry with a method that takes a param with a
+// custom JSON encoding. Expect the param to be described by its JSONSchema method.
+func TestRegistryDiscoverDescriber(t *testing.T) {
+	r := NewRegistry(Info{Title: "test", Version: "1.0.0"})
+	r.Register(NewMethod(
+		"test_hex",
+		func(h *mockHex) (int, error) { return h.N, nil },
+		Param{Name: "hex"},
+	))
+
+	res, err := r.methods[DiscoverMethod].call(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	schema := res.(*OpenRPCDocument).Methods[1].Params[0].Schema
+	if schema["type"] != "string" || schema["pattern"] != "^0x[0-9a-f]*$" {
+		t.Fatalf("got schema %v, want hex string", schema)
+	}
+}
+
+// TestMethodCallTypedNilError calls a Method whose handler returns a nil *errors.RPCError. Expect no error.
+func TestMethodCallTypedNilError(t *testing.T) {
+	m := NewMethod("test_ok", func() (string, *errors.RPCError) { return "ok", nil })
+
+	res, err := m.call(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if res != "ok" {
+		t.Fatalf("got %v, want ok", res)
+	}
+}
@@ -0,0 +1,62 @@
+// Package linea implements helpers for Linea's gas pricing model where the L1 data cost of a transaction is
+// charged through its gas price.
+package linea
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	extraDataVersion = 1
+	extraDataLength  = 13
+)
+
+var (
+	kwei = big.NewInt(1000)
+
+	ErrExtraDataTooShort = errors.New("linea: block extraData too short")
+)
+
+// ExtraData contains the pricing variables published by the Linea sequencer in the extraData field of each
+// block header. All values are in wei.
+type ExtraData struct {
+	Version uint8
+
+	// FixedCost is the cost per gas unit of executing a transaction on L2.
+	FixedCost *big.Int
+
+	// VariableCost is the cost of posting a byte of compressed transaction data to L1.
+	VariableCost *big.Int
+
+	// EthGasPrice is the gas price returned by eth_gasPrice on the node.
+	EthGasPrice *big.Int
+}
+
+func toWei(b []byte) *big.Int {
+	return big.NewInt(0).Mul(big.NewInt(0).SetBytes(b), kwei)
+}
+
+// ParseExtraData decodes the pricing variables from a block header's extraData. The expected layout is a 1
+// byte version followed by the fixed cost, variable cost, and eth gas price as 4 byte values in kwei.
+func ParseExtraData(extra []byte) (*ExtraData, error) {
+	if len(extra) < extraDataLength {
+		return nil, ErrExtraDataTooShort
+	}
+	if extra[0] != extraDataVersion {
+		return nil, fmt.Errorf("linea: unsupported extraData version %d", extra[0])
+	}
+
+	return &ExtraData{
+		Version:      extra[0],
+		FixedCost:    toWei(extra[1:5]),
+		VariableCost: toWei(extra[5:9]),
+		EthGasPrice:  toWei(extra[9:13]),
+	}, nil
+}
+
+// DataFee returns the L1 data fee in wei for the given number of compressed bytes.
+func (e *ExtraData) DataFee(size int) *big.Int {
+	return big.NewInt(0).Mul(e.VariableCost, big.NewInt(int64(size)))
+}
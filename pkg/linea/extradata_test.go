@@ -0,0 +1,33 @@
+package linea
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestParseExtraData verifies that pricing variables are decoded from kwei to wei.
+func TestParseExtraData(t *testing.T) {
+	extra := common.FromHex("0x01000000640000006e000003e800000000000000000000000000000000000000")
+	ed, err := ParseExtraData(extra)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if ed.FixedCost.Int64() != 100_000 || ed.VariableCost.Int64() != 110_000 || ed.EthGasPrice.Int64() != 1_000_000 {
+		t.Fatalf("got %s, %s, %s, want 100000, 110000, 1000000", ed.FixedCost, ed.VariableCost, ed.EthGasPrice)
+	}
+	if fee := ed.DataFee(10); fee.Int64() != 1_100_000 {
+		t.Fatalf("got data fee %s, want 1100000", fee)
+	}
+}
+
+// TestParseExtraDataInvalid verifies that short or unknown extraData is rejected.
+func TestParseExtraDataInvalid(t *testing.T) {
+	if _, err := ParseExtraData([]byte{1, 2, 3}); err != ErrExtraDataTooShort {
+		t.Fatalf("got %v, want %v", err, ErrExtraDataTooShort)
+	}
+	if _, err := ParseExtraData(make([]byte, 32)); err == nil {
+		t.Fatal("got nil, want unsupported version error")
+	}
+}
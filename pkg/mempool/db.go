@@ -39,7 +39,7 @@ func getUserOpFromDBValue(value []byte) (*userop.UserOperation, error) {
 	return op, nil
 }
 
-func loadFromDisk(db *badger.DB, q *userOpQueues) error {
+func loadFromDisk(db *badger.DB, q *userOpQueues, u *usage) error {
 	return db.View(func(txn *badger.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.PrefetchSize = 10
@@ -58,6 +58,7 @@ func loadFromDisk(db *badger.DB, q *userOpQueues) error {
 				}
 
 				q.AddOp(ep, op)
+				u.add(string(item.Key()), int64(len(v)))
 				return nil
 			})
 
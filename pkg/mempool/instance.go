@@ -3,57 +3,214 @@
 package mempool
 
 import (
+	"math/big"
+	"sync"
+
 	badger "github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // Mempool provides read and write access to a pool of pending UserOperations which have passed all Client
 // checks.
 type Mempool struct {
-	db    *badger.DB
-	queue *userOpQueues
+	db         *badger.DB
+	queue      *userOpQueues
+	limits     *limits
+	usage      *usage
+	getBaseFee GetBaseFeeFunc
+	onEvict    OnEvictFunc
+	nonces     *nonceCache
+	mu         sync.RWMutex
 }
 
 // New creates an instance of a mempool that uses an embedded DB to persist and load UserOperations from disk
 // incase of a reset.
 func New(db *badger.DB) (*Mempool, error) {
 	queue := newUserOpQueue()
-	err := loadFromDisk(db, queue)
+	usage := newUsage()
+	err := loadFromDisk(db, queue, usage)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Mempool{db, queue}, nil
+	return &Mempool{
+		db:         db,
+		queue:      queue,
+		limits:     &limits{},
+		usage:      usage,
+		getBaseFee: getBaseFeeNoop,
+		onEvict:    onEvictNoop,
+		nonces:     newNonceCache(),
+	}, nil
+}
+
+// SetMaxOps sets the max number of UserOperations allowed in the mempool across all EntryPoints. A value of
+// 0 means there is no limit.
+func (m *Mempool) SetMaxOps(max int) {
+	m.limits.maxOps = max
+}
+
+// SetMaxOpsPerSender sets the max number of UserOperations allowed in the mempool for a single sender. A
+// value of 0 means there is no limit.
+func (m *Mempool) SetMaxOpsPerSender(max int) {
+	m.limits.maxOpsPerSender = max
+}
+
+// SetMaxBytes sets the max total size in bytes of the encoded UserOperations persisted by the mempool. A
+// value of 0 means there is no limit.
+func (m *Mempool) SetMaxBytes(max int64) {
+	m.limits.maxBytes = max
+}
+
+// SetGetBaseFeeFunc defines the function used to retrieve the basefee when comparing the effective tip of
+// UserOperations for eviction. If not set, the effective tip is calculated with a basefee of 0.
+func (m *Mempool) SetGetBaseFeeFunc(fn GetBaseFeeFunc) {
+	m.getBaseFee = fn
+}
+
+// SetOnEvictFunc defines a function that is called for each UserOperation evicted to make room for another.
+// This allows other modules to clean up data related to the op.
+func (m *Mempool) SetOnEvictFunc(fn OnEvictFunc) {
+	m.onEvict = fn
 }
 
 // GetOps returns all the UserOperations associated with an EntryPoint and Sender address.
 func (m *Mempool) GetOps(entryPoint common.Address, sender common.Address) ([]*userop.UserOperation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	ops := m.queue.GetOps(entryPoint, sender)
 	return ops, nil
 }
 
+// SetGetNonceFunc defines the function used to retrieve the current EntryPoint nonce of a sender. This is
+// used to determine which UserOperations are ready to be bundled and which are queued behind a nonce gap. If
+// not set, all UserOperations are considered ready.
+func (m *Mempool) SetGetNonceFunc(fn nonce.GetNonceFunc) {
+	m.nonces.getNonces = nonce.GetNoncesWithFunc(fn)
+}
+
+// SetGetNoncesFunc is the same as SetGetNonceFunc but fetches the nonces of all senders that are not cached in
+// a single call.
+func (m *Mempool) SetGetNoncesFunc(fn nonce.GetNoncesFunc) {
+	m.nonces.getNonces = fn
+}
+
 // AddOp adds a UserOperation to the mempool or replace an existing one with the same EntryPoint, Sender, and
-// Nonce values.
+// Nonce values. If the mempool is at capacity, UserOperations with the lowest effective tip are evicted to
+// make room. ErrMempoolFull is returned if the incoming UserOperation does not pay a higher effective tip
+// than the ops that would need to be evicted.
 func (m *Mempool) AddOp(entryPoint common.Address, op *userop.UserOperation) error {
 	data, err := op.MarshalJSON()
 	if err != nil {
 		return err
 	}
+	key := string(getUniqueKey(entryPoint, op.Sender, op.Nonce))
+
+	// The basefee is only needed to select ops for eviction. It is fetched before taking the lock so that a
+	// slow RPC call does not block the mempool. If the mempool fills up in between, tips are compared with a
+	// basefee of 0.
+	var bf *big.Int
+	m.mu.RLock()
+	full := m.isFull(key, int64(len(data)))
+	m.mu.RUnlock()
+	if full {
+		if bf, err = m.getBaseFee(); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	evict, err := m.getOpsToEvict(entryPoint, op, key, int64(len(data)), bf)
+	if err != nil {
+		return err
+	}
 
 	err = m.db.Update(func(txn *badger.Txn) error {
-		return txn.Set(getUniqueKey(entryPoint, op.Sender, op.Nonce), data)
+		for _, c := range evict {
+			if err := txn.Delete([]byte(c.key)); err != nil {
+				return err
+			}
+			if err := m.onEvict(txn, c.entryPoint, c.op); err != nil {
+				return err
+			}
+		}
+		return txn.Set([]byte(key), data)
 	})
 	if err != nil {
 		return err
 	}
 
+	for _, c := range evict {
+		m.queue.RemoveOps(c.entryPoint, c.op)
+		m.usage.remove(c.key)
+	}
 	m.queue.AddOp(entryPoint, op)
+	m.usage.add(key, int64(len(data)))
 	return nil
 }
 
+// getUsageDelta returns the change in ops and bytes if the op with the given key and size is added.
+func (m *Mempool) getUsageDelta(key string, size int64) (int, int64, bool) {
+	prev, ok := m.usage.sizes[key]
+	if ok {
+		return 0, size - prev, true
+	}
+	return 1, size, false
+}
+
+// isFull returns true if ops must be evicted for an op with the given key and size to fit within the mempool
+// limits.
+func (m *Mempool) isFull(key string, size int64) bool {
+	addOps, addBytes, _ := m.getUsageDelta(key, size)
+	return m.limits.exceeds(m.usage, addOps, addBytes)
+}
+
+// getOpsToEvict returns the UserOperations that must be removed for the incoming op to fit within the
+// mempool limits. The effective tip of each op is calculated with the given basefee.
+func (m *Mempool) getOpsToEvict(
+	entryPoint common.Address,
+	op *userop.UserOperation,
+	key string,
+	size int64,
+	bf *big.Int,
+) ([]*evictionCandidate, error) {
+	addOps, addBytes, replace := m.getUsageDelta(key, size)
+	if !replace && m.limits.maxOpsPerSender > 0 &&
+		len(m.queue.getSenderOps(m.queue.getEntryPointSet(entryPoint), op.Sender)) >= m.limits.maxOpsPerSender {
+		return nil, ErrSenderLimitReached
+	}
+	if !m.limits.exceeds(m.usage, addOps, addBytes) {
+		return nil, nil
+	}
+	tip := getEffectiveTip(op, bf)
+
+	evict := []*evictionCandidate{}
+	selected := make(map[string]bool)
+	for m.limits.exceeds(m.usage, addOps, addBytes) {
+		c := m.queue.findEvictionCandidate(op.Sender, selected, bf)
+		if c == nil || c.tip.Cmp(tip) >= 0 {
+			return nil, ErrMempoolFull
+		}
+
+		selected[c.key] = true
+		evict = append(evict, c)
+		addOps--
+		addBytes -= m.usage.sizes[c.key]
+	}
+
+	return evict, nil
+}
+
 // RemoveOps removes a list of UserOperations from the mempool by EntryPoint, Sender, and Nonce values.
 func (m *Mempool) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	err := m.db.Update(func(txn *badger.Txn) error {
 		for _, op := range ops {
 			err := txn.Delete(getUniqueKey(entryPoint, op.Sender, op.Nonce))
@@ -69,20 +226,73 @@ func (m *Mempool) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperat
 	}
 
 	m.queue.RemoveOps(entryPoint, ops...)
+	m.nonces.invalidate(entryPoint, ops...)
+	for _, op := range ops {
+		m.usage.remove(string(getUniqueKey(entryPoint, op.Sender, op.Nonce)))
+	}
 	return nil
 }
 
 // Dump will return a list of UserOperations from the mempool by EntryPoint in the order it arrived.
 func (m *Mempool) Dump(entryPoint common.Address) ([]*userop.UserOperation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	return m.queue.All(entryPoint), nil
 }
 
+// Best will return up to n UserOperations from the mempool by EntryPoint ordered by the highest effective
+// gas price at the given basefee. UserOperations from the same sender and nonce key are always returned in
+// ascending nonce order and ops that are queued behind a nonce gap are excluded. If basefee is nil, ops are
+// ordered by maxFeePerGas. A value of n <= 0 will return all ready ops.
+func (m *Mempool) Best(entryPoint common.Address, n int, baseFee *big.Int) ([]*userop.UserOperation, error) {
+	nv := newNonceView(m.nonces)
+	for {
+		m.mu.Lock()
+		batch, missing := m.queue.Best(entryPoint, n, baseFee, nv)
+		m.mu.Unlock()
+		if len(missing) == 0 {
+			return batch, nil
+		}
+
+		// Fetch unknown nonces without holding the lock and try again with the fetched values.
+		if err := nv.fetch(missing); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// IsReady returns true if the UserOperation forms a contiguous nonce sequence with the current EntryPoint
+// nonce of the sender and can be bundled. Otherwise it is queued behind a nonce gap.
+func (m *Mempool) IsReady(entryPoint common.Address, op *userop.UserOperation) (bool, error) {
+	nv := newNonceView(m.nonces)
+	for {
+		m.mu.RLock()
+		eps := m.queue.getEntryPointSet(entryPoint)
+		sq, missing := getSenderQueues(entryPoint, op.Sender, m.queue.getSenderOps(eps, op.Sender), nv)
+		m.mu.RUnlock()
+		if sq != nil {
+			return sq.isReady(op), nil
+		}
+
+		// Fetch unknown nonces without holding the lock and try again with the fetched values.
+		if err := nv.fetch(missing); err != nil {
+			return false, err
+		}
+	}
+}
+
 // Clear will clear the entire embedded db and reset it to a clean state.
 func (m *Mempool) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if err := m.db.DropAll(); err != nil {
 		return err
 	}
 	m.queue = newUserOpQueue()
+	m.usage = newUsage()
+	m.nonces.clear()
 
 	return nil
 }
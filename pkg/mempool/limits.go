@@ -0,0 +1,136 @@
+package mempool
+
+import (
+	"errors"
+	"math/big"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// ErrMempoolFull is returned when the mempool is at capacity and the incoming UserOperation does not pay
+	// a higher effective tip than the cheapest UserOperation that can be evicted.
+	ErrMempoolFull = errors.New("mempool: full and op does not outbid the lowest priced op")
+
+	// ErrSenderLimitReached is returned when the sender already has the max number of UserOperations allowed
+	// in the mempool.
+	ErrSenderLimitReached = errors.New("mempool: sender has reached the max number of pending ops")
+)
+
+// GetBaseFeeFunc is used to retrieve the current basefee when comparing the effective tip of UserOperations
+// for eviction. It is defined here to avoid an import cycle with the gasprice module.
+type GetBaseFeeFunc = func() (*big.Int, error)
+
+func getBaseFeeNoop() (*big.Int, error) {
+	return nil, nil
+}
+
+// OnEvictFunc is called for each UserOperation evicted to make room for another. It runs in the same DB
+// transaction that removes the op so that any related data can be deleted atomically.
+type OnEvictFunc = func(txn *badger.Txn, entryPoint common.Address, op *userop.UserOperation) error
+
+func onEvictNoop(txn *badger.Txn, entryPoint common.Address, op *userop.UserOperation) error {
+	return nil
+}
+
+// limits defines the capacity of the mempool. A value of 0 means there is no limit.
+type limits struct {
+	maxOps          int
+	maxOpsPerSender int
+	maxBytes        int64
+}
+
+// usage tracks the current number of UserOperations in the mempool and their encoded size on disk.
+type usage struct {
+	ops   int
+	bytes int64
+	sizes map[string]int64
+}
+
+func newUsage() *usage {
+	return &usage{sizes: make(map[string]int64)}
+}
+
+func (u *usage) add(key string, size int64) {
+	if prev, ok := u.sizes[key]; ok {
+		u.bytes -= prev
+	} else {
+		u.ops++
+	}
+	u.sizes[key] = size
+	u.bytes += size
+}
+
+func (u *usage) remove(key string) {
+	if prev, ok := u.sizes[key]; ok {
+		u.ops--
+		u.bytes -= prev
+		delete(u.sizes, key)
+	}
+}
+
+// evictionCandidate is a UserOperation that can be removed from the mempool to make room for another.
+type evictionCandidate struct {
+	entryPoint common.Address
+	op         *userop.UserOperation
+	key        string
+	tip        *big.Int
+}
+
+// getEffectiveTip returns the tip paid to the bundler per unit of gas given a basefee.
+func getEffectiveTip(op *userop.UserOperation, baseFee *big.Int) *big.Int {
+	bf := baseFee
+	if bf == nil {
+		bf = big.NewInt(0)
+	}
+	return big.NewInt(0).Sub(op.GetDynamicGasPrice(bf), bf)
+}
+
+// exceeds returns true if the mempool will be over capacity after adding the given number of ops and bytes.
+func (l *limits) exceeds(u *usage, ops int, bytes int64) bool {
+	return (l.maxOps > 0 && u.ops+ops > l.maxOps) || (l.maxBytes > 0 && u.bytes+bytes > l.maxBytes)
+}
+
+// findEvictionCandidate returns the lowest priced op that can be evicted without creating a nonce gap. Only
+// the highest nonce op for each sender is considered. Ops from the given sender and keys that have already
+// been selected are skipped.
+func (q *userOpQueues) findEvictionCandidate(
+	sender common.Address,
+	selected map[string]bool,
+	baseFee *big.Int,
+) *evictionCandidate {
+	var cheapest *evictionCandidate
+	q.setsByEntryPoint.Range(func(k, v any) bool {
+		ep := k.(common.Address)
+		eps := v.(*set)
+		tails := make(map[common.Address]bool)
+
+		for _, n := range eps.all.GetByRankRange(1, -1, false) {
+			op := n.Value.(*userop.UserOperation)
+			if op.Sender == sender || tails[op.Sender] {
+				continue
+			}
+
+			// Walk the sender's ops from highest to lowest nonce and pick the first one not yet selected.
+			ops := q.getSenderOps(eps, op.Sender)
+			for i := len(ops) - 1; i >= 0; i-- {
+				key := string(getUniqueKey(ep, ops[i].Sender, ops[i].Nonce))
+				if selected[key] {
+					continue
+				}
+
+				tip := getEffectiveTip(ops[i], baseFee)
+				if cheapest == nil || tip.Cmp(cheapest.tip) < 0 {
+					cheapest = &evictionCandidate{entryPoint: ep, op: ops[i], key: key, tip: tip}
+				}
+				break
+			}
+			tails[op.Sender] = true
+		}
+		return true
+	})
+
+	return cheapest
+}
@@ -0,0 +1,226 @@
+package mempool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func mockOpWithTip(sender common.Address, nonce int64, tip int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(nonce)
+	op.MaxPriorityFeePerGas = big.NewInt(tip)
+	op.MaxFeePerGas = big.NewInt(tip)
+	return op
+}
+
+// TestMaxOpsEvictsLowestTip verifies that a UserOperation with a higher effective tip evicts the lowest
+// priced UserOperation when the mempool is full.
+func TestMaxOpsEvictsLowestTip(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOps(2)
+	ep := testutils.ValidAddress1
+
+	cheap := mockOpWithTip(testutils.ValidAddress2, 0, 1)
+	mid := mockOpWithTip(testutils.ValidAddress3, 0, 2)
+	high := mockOpWithTip(testutils.ValidAddress4, 0, 3)
+	for _, op := range []*userop.UserOperation{cheap, mid, high} {
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	memOps, _ := mem.Dump(ep)
+	if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], mid) || !testutils.IsOpsEqual(memOps[1], high) {
+		t.Fatal("incorrect op evicted")
+	}
+}
+
+// TestMaxOpsRejectsUnderpriced verifies that ErrMempoolFull is returned when the mempool is full and the
+// incoming UserOperation does not outbid the lowest priced UserOperation.
+func TestMaxOpsRejectsUnderpriced(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOps(1)
+	ep := testutils.ValidAddress1
+
+	if err := mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress2, 0, 2)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress3, 0, 2)); !errors.Is(err, ErrMempoolFull) {
+		t.Fatalf("got %v, want ErrMempoolFull", err)
+	}
+}
+
+// TestMaxOpsEvictsHighestNonce verifies that eviction only removes the highest nonce UserOperation of a
+// sender so that no nonce gaps are created.
+func TestMaxOpsEvictsHighestNonce(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOps(2)
+	ep := testutils.ValidAddress1
+
+	first := mockOpWithTip(testutils.ValidAddress2, 0, 1)
+	second := mockOpWithTip(testutils.ValidAddress2, 1, 5)
+	incoming := mockOpWithTip(testutils.ValidAddress3, 0, 6)
+	for _, op := range []*userop.UserOperation{first, second, incoming} {
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	memOps, _ := mem.GetOps(ep, testutils.ValidAddress2)
+	if len(memOps) != 1 {
+		t.Fatalf("got length %d, want 1", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], first) {
+		t.Fatal("incorrect op evicted")
+	}
+}
+
+// TestMaxOpsAllowsReplacement verifies that replacing an existing UserOperation is not restricted by the max
+// number of ops.
+func TestMaxOpsAllowsReplacement(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOps(1)
+	ep := testutils.ValidAddress1
+
+	if err := mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress2, 0, 1)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress2, 0, 2)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestMaxOpsPerSender verifies that ErrSenderLimitReached is returned when a sender has too many pending
+// UserOperations.
+func TestMaxOpsPerSender(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOpsPerSender(1)
+	ep := testutils.ValidAddress1
+
+	if err := mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress2, 0, 1)); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress2, 1, 1)); !errors.Is(err, ErrSenderLimitReached) {
+		t.Fatalf("got %v, want ErrSenderLimitReached", err)
+	}
+}
+
+// TestMaxOpsPerSenderIgnoresEntityOps verifies that ops where the sender is only the factory are not counted
+// towards its per sender limit. Expect the sender's own op to be added.
+func TestMaxOpsPerSenderIgnoresEntityOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOpsPerSender(1)
+	ep := testutils.ValidAddress1
+
+	factory := testutils.MockValidInitUserOp().GetFactory()
+	for _, op := range []*userop.UserOperation{
+		mockOpWithTip(testutils.ValidAddress2, 0, 1),
+		mockOpWithTip(testutils.ValidAddress3, 0, 1),
+		mockOpWithTip(factory, 0, 1),
+	} {
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+}
+
+// TestMaxOpsEvictsSenderOpOverEntityOp verifies that eviction only considers ops of the sender and not ops
+// where the sender is the factory. Expect the cheapest op to be evicted.
+func TestMaxOpsEvictsSenderOpOverEntityOp(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOps(2)
+	ep := testutils.ValidAddress1
+
+	factory := testutils.MockValidInitUserOp().GetFactory()
+	cheap := mockOpWithTip(factory, 0, 1)
+	mid := mockOpWithTip(testutils.ValidAddress2, 0, 5)
+	incoming := mockOpWithTip(testutils.ValidAddress3, 0, 6)
+	for _, op := range []*userop.UserOperation{cheap, mid, incoming} {
+		if err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	memOps, _ := mem.Dump(ep)
+	if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], mid) || !testutils.IsOpsEqual(memOps[1], incoming) {
+		t.Fatal("incorrect op evicted")
+	}
+}
+
+// TestMaxBytesEvictsLowestTip verifies that UserOperations are evicted when the encoded size of the mempool
+// exceeds the max bytes.
+func TestMaxBytesEvictsLowestTip(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	cheap := mockOpWithTip(testutils.ValidAddress2, 0, 1)
+	data, _ := cheap.MarshalJSON()
+	mem.SetMaxBytes(int64(len(data)) + 1)
+	if err := mem.AddOp(ep, cheap); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	high := mockOpWithTip(testutils.ValidAddress3, 0, 2)
+	if err := mem.AddOp(ep, high); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	memOps, _ := mem.Dump(ep)
+	if len(memOps) != 1 {
+		t.Fatalf("got length %d, want 1", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], high) {
+		t.Fatal("incorrect op evicted")
+	}
+}
+
+// TestAddOpFetchesBaseFeeWithoutLock verifies that the basefee used for eviction is fetched without holding
+// the mempool lock. Expect a basefee func that reads from the mempool to not block.
+func TestAddOpFetchesBaseFeeWithoutLock(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetMaxOps(1)
+	ep := testutils.ValidAddress1
+	mem.SetGetBaseFeeFunc(func() (*big.Int, error) {
+		_, _ = mem.Dump(ep)
+		return big.NewInt(0), nil
+	})
+
+	_ = mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress2, 0, 1))
+	done := make(chan error)
+	go func() { done <- mem.AddOp(ep, mockOpWithTip(testutils.ValidAddress3, 0, 2)) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got blocked, want basefee fetched without lock")
+	}
+}
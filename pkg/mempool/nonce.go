@@ -0,0 +1,196 @@
+package mempool
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// nonceCacheTTL is the max duration to cache an EntryPoint nonce. This allows queued ops to become ready
+	// if the gap is filled by a transaction that did not go through this mempool.
+	nonceCacheTTL = 12 * time.Second
+)
+
+type nonceEntry struct {
+	value     *big.Int
+	fetchedAt time.Time
+}
+
+// nonceCache tracks the current EntryPoint nonce for each sender and key.
+type nonceCache struct {
+	getNonces nonce.GetNoncesFunc
+	entries   map[string]*nonceEntry
+	mu        sync.Mutex
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{
+		getNonces: nonce.GetNoncesWithFunc(nonce.GetNonceFuncNoop()),
+		entries:   make(map[string]*nonceEntry),
+	}
+}
+
+func getNonceCacheKey(entryPoint, sender common.Address, key *big.Int) string {
+	return dbutils.JoinValues(entryPoint.String(), sender.String(), key.String())
+}
+
+// lookup returns the cached EntryPoint nonce for the sender and key. A nil value means the nonce is unknown.
+// False is returned if the nonce has not been fetched or has expired.
+func (c *nonceCache) lookup(k string) (*big.Int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[k]
+	if !ok || time.Since(e.fetchedAt) >= nonceCacheTTL {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// nonceView reads EntryPoint nonces for a single Best or IsReady call. Nonces fetched during the call take
+// precedence over the cache so that they cannot expire if the call has to be retried.
+type nonceView struct {
+	cache   *nonceCache
+	fetched map[string]*big.Int
+}
+
+func newNonceView(c *nonceCache) *nonceView {
+	return &nonceView{cache: c, fetched: make(map[string]*big.Int)}
+}
+
+// lookup returns the EntryPoint nonce for the sender and key. A nil value means the nonce is unknown. False
+// is returned if the nonce must be fetched first.
+func (v *nonceView) lookup(entryPoint, sender common.Address, key *big.Int) (*big.Int, bool) {
+	k := getNonceCacheKey(entryPoint, sender, key)
+	if n, ok := v.fetched[k]; ok {
+		return n, true
+	}
+	return v.cache.lookup(k)
+}
+
+// fetch retrieves the EntryPoint nonce for each request in a single call and caches the results. This makes
+// an RPC call and should not be called while holding the mempool lock.
+func (v *nonceView) fetch(reqs []*nonce.Request) error {
+	nonces, err := v.cache.getNonces(reqs)
+	if err != nil {
+		return err
+	}
+
+	v.cache.mu.Lock()
+	defer v.cache.mu.Unlock()
+	for i, r := range reqs {
+		k := getNonceCacheKey(r.EntryPoint, r.Sender, r.Key)
+		v.fetched[k] = nonces[i]
+		v.cache.entries[k] = &nonceEntry{value: nonces[i], fetchedAt: time.Now()}
+	}
+	return nil
+}
+
+// invalidate removes the cached nonce for the sender and key of each op.
+func (c *nonceCache) invalidate(entryPoint common.Address, ops ...*userop.UserOperation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, op := range ops {
+		delete(c.entries, getNonceCacheKey(entryPoint, op.Sender, nonce.GetKey(op.Nonce)))
+	}
+}
+
+func (c *nonceCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*nonceEntry)
+}
+
+// senderQueues holds the ready ops of a single sender grouped by nonce key in ascending nonce order.
+type senderQueues struct {
+	ready map[string][]*userop.UserOperation
+}
+
+func (s *senderQueues) isHead(op *userop.UserOperation) bool {
+	q := s.ready[nonce.GetKey(op.Nonce).String()]
+	return len(q) > 0 && q[0] == op
+}
+
+func (s *senderQueues) isReady(op *userop.UserOperation) bool {
+	for _, r := range s.ready[nonce.GetKey(op.Nonce).String()] {
+		if r.Nonce.Cmp(op.Nonce) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pop removes the op at the head of its key queue and returns the next op for the same key.
+func (s *senderQueues) pop(op *userop.UserOperation) *userop.UserOperation {
+	k := nonce.GetKey(op.Nonce).String()
+	s.ready[k] = s.ready[k][1:]
+	if len(s.ready[k]) == 0 {
+		return nil
+	}
+	return s.ready[k][0]
+}
+
+// getSenderQueues groups the given ops from a single sender by nonce key and returns only those that form a
+// contiguous sequence from the current EntryPoint nonce. Ops with a nonce below the current EntryPoint nonce
+// are also considered ready so that they can be dropped by downstream modules during the bundling process.
+// If the current EntryPoint nonce is unknown, all ops are considered ready.
+//
+// Only nonces that are already known to nv are used. If a nonce for any key is unknown, nil is returned
+// along with the requests that must be fetched first.
+func getSenderQueues(
+	entryPoint common.Address,
+	sender common.Address,
+	ops []*userop.UserOperation,
+	nv *nonceView,
+) (*senderQueues, []*nonce.Request) {
+	byKey := make(map[string][]*userop.UserOperation)
+	keys := make(map[string]*big.Int)
+	for _, op := range ops {
+		key := nonce.GetKey(op.Nonce)
+		byKey[key.String()] = append(byKey[key.String()], op)
+		keys[key.String()] = key
+	}
+
+	sq := &senderQueues{ready: make(map[string][]*userop.UserOperation)}
+	missing := []*nonce.Request{}
+	for k, kops := range byKey {
+		sort.SliceStable(kops, func(i, j int) bool { return kops[i].Nonce.Cmp(kops[j].Nonce) < 0 })
+
+		curr, ok := nv.lookup(entryPoint, sender, keys[k])
+		if !ok {
+			missing = append(missing, &nonce.Request{EntryPoint: entryPoint, Sender: sender, Key: keys[k]})
+			continue
+		}
+		if curr == nil {
+			sq.ready[k] = kops
+			continue
+		}
+
+		ready := []*userop.UserOperation{}
+		expected := big.NewInt(0).Set(curr)
+		for _, op := range kops {
+			if op.Nonce.Cmp(expected) > 0 {
+				break
+			}
+			ready = append(ready, op)
+			if op.Nonce.Cmp(expected) == 0 {
+				expected.Add(expected, common.Big1)
+			}
+		}
+		sq.ready[k] = ready
+	}
+	if len(missing) > 0 {
+		return nil, missing
+	}
+
+	return sq, nil
+}
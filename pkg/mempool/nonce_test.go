@@ -0,0 +1,219 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func mockGetNonceFunc(nonces map[common.Address]*big.Int, calls *int) nonce.GetNonceFunc {
+	return func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		*calls++
+		n, ok := nonces[sender]
+		if !ok {
+			n = big.NewInt(0)
+		}
+		return big.NewInt(0).Or(big.NewInt(0).Lsh(key, 64), n), nil
+	}
+}
+
+// TestBestExcludesNonceGap verifies that ops queued behind a nonce gap are not returned by Best until the
+// gap is filled.
+func TestBestExcludesNonceGap(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	calls := 0
+	mem.SetGetNonceFunc(mockGetNonceFunc(map[common.Address]*big.Int{}, &calls))
+	ep := testutils.ValidAddress1
+
+	first := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	third := mockOpWithFees(testutils.ValidAddress2, 2, 20, 1)
+	_ = mem.AddOp(ep, first)
+	_ = mem.AddOp(ep, third)
+
+	batch, _ := mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, first)
+	if ok, _ := mem.IsReady(ep, third); ok {
+		t.Fatal("got ready, want queued")
+	}
+
+	second := mockOpWithFees(testutils.ValidAddress2, 1, 20, 1)
+	_ = mem.AddOp(ep, second)
+	batch, _ = mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, first, second, third)
+}
+
+// TestBestExcludesOpsAheadOfOnChainNonce verifies that ops are queued if the lowest nonce in the mempool is
+// greater than the current EntryPoint nonce.
+func TestBestExcludesOpsAheadOfOnChainNonce(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	calls := 0
+	mem.SetGetNonceFunc(mockGetNonceFunc(map[common.Address]*big.Int{testutils.ValidAddress2: big.NewInt(3)}, &calls))
+	ep := testutils.ValidAddress1
+
+	queued := mockOpWithFees(testutils.ValidAddress2, 4, 20, 1)
+	ready := mockOpWithFees(testutils.ValidAddress3, 0, 20, 1)
+	_ = mem.AddOp(ep, queued)
+	_ = mem.AddOp(ep, ready)
+
+	batch, _ := mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, ready)
+}
+
+// TestBestNonceKeysAreIndependent verifies that a nonce gap in one 2D nonce key does not block ops using
+// another key.
+func TestBestNonceKeysAreIndependent(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	calls := 0
+	mem.SetGetNonceFunc(mockGetNonceFunc(map[common.Address]*big.Int{}, &calls))
+	ep := testutils.ValidAddress1
+
+	gapped := mockOpWithFees(testutils.ValidAddress2, 1, 20, 1)
+	keyed := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	keyed.Nonce = big.NewInt(0).Lsh(common.Big1, 64)
+	_ = mem.AddOp(ep, gapped)
+	_ = mem.AddOp(ep, keyed)
+
+	batch, _ := mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, keyed)
+}
+
+// TestNonceCacheInvalidatedOnRemove verifies that the cached nonce for a sender is refreshed after ops are
+// removed from the mempool.
+func TestNonceCacheInvalidatedOnRemove(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	calls := 0
+	mem.SetGetNonceFunc(mockGetNonceFunc(map[common.Address]*big.Int{}, &calls))
+	ep := testutils.ValidAddress1
+
+	op := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	_ = mem.AddOp(ep, op)
+	_, _ = mem.Best(ep, 0, big.NewInt(10))
+	_, _ = mem.Best(ep, 0, big.NewInt(10))
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+
+	_ = mem.RemoveOps(ep, op)
+	_ = mem.AddOp(ep, op)
+	_, _ = mem.Best(ep, 0, big.NewInt(10))
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+}
+
+// TestBestFetchesNoncesWithoutLock verifies that EntryPoint nonces are fetched without holding the mempool
+// lock. Expect a nonce func that reads from the mempool to not block Best or IsReady.
+func TestBestFetchesNoncesWithoutLock(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	mem.SetGetNonceFunc(func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		_, _ = mem.GetOps(entryPoint, sender)
+		return big.NewInt(0), nil
+	})
+
+	op := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	_ = mem.AddOp(ep, op)
+	done := make(chan []*userop.UserOperation)
+	go func() {
+		batch, _ := mem.Best(ep, 0, big.NewInt(10))
+		done <- batch
+	}()
+	select {
+	case batch := <-done:
+		assertOpsOrder(t, batch, op)
+	case <-time.After(time.Second):
+		t.Fatal("got blocked, want nonces fetched without lock")
+	}
+
+	_ = mem.RemoveOps(ep, op)
+	_ = mem.AddOp(ep, op)
+	ready := make(chan bool)
+	go func() {
+		ok, _ := mem.IsReady(ep, op)
+		ready <- ok
+	}()
+	select {
+	case ok := <-ready:
+		if !ok {
+			t.Fatal("got queued, want ready")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got blocked, want nonces fetched without lock")
+	}
+}
+
+// TestBestFetchesNoncesInOneCall calls Best with ops from several senders that are not cached. Expect the
+// nonces of every sender to be fetched in a single call.
+func TestBestFetchesNoncesInOneCall(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	calls := 0
+	mem.SetGetNoncesFunc(func(reqs []*nonce.Request) ([]*big.Int, error) {
+		calls++
+		return make([]*big.Int, len(reqs)), nil
+	})
+	ep := testutils.ValidAddress1
+
+	ops := []*userop.UserOperation{
+		mockOpWithFees(testutils.ValidAddress2, 0, 30, 1),
+		mockOpWithFees(testutils.ValidAddress3, 0, 20, 1),
+		mockOpWithFees(testutils.ValidAddress4, 0, 10, 1),
+	}
+	for _, op := range ops {
+		_ = mem.AddOp(ep, op)
+	}
+
+	batch, err := mem.Best(ep, 0, big.NewInt(0))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertOpsOrder(t, batch, ops...)
+	if calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+}
+
+// TestBestUsesFetchedNoncesWhenCacheExpires calls Best and IsReady with a nonce cache that expires
+// immediately. Expect the nonces fetched during the call to be used instead of fetching them again.
+func TestBestUsesFetchedNoncesWhenCacheExpires(t *testing.T) {
+	ttl := nonceCacheTTL
+	nonceCacheTTL = 0
+	defer func() { nonceCacheTTL = ttl }()
+
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	calls := 0
+	mem.SetGetNonceFunc(mockGetNonceFunc(map[common.Address]*big.Int{}, &calls))
+	ep := testutils.ValidAddress1
+
+	op := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	_ = mem.AddOp(ep, op)
+	batch, err := mem.Best(ep, 0, big.NewInt(10))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	assertOpsOrder(t, batch, op)
+	if ok, _ := mem.IsReady(ep, op); !ok {
+		t.Fatal("got queued, want ready")
+	}
+	if calls != 2 {
+		t.Fatalf("got %d calls, want 2", calls)
+	}
+}
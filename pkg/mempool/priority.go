@@ -0,0 +1,141 @@
+package mempool
+
+import (
+	"container/heap"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/nonce"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+	"github.com/wangjia184/sortedset"
+)
+
+// getPriceScore returns the score used to order a UserOperation in the price index. If basefee is nil, the
+// network is assumed to not support EIP-1559 and maxFeePerGas is used instead.
+func getPriceScore(op *userop.UserOperation, baseFee *big.Int) sortedset.SCORE {
+	price := op.MaxFeePerGas
+	if baseFee != nil {
+		price = op.GetDynamicGasPrice(baseFee)
+	}
+	if !price.IsInt64() {
+		return sortedset.SCORE(math.MaxInt64)
+	}
+	return sortedset.SCORE(price.Int64())
+}
+
+func isSameBaseFee(a *big.Int, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Cmp(b) == 0
+}
+
+// rescore recomputes the price index of the set if the basefee has changed since it was last scored.
+func (s *set) rescore(baseFee *big.Int) {
+	if isSameBaseFee(s.baseFee, baseFee) {
+		return
+	}
+
+	s.baseFee = baseFee
+	for _, n := range s.byPrice.GetByRankRange(1, -1, false) {
+		op := n.Value.(*userop.UserOperation)
+		s.byPrice.AddOrUpdate(n.Key(), getPriceScore(op, baseFee), op)
+	}
+}
+
+type priceNode struct {
+	key   string
+	score sortedset.SCORE
+	op    *userop.UserOperation
+}
+
+// priceHeap is a max heap of UserOperations by price score.
+type priceHeap []*priceNode
+
+func (h priceHeap) Len() int           { return len(h) }
+func (h priceHeap) Less(i, j int) bool { return h[i].score > h[j].score }
+func (h priceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *priceHeap) Push(x any)        { *h = append(*h, x.(*priceNode)) }
+func (h *priceHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// Best returns up to n ready UserOperations from the price index of an EntryPoint, ordered by highest
+// effective gas price first while ensuring that ops from the same sender and nonce key remain in ascending
+// nonce order. Ops that are queued behind a nonce gap are skipped. A value of n <= 0 returns all ready
+// UserOperations.
+//
+// Ops from senders without a known EntryPoint nonce are also skipped and the nonces that must be fetched are
+// returned. The caller should fetch them and try again.
+func (q *userOpQueues) Best(
+	entryPoint common.Address,
+	n int,
+	baseFee *big.Int,
+	nv *nonceView,
+) ([]*userop.UserOperation, []*nonce.Request) {
+	eps := q.getEntryPointSet(entryPoint)
+	eps.rescore(baseFee)
+
+	total := eps.byPrice.GetCount()
+	if n <= 0 || n > total {
+		n = total
+	}
+
+	batch := []*userop.UserOperation{}
+	missing := []*nonce.Request{}
+	senders := make(map[common.Address]*senderQueues)
+	waiting := make(map[*userop.UserOperation]*priceNode)
+	ready := &priceHeap{}
+	emit := func(node *priceNode) {
+		batch = append(batch, node.op)
+
+		// The next op for the same sender and key may have already been passed over in the price index. If
+		// so, it is now ready to be included.
+		next := senders[node.op.Sender].pop(node.op)
+		if w, ok := waiting[next]; ok && next != nil {
+			delete(waiting, next)
+			heap.Push(ready, w)
+		}
+	}
+
+	rank := 1
+	for len(batch) < n {
+		var candidate *priceNode
+		if rank <= total {
+			pn := eps.byPrice.GetByRank(-rank, false)
+			candidate = &priceNode{pn.Key(), pn.Score(), pn.Value.(*userop.UserOperation)}
+		}
+
+		if ready.Len() > 0 && (candidate == nil || (*ready)[0].score >= candidate.score) {
+			emit(heap.Pop(ready).(*priceNode))
+			continue
+		}
+		if candidate == nil {
+			break
+		}
+		rank++
+
+		sq, ok := senders[candidate.op.Sender]
+		if !ok {
+			var m []*nonce.Request
+			sq, m = getSenderQueues(entryPoint, candidate.op.Sender, q.getSenderOps(eps, candidate.op.Sender), nv)
+			missing = append(missing, m...)
+			senders[candidate.op.Sender] = sq
+		}
+
+		if sq == nil {
+			continue
+		} else if sq.isHead(candidate.op) {
+			emit(candidate)
+		} else if sq.isReady(candidate.op) {
+			waiting[candidate.op] = candidate
+		}
+	}
+
+	return batch, missing
+}
@@ -0,0 +1,120 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func mockOpWithFees(sender common.Address, nonce int64, maxFee int64, tip int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(nonce)
+	op.MaxFeePerGas = big.NewInt(maxFee)
+	op.MaxPriorityFeePerGas = big.NewInt(tip)
+	return op
+}
+
+func assertOpsOrder(t *testing.T, got []*userop.UserOperation, want ...*userop.UserOperation) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got length %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !testutils.IsOpsEqual(got[i], want[i]) {
+			t.Fatalf("incorrect order at index %d: %s", i, testutils.GetOpsDiff(got[i], want[i]))
+		}
+	}
+}
+
+// TestBestOrdersByEffectiveGasPrice verifies that Best returns ops by highest effective gas price first.
+func TestBestOrdersByEffectiveGasPrice(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	low := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	high := mockOpWithFees(testutils.ValidAddress3, 0, 20, 5)
+	mid := mockOpWithFees(testutils.ValidAddress4, 0, 20, 3)
+	for _, op := range []*userop.UserOperation{low, high, mid} {
+		_ = mem.AddOp(ep, op)
+	}
+
+	batch, _ := mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, high, mid, low)
+}
+
+// TestBestPreservesNonceOrder verifies that a higher priced op is not returned before a lower nonce op from
+// the same sender.
+func TestBestPreservesNonceOrder(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	first := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	second := mockOpWithFees(testutils.ValidAddress2, 1, 20, 9)
+	other := mockOpWithFees(testutils.ValidAddress3, 0, 20, 5)
+	for _, op := range []*userop.UserOperation{second, other, first} {
+		_ = mem.AddOp(ep, op)
+	}
+
+	batch, _ := mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, other, first, second)
+}
+
+// TestBestLimit verifies that Best returns at most n ops.
+func TestBestLimit(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	low := mockOpWithFees(testutils.ValidAddress2, 0, 20, 1)
+	high := mockOpWithFees(testutils.ValidAddress3, 0, 20, 5)
+	_ = mem.AddOp(ep, low)
+	_ = mem.AddOp(ep, high)
+
+	batch, _ := mem.Best(ep, 1, big.NewInt(10))
+	assertOpsOrder(t, batch, high)
+}
+
+// TestBestRescoresOnBaseFeeChange verifies that the ordering is updated when the basefee changes and the
+// effective gas price of some ops becomes capped by maxFeePerGas.
+func TestBestRescoresOnBaseFeeChange(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	capped := mockOpWithFees(testutils.ValidAddress2, 0, 12, 10)
+	uncapped := mockOpWithFees(testutils.ValidAddress3, 0, 100, 5)
+	_ = mem.AddOp(ep, capped)
+	_ = mem.AddOp(ep, uncapped)
+
+	batch, _ := mem.Best(ep, 0, big.NewInt(1))
+	assertOpsOrder(t, batch, capped, uncapped)
+
+	batch, _ = mem.Best(ep, 0, big.NewInt(10))
+	assertOpsOrder(t, batch, uncapped, capped)
+}
+
+// TestBestWithoutBaseFee verifies that ops are ordered by maxFeePerGas when basefee is nil.
+func TestBestWithoutBaseFee(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	low := mockOpWithFees(testutils.ValidAddress2, 0, 10, 10)
+	high := mockOpWithFees(testutils.ValidAddress3, 0, 20, 1)
+	_ = mem.AddOp(ep, low)
+	_ = mem.AddOp(ep, high)
+
+	batch, _ := mem.Best(ep, 0, nil)
+	assertOpsOrder(t, batch, high, low)
+}
@@ -1,6 +1,8 @@
 package mempool
 
 import (
+	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -10,7 +12,15 @@ import (
 
 type set struct {
 	all      *sortedset.SortedSet
+	byPrice  *sortedset.SortedSet
 	entities map[common.Address]*sortedset.SortedSet
+
+	// baseFee is the value used to compute the current scores in the byPrice index.
+	baseFee *big.Int
+
+	// arrivals is incremented on every added op and used as a score to preserve the order in which ops
+	// arrived, even after other ops have been removed.
+	arrivals uint64
 }
 
 func (s *set) getEntitiesSortedSet(entity common.Address) *sortedset.SortedSet {
@@ -21,6 +31,15 @@ func (s *set) getEntitiesSortedSet(entity common.Address) *sortedset.SortedSet {
 	return s.entities[entity]
 }
 
+// findEntitiesSortedSet returns the sorted set for an entity without creating it. It returns an empty set if
+// the entity has no ops and is safe to use for reads.
+func (s *set) findEntitiesSortedSet(entity common.Address) *sortedset.SortedSet {
+	if ess, ok := s.entities[entity]; ok {
+		return ess
+	}
+	return sortedset.New()
+}
+
 type userOpQueues struct {
 	setsByEntryPoint sync.Map
 }
@@ -28,11 +47,11 @@ type userOpQueues struct {
 func (q *userOpQueues) getEntryPointSet(entryPoint common.Address) *set {
 	val, ok := q.setsByEntryPoint.Load(entryPoint)
 	if !ok {
-		val = &set{
+		val, _ = q.setsByEntryPoint.LoadOrStore(entryPoint, &set{
 			all:      sortedset.New(),
+			byPrice:  sortedset.New(),
 			entities: make(map[common.Address]*sortedset.SortedSet),
-		}
-		q.setsByEntryPoint.Store(entryPoint, val)
+		})
 	}
 
 	return val.(*set)
@@ -42,21 +61,27 @@ func (q *userOpQueues) AddOp(entryPoint common.Address, op *userop.UserOperation
 	eps := q.getEntryPointSet(entryPoint)
 	key := string(getUniqueKey(entryPoint, op.Sender, op.Nonce))
 
-	eps.all.AddOrUpdate(key, sortedset.SCORE(eps.all.GetCount()), op)
+	score := sortedset.SCORE(eps.arrivals)
+	if n := eps.all.GetByKey(key); n != nil {
+		score = n.Score()
+	} else {
+		eps.arrivals++
+	}
+
+	eps.all.AddOrUpdate(key, score, op)
+	eps.byPrice.AddOrUpdate(key, getPriceScore(op, eps.baseFee), op)
 	eps.getEntitiesSortedSet(op.Sender).AddOrUpdate(key, sortedset.SCORE(op.Nonce.Int64()), op)
 	if factory := op.GetFactory(); factory != common.HexToAddress("0x") {
-		fss := eps.getEntitiesSortedSet(factory)
-		fss.AddOrUpdate(key, sortedset.SCORE(fss.GetCount()), op)
+		eps.getEntitiesSortedSet(factory).AddOrUpdate(key, score, op)
 	}
 	if paymaster := op.GetPaymaster(); paymaster != common.HexToAddress("0x") {
-		pss := eps.getEntitiesSortedSet(paymaster)
-		pss.AddOrUpdate(key, sortedset.SCORE(pss.GetCount()), op)
+		eps.getEntitiesSortedSet(paymaster).AddOrUpdate(key, score, op)
 	}
 }
 
 func (q *userOpQueues) GetOps(entryPoint common.Address, entity common.Address) []*userop.UserOperation {
 	eps := q.getEntryPointSet(entryPoint)
-	ess := eps.getEntitiesSortedSet(entity)
+	ess := eps.findEntitiesSortedSet(entity)
 	nodes := ess.GetByRankRange(-1, -ess.GetCount(), false)
 	batch := []*userop.UserOperation{}
 	for _, n := range nodes {
@@ -66,6 +91,22 @@ func (q *userOpQueues) GetOps(entryPoint common.Address, entity common.Address)
 	return batch
 }
 
+// getSenderOps returns all ops in the EntryPoint set where the given address is the sender, sorted by nonce in
+// ascending order.
+func (q *userOpQueues) getSenderOps(eps *set, sender common.Address) []*userop.UserOperation {
+	ess := eps.findEntitiesSortedSet(sender)
+	ops := []*userop.UserOperation{}
+	for _, n := range ess.GetByRankRange(1, -1, false) {
+		if op := n.Value.(*userop.UserOperation); op.Sender == sender {
+			ops = append(ops, op)
+		}
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].Nonce.Cmp(ops[j].Nonce) < 0
+	})
+	return ops
+}
+
 func (q *userOpQueues) All(entryPoint common.Address) []*userop.UserOperation {
 	eps := q.getEntryPointSet(entryPoint)
 	nodes := eps.all.GetByRankRange(1, -1, false)
@@ -82,6 +123,7 @@ func (q *userOpQueues) RemoveOps(entryPoint common.Address, ops ...*userop.UserO
 	for _, op := range ops {
 		key := string(getUniqueKey(entryPoint, op.Sender, op.Nonce))
 		eps.all.Remove(key)
+		eps.byPrice.Remove(key)
 		eps.getEntitiesSortedSet(op.Sender).Remove(key)
 		eps.getEntitiesSortedSet(op.GetFactory()).Remove(key)
 		eps.getEntitiesSortedSet(op.GetPaymaster()).Remove(key)
@@ -0,0 +1,59 @@
+package batch
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+// MaintainMinBundleSize returns a BatchHandlerFunc that defers UserOperations with a preVerificationGas that
+// only covers the fixed bundle overhead when it is amortized across a larger batch than the current one.
+// Later ops from the same sender are also deferred to prevent nonce gaps. Ops that cannot be funded by any
+// bundle size are dropped. This should only be used if PVG is amortized with
+// Overhead.SetExpectedBundleSizeFunc.
+func MaintainMinBundleSize(ov *gas.Overhead) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		blocked := map[common.Address]bool{}
+		mins := []int{}
+		for i := 0; i < len(ctx.Batch); {
+			op := ctx.Batch[i]
+			if blocked[op.Sender] {
+				ctx.DeferOpIndex(i)
+				continue
+			}
+
+			min, err := ov.CalcMinBundleSize(op)
+			if errors.Is(err, gas.ErrPreVerificationGasTooLow) {
+				blocked[op.Sender] = true
+				ctx.MarkOpIndexForRemoval(i, err.Error())
+				continue
+			} else if err != nil {
+				return err
+			}
+			mins = append(mins, min)
+			i++
+		}
+
+		// Deferring ops reduces the batch size which may cause other ops to fall short. Repeat until the
+		// batch is stable.
+		for {
+			deferred := map[common.Address]bool{}
+			size := len(ctx.Batch)
+			for i := 0; i < len(ctx.Batch); {
+				op := ctx.Batch[i]
+				if deferred[op.Sender] || mins[i] > size {
+					deferred[op.Sender] = true
+					ctx.DeferOpIndex(i)
+					mins = append(mins[:i], mins[i+1:]...)
+					continue
+				}
+				i++
+			}
+			if len(deferred) == 0 {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package batch
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func newOpWithMinBundleSize(
+	t *testing.T,
+	ov *gas.Overhead,
+	size float64,
+	sender common.Address,
+	nonce int64,
+) *userop.UserOperation {
+	t.Helper()
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(nonce)
+
+	ov.SetExpectedBundleSizeFunc(func() float64 { return size })
+	pvg, err := ov.CalcPreVerificationGas(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op.PreVerificationGas = pvg
+	return op
+}
+
+// TestMaintainMinBundleSize verifies that ops requiring a larger bundle are deferred along with later ops
+// from the same sender, and that deferring repeats until the batch is stable.
+func TestMaintainMinBundleSize(t *testing.T) {
+	ov := gas.NewDefaultOverhead()
+	a := common.HexToAddress("0xa")
+	b := common.HexToAddress("0xb")
+	c := common.HexToAddress("0xc")
+	batch := []*userop.UserOperation{
+		newOpWithMinBundleSize(t, ov, 1, a, 0),
+		newOpWithMinBundleSize(t, ov, 5, b, 0),
+		newOpWithMinBundleSize(t, ov, 1, b, 1),
+		newOpWithMinBundleSize(t, ov, 3, c, 0),
+	}
+
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	if err := MaintainMinBundleSize(ov)(ctx); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	// b's first op needs 5 ops and is deferred with its second op. c's op then needs 3 ops but only 2 remain.
+	if len(ctx.Batch) != 1 || ctx.Batch[0].Sender != a {
+		t.Fatalf("got batch length %d, want only sender a", len(ctx.Batch))
+	}
+	if len(ctx.PendingRemoval) != 0 {
+		t.Fatalf("got %d pending removals, want 0", len(ctx.PendingRemoval))
+	}
+}
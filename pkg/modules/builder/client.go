@@ -27,6 +27,7 @@ type BuilderClient struct {
 	beneficiary       common.Address
 	blocksInTheFuture int
 	waitTimeout       time.Duration
+	feeStrategy       transaction.FeeStrategy
 }
 
 // New returns an instance of a BuilderClient with modules to send UserOperation bundles via the mev-boost
@@ -45,6 +46,7 @@ func New(
 		beneficiary:       beneficiary,
 		blocksInTheFuture: blocksInTheFuture,
 		waitTimeout:       DefaultWaitTimeout,
+		feeStrategy:       transaction.MeanFeeStrategy(),
 	}
 }
 
@@ -57,6 +59,12 @@ func (b *BuilderClient) SetWaitTimeout(timeout time.Duration) {
 	b.waitTimeout = timeout
 }
 
+// SetFeeStrategy sets the strategy used to determine the fees of the transaction that submits a batch. The
+// default strategy is transaction.MeanFeeStrategy.
+func (b *BuilderClient) SetFeeStrategy(fs transaction.FeeStrategy) {
+	b.feeStrategy = fs
+}
+
 // SendUserOperation returns a BatchHandler that is used by the Bundler to send batches to a block builder
 // that supports eth_sendBundle.
 func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
@@ -74,6 +82,7 @@ func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
 			GasLimit:    0,
 			NoSend:      true,
 			WaitTimeout: b.waitTimeout,
+			FeeStrategy: b.feeStrategy,
 		}
 		// Estimate gas for handleOps() and drop all userOps that cause unexpected reverts.
 		for len(ctx.Batch) > 0 {
@@ -2,6 +2,7 @@ package checks
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
@@ -9,8 +10,11 @@ import (
 )
 
 var (
-	keyPrefix        = dbutils.JoinValues("checks")
-	codeHashesPrefix = dbutils.JoinValues(keyPrefix, "codeHashes")
+	keyPrefix            = dbutils.JoinValues("checks")
+	codeHashesPrefix     = dbutils.JoinValues(keyPrefix, "codeHashes")
+	validityWindowPrefix = dbutils.JoinValues(keyPrefix, "validityWindow")
+	storageAccessPrefix  = dbutils.JoinValues(keyPrefix, "storageAccess")
+	pvgShortfallPrefix   = dbutils.JoinValues(keyPrefix, "pvgShortfall")
 )
 
 func getCodeHashesKey(userOpHash common.Hash) []byte {
@@ -44,13 +48,132 @@ func getSavedCodeHashes(db *badger.DB, userOpHash common.Hash) ([]codeHash, erro
 	return ch, err
 }
 
-func removeSavedCodeHashes(db *badger.DB, userOpHashes ...common.Hash) error {
+func getValidityWindowKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(validityWindowPrefix, userOpHash.String()))
+}
+
+func saveValidityWindow(db *badger.DB, userOpHash common.Hash, vw *validityWindow) error {
+	return db.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(vw)
+		if err != nil {
+			return err
+		}
+
+		return txn.Set(getValidityWindowKey(userOpHash), data)
+	})
+}
+
+// getSavedValidityWindow returns the validity window saved during simulation. If no value was saved, a nil
+// window is returned without an error.
+func getSavedValidityWindow(db *badger.DB, userOpHash common.Hash) (*validityWindow, error) {
+	var vw *validityWindow
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getValidityWindowKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			vw = &validityWindow{}
+			return json.Unmarshal(val, vw)
+		})
+	})
+
+	return vw, err
+}
+
+func getStorageAccessKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(storageAccessPrefix, userOpHash.String()))
+}
+
+func saveStorageAccess(db *badger.DB, userOpHash common.Hash, addrs []common.Address) error {
+	return db.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(addrs)
+		if err != nil {
+			return err
+		}
+
+		return txn.Set(getStorageAccessKey(userOpHash), data)
+	})
+}
+
+// getSavedStorageAccess returns the addresses with storage accessed during validation. If no value was saved,
+// an empty slice is returned without an error.
+func getSavedStorageAccess(db *badger.DB, userOpHash common.Hash) ([]common.Address, error) {
+	addrs := []common.Address{}
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getStorageAccessKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &addrs)
+		})
+	})
+
+	return addrs, err
+}
+
+func getPVGShortfallKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(pvgShortfallPrefix, userOpHash.String()))
+}
+
+func savePVGShortfall(db *badger.DB, userOpHash common.Hash, blockNum uint64) error {
+	return db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getPVGShortfallKey(userOpHash), []byte(strconv.FormatUint(blockNum, 10)))
+	})
+}
+
+// getSavedPVGShortfall returns the block number at which the op was first found to have an insufficient
+// preVerificationGas. If no value was saved, false is returned without an error.
+func getSavedPVGShortfall(db *badger.DB, userOpHash common.Hash) (uint64, bool, error) {
+	var blockNum uint64
+	found := false
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getPVGShortfallKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			found = true
+			blockNum, err = strconv.ParseUint(string(val), 10, 64)
+			return err
+		})
+	})
+
+	return blockNum, found, err
+}
+
+func removeSavedPVGShortfalls(db *badger.DB, userOpHashes ...common.Hash) error {
 	return db.Update(func(txn *badger.Txn) error {
 		for _, userOpHash := range userOpHashes {
-			if err := txn.Delete(getCodeHashesKey(userOpHash)); err != nil {
+			if err := txn.Delete(getPVGShortfallKey(userOpHash)); err != nil {
 				return err
 			}
 		}
 		return nil
 	})
 }
+
+// removeSavedState deletes all data saved during simulation for a UserOperation.
+func removeSavedState(txn *badger.Txn, userOpHash common.Hash) error {
+	for _, key := range [][]byte{
+		getCodeHashesKey(userOpHash),
+		getValidityWindowKey(userOpHash),
+		getStorageAccessKey(userOpHash),
+		getPVGShortfallKey(userOpHash),
+	} {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
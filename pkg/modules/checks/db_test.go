@@ -0,0 +1,67 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+)
+
+// TestOnEvictRemovesSavedState evicts an op from a full mempool with the checks OnEvict func set. Expect all
+// data saved during simulation for the evicted op to be removed.
+func TestOnEvictRemovesSavedState(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	s := &Standalone{db: db}
+	ep := testutils.ValidAddress1
+
+	mem, _ := mempool.New(db)
+	mem.SetMaxOps(1)
+	mem.SetOnEvictFunc(s.OnEvict(testutils.ChainID))
+
+	cheap := testutils.MockValidInitUserOp()
+	cheap.MaxFeePerGas, cheap.MaxPriorityFeePerGas = big.NewInt(1), big.NewInt(1)
+	hash := cheap.GetUserOpHash(ep, testutils.ChainID)
+	if err := saveCodeHashes(db, hash, []codeHash{}); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if err := saveValidityWindow(db, hash, &validityWindow{}); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if err := saveStorageAccess(db, hash, []common.Address{}); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if err := savePVGShortfall(db, hash, 1); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if err := mem.AddOp(ep, cheap); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	high := testutils.MockValidInitUserOp()
+	high.Sender = testutils.ValidAddress2
+	high.MaxFeePerGas, high.MaxPriorityFeePerGas = big.NewInt(2), big.NewInt(2)
+	if err := mem.AddOp(ep, high); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	err := db.View(func(txn *badger.Txn) error {
+		for _, key := range [][]byte{
+			getCodeHashesKey(hash),
+			getValidityWindowKey(hash),
+			getStorageAccessKey(hash),
+			getPVGShortfallKey(hash),
+		} {
+			if _, err := txn.Get(key); err != badger.ErrKeyNotFound {
+				t.Errorf("got %v for key %s, want %v", err, key, badger.ErrKeyNotFound)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
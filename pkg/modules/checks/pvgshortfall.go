@@ -0,0 +1,74 @@
+package checks
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+// checkPreVerificationGas recomputes the required preVerificationGas for each op in the batch. Ops that fall
+// short are deferred and the block at which they were first found short is saved. Once an op has been short
+// for maxShortfallBlocks it is dropped. Later ops from the same sender are deferred to prevent nonce gaps.
+func checkPreVerificationGas(
+	ctx *modules.BatchHandlerCtx,
+	db *badger.DB,
+	ov *gas.Overhead,
+	getBlockNum GetBlockNumberFunc,
+	maxShortfallBlocks uint64,
+) error {
+	size := len(ctx.Batch)
+	blocked := map[common.Address]bool{}
+	var blockNum *uint64
+	for i := 0; i < len(ctx.Batch); {
+		op := ctx.Batch[i]
+		if blocked[op.Sender] {
+			ctx.DeferOpIndex(i)
+			continue
+		}
+
+		hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+		first, found, err := getSavedPVGShortfall(db, hash)
+		if err != nil {
+			return err
+		}
+
+		pvg, err := ov.CalcPreVerificationGasForBundleSize(op, size)
+		if err != nil {
+			return err
+		}
+		if op.PreVerificationGas.Cmp(pvg) >= 0 {
+			if found {
+				if err := removeSavedPVGShortfalls(db, hash); err != nil {
+					return err
+				}
+			}
+			i++
+			continue
+		}
+
+		blocked[op.Sender] = true
+		if blockNum == nil {
+			bn, err := getBlockNum()
+			if err != nil {
+				return err
+			}
+			blockNum = &bn
+		}
+
+		if !found {
+			if err := savePVGShortfall(db, hash, *blockNum); err != nil {
+				return err
+			}
+			first = *blockNum
+		}
+		if *blockNum-first >= maxShortfallBlocks {
+			ctx.MarkOpIndexForRemoval(i, fmt.Sprintf("preVerificationGas: below expected gas of %s", pvg.String()))
+		} else {
+			ctx.DeferOpIndex(i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+const mockL1Fee = 10000
+
+func newOpWithPVG(
+	t *testing.T,
+	ov *gas.Overhead,
+	sender common.Address,
+	nonce int64,
+	size int,
+	delta int64,
+) *userop.UserOperation {
+	t.Helper()
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(nonce)
+
+	pvg, err := ov.CalcPreVerificationGasForBundleSize(op, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op.PreVerificationGas = big.NewInt(0).Add(pvg, big.NewInt(delta))
+	return op
+}
+
+// TestCheckPreVerificationGas calls checks.checkPreVerificationGas with a batch where some ops no longer
+// cover the live L1 fee. Expect newly short ops to be deferred with later ops from the same sender and ops
+// that have been short for too long to be dropped.
+func TestCheckPreVerificationGas(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	ov := gas.NewDefaultOverhead()
+	ov.SetCalcPreVerificationGasFunc(func(op *userop.UserOperation, static *big.Int, bundleSize float64) (*big.Int, error) {
+		return big.NewInt(0).Add(static, big.NewInt(mockL1Fee)), nil
+	})
+
+	a := common.HexToAddress("0xa")
+	b := common.HexToAddress("0xb")
+	c := common.HexToAddress("0xc")
+	okA := newOpWithPVG(t, ov, a, 0, 4, 0)
+	shortB := newOpWithPVG(t, ov, b, 0, 4, -1)
+	okB := newOpWithPVG(t, ov, b, 1, 4, 0)
+	shortC := newOpWithPVG(t, ov, c, 0, 4, -1)
+	if err := savePVGShortfall(db, shortC.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID), 90); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{okA, shortB, okB, shortC},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	bn := func() (uint64, error) { return 100, nil }
+	if err := checkPreVerificationGas(ctx, db, ov, bn, 10); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if len(ctx.Batch) != 1 || !testutils.IsOpsEqual(ctx.Batch[0], okA) {
+		t.Fatalf("batch: got length %d, want only okA", len(ctx.Batch))
+	} else if len(ctx.PendingRemoval) != 1 || ctx.PendingRemoval[0].Op.Sender != c {
+		t.Fatalf("pending removal: got length %d, want only shortC", len(ctx.PendingRemoval))
+	}
+
+	first, found, err := getSavedPVGShortfall(db, shortB.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID))
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	} else if !found || first != 100 {
+		t.Fatalf("shortfall: got %d, %t, want 100, true", first, found)
+	}
+}
+
+// TestCheckPreVerificationGasRecovers calls checks.checkPreVerificationGas with an op that was previously
+// short but now covers the L1 fee. Expect the op to be included and its shortfall record removed.
+func TestCheckPreVerificationGasRecovers(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	ov := gas.NewDefaultOverhead()
+
+	op := newOpWithPVG(t, ov, common.HexToAddress("0xa"), 0, 1, 0)
+	hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	if err := savePVGShortfall(db, hash, 90); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	bn := func() (uint64, error) {
+		t.Fatal("block number should not be fetched")
+		return 0, nil
+	}
+	if err := checkPreVerificationGas(ctx, db, ov, bn, 10); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if len(ctx.Batch) != 1 {
+		t.Fatalf("batch: got length %d, want 1", len(ctx.Batch))
+	}
+	if _, found, err := getSavedPVGShortfall(db, hash); err != nil {
+		t.Fatalf("get failed: %v", err)
+	} else if found {
+		t.Fatal("shortfall: got saved record, want none")
+	}
+}
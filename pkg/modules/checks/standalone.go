@@ -8,13 +8,14 @@ import (
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/altmempools"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
@@ -24,11 +25,11 @@ import (
 
 // Standalone exposes modules to perform basic Client and Bundler checks as specified in EIP-4337. It is
 // intended for bundlers that are independent of an Ethereum node and hence relies on a given ethClient to
-// query blockchain state.
+// query blockchain state and an rpcClient to trace calls.
 type Standalone struct {
 	db                 *badger.DB
-	rpc                *rpc.Client
-	eth                *ethclient.Client
+	rpc                backend.TraceCaller
+	eth                backend.ContractCaller
 	ov                 *gas.Overhead
 	alt                *altmempools.Directory
 	maxVerificationGas *big.Int
@@ -42,7 +43,8 @@ type Standalone struct {
 // standard checks as specified in EIP-4337.
 func New(
 	db *badger.DB,
-	rpc *rpc.Client,
+	eth backend.ContractCaller,
+	rpc backend.TraceCaller,
 	ov *gas.Overhead,
 	alt *altmempools.Directory,
 	maxVerificationGas *big.Int,
@@ -51,7 +53,6 @@ func New(
 	tracer string,
 	repConst *entities.ReputationConstants,
 ) *Standalone {
-	eth := ethclient.NewClient(rpc)
 	return &Standalone{
 		db,
 		rpc,
@@ -94,8 +95,11 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 	return func(ctx *modules.UserOpHandlerCtx) error {
 		gc := getCodeWithEthClient(s.eth)
 		g := new(errgroup.Group)
+		var sim *reverts.ValidationResultRevert
+		var trace *simulation.TraceOutput
 		g.Go(func() error {
-			sim, err := simulation.SimulateValidation(s.rpc, ctx.EntryPoint, ctx.UserOp)
+			var err error
+			sim, err = simulation.SimulateValidation(s.eth, ctx.EntryPoint, ctx.UserOp)
 
 			if err != nil {
 				return errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, err.Error(), err.Error())
@@ -107,18 +111,11 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 					nil,
 				)
 			}
-			if sim.ReturnInfo.ValidUntil.Cmp(common.Big0) != 0 &&
-				time.Now().Unix() >= sim.ReturnInfo.ValidUntil.Int64()-30 {
-				return errors.NewRPCError(
-					errors.SHORT_DEADLINE,
-					"expires too soon",
-					nil,
-				)
-			}
 			return nil
 		})
 		g.Go(func() error {
-			out, err := simulation.TraceSimulateValidation(&simulation.TraceInput{
+			var err error
+			trace, err = simulation.TraceSimulateValidation(&simulation.TraceInput{
 				Rpc:                s.rpc,
 				EntryPoint:         ctx.EntryPoint,
 				AltMempools:        s.alt,
@@ -132,18 +129,38 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 					ctx.UserOp.GetPaymaster(): ctx.GetPaymasterDepositInfo(),
 				},
 			})
-			if err != nil {
-				return errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
-			}
-
-			ch, err := getCodeHashes(out.TouchedContracts, gc)
-			if err != nil {
+			if v, ok := err.(*simulation.Violation); ok {
+				return errors.NewRPCError(errors.BANNED_OPCODE, v.Error(), v)
+			} else if err != nil {
 				return errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
 			}
-			return saveCodeHashes(s.db, ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID), ch)
+			return nil
 		})
+		if err := g.Wait(); err != nil {
+			return err
+		}
 
-		return g.Wait()
+		vw := newValidityWindow(sim.ReturnInfo, trace.PaymasterValidationData)
+		if vw.expiresWithin(time.Now().Unix(), minValidUntilOnAdmission) {
+			return errors.NewRPCError(
+				errors.SHORT_DEADLINE,
+				"expires too soon",
+				nil,
+			)
+		}
+
+		ch, err := getCodeHashes(trace.TouchedContracts, gc)
+		if err != nil {
+			return errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
+		}
+		hash := ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+		if err := saveCodeHashes(s.db, hash, ch); err != nil {
+			return err
+		}
+		if err := saveStorageAccess(s.db, hash, trace.AccessedStorage); err != nil {
+			return err
+		}
+		return saveValidityWindow(s.db, hash, vw)
 	}
 }
 
@@ -173,6 +190,73 @@ func (s *Standalone) CodeHashes() modules.BatchHandlerFunc {
 	}
 }
 
+// ValidityWindow returns a BatchHandler that checks each UserOp in the batch against the validAfter and
+// validUntil range returned during simulation. UserOps that are not valid yet are deferred to a later batch
+// and UserOps that have expired, or will expire before the bundle can be mined, are dropped.
+func (s *Standalone) ValidityWindow() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		now := time.Now().Unix()
+
+		end := len(ctx.Batch) - 1
+		for i := end; i >= 0; i-- {
+			op := ctx.Batch[i]
+			vw, err := getSavedValidityWindow(s.db, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID))
+			if err != nil {
+				return err
+			}
+			if vw == nil {
+				continue
+			}
+
+			if vw.expiresWithin(now, minValidUntilOnBundle) {
+				ctx.MarkOpIndexForRemoval(i, "expired validUntil")
+			} else if vw.isPending(now) {
+				ctx.DeferOpIndex(i)
+			}
+		}
+		return nil
+	}
+}
+
+// StorageConflicts returns a BatchHandler that ensures no UserOp in the batch accesses the storage of another
+// UserOp's sender and that no sender is used as a factory or paymaster by another UserOp. Conflicting UserOps
+// are deferred to a later batch.
+func (s *Standalone) StorageConflicts() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		access := make(map[common.Hash][]common.Address)
+		for _, op := range ctx.Batch {
+			hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+			addrs, err := getSavedStorageAccess(s.db, hash)
+			if err != nil {
+				return err
+			}
+			access[hash] = addrs
+		}
+
+		sc := newStorageConflicts()
+		for i := 0; i < len(ctx.Batch); {
+			op := ctx.Batch[i]
+			if sc.hasConflict(op, access[op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)]) {
+				ctx.DeferOpIndex(i)
+				continue
+			}
+
+			sc.include(op, access[op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)])
+			i++
+		}
+		return nil
+	}
+}
+
+// PreVerificationGas returns a BatchHandler that recomputes the required preVerificationGas for each UserOp
+// against the current batch size and L1 fee. UserOps that fall short are deferred along with later UserOps
+// from the same sender. UserOps that remain short for more than maxShortfallBlocks are dropped.
+func (s *Standalone) PreVerificationGas(maxShortfallBlocks uint64) modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		return checkPreVerificationGas(ctx, s.db, s.ov, getBlockNumberWithEthClient(s.eth), maxShortfallBlocks)
+	}
+}
+
 // PaymasterDeposit returns a BatchHandler that tracks each paymaster in the batch and ensures it has enough
 // deposit to pay for all the UserOps that use it.
 func (s *Standalone) PaymasterDeposit() modules.BatchHandlerFunc {
@@ -223,11 +307,21 @@ func (s *Standalone) Clean() modules.BatchHandlerFunc {
 		for _, item := range ctx.PendingRemoval {
 			all = append(all, item.Op)
 		}
-		hashes := []common.Hash{}
-		for _, op := range all {
-			hashes = append(hashes, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID))
-		}
+		return s.db.Update(func(txn *badger.Txn) error {
+			for _, op := range all {
+				if err := removeSavedState(txn, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
 
-		return removeSavedCodeHashes(s.db, hashes...)
+// OnEvict returns a function for the mempool that clears the DB of data saved for a UserOperation that is
+// evicted. This is the same data that is removed by Clean once an op leaves the mempool through a bundle.
+func (s *Standalone) OnEvict(chainID *big.Int) mempool.OnEvictFunc {
+	return func(txn *badger.Txn, entryPoint common.Address, op *userop.UserOperation) error {
+		return removeSavedState(txn, op.GetUserOpHash(entryPoint, chainID))
 	}
 }
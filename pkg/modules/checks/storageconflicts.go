@@ -0,0 +1,69 @@
+package checks
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// storageConflicts tracks the senders, entities, and storage accessed by the UserOps already included in a
+// batch.
+type storageConflicts struct {
+	senders  mapset.Set[common.Address]
+	entities mapset.Set[common.Address]
+	accessed mapset.Set[common.Address]
+	deferred mapset.Set[common.Address]
+}
+
+func newStorageConflicts() *storageConflicts {
+	return &storageConflicts{
+		senders:  mapset.NewSet[common.Address](),
+		entities: mapset.NewSet[common.Address](),
+		accessed: mapset.NewSet[common.Address](),
+		deferred: mapset.NewSet[common.Address](),
+	}
+}
+
+func getOpEntities(op *userop.UserOperation) []common.Address {
+	ents := []common.Address{}
+	if f := op.GetFactory(); f != common.HexToAddress("0x") {
+		ents = append(ents, f)
+	}
+	if pm := op.GetPaymaster(); pm != common.HexToAddress("0x") {
+		ents = append(ents, pm)
+	}
+	return ents
+}
+
+// hasConflict returns true if the op cannot be included alongside the ops already in the batch. Once an op
+// is deferred, all subsequent ops from the same sender are also deferred to prevent a nonce gap.
+func (c *storageConflicts) hasConflict(op *userop.UserOperation, accessed []common.Address) bool {
+	conflict := c.deferred.Contains(op.Sender) ||
+		c.accessed.Contains(op.Sender) ||
+		c.entities.Contains(op.Sender)
+	for _, addr := range accessed {
+		if addr != op.Sender && c.senders.Contains(addr) {
+			conflict = true
+		}
+	}
+	for _, ent := range getOpEntities(op) {
+		if c.senders.Contains(ent) {
+			conflict = true
+		}
+	}
+
+	if conflict {
+		c.deferred.Add(op.Sender)
+	}
+	return conflict
+}
+
+func (c *storageConflicts) include(op *userop.UserOperation, accessed []common.Address) {
+	c.senders.Add(op.Sender)
+	c.entities.Append(getOpEntities(op)...)
+	for _, addr := range accessed {
+		if addr != op.Sender {
+			c.accessed.Add(addr)
+		}
+	}
+}
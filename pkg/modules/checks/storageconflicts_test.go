@@ -0,0 +1,105 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func runStorageConflicts(
+	t *testing.T,
+	batch []*userop.UserOperation,
+	access map[*userop.UserOperation][]common.Address,
+) *modules.BatchHandlerCtx {
+	t.Helper()
+	db := testutils.DBMock()
+	defer db.Close()
+	s := &Standalone{db: db}
+
+	for op, addrs := range access {
+		hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+		if err := saveStorageAccess(db, hash, addrs); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	if err := (s.StorageConflicts())(ctx); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	return ctx
+}
+
+func mockOpWithSender(sender common.Address, nonce int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.Nonce = big.NewInt(nonce)
+	op.InitCode = []byte{}
+	op.PaymasterAndData = []byte{}
+	return op
+}
+
+// TestStorageConflictsNoConflict calls checks.Standalone.StorageConflicts with independent UserOps. Expect
+// all ops to remain in the batch.
+func TestStorageConflictsNoConflict(t *testing.T) {
+	op1 := mockOpWithSender(testutils.ValidAddress2, 0)
+	op2 := mockOpWithSender(testutils.ValidAddress3, 0)
+	ctx := runStorageConflicts(t, []*userop.UserOperation{op1, op2}, nil)
+
+	if len(ctx.Batch) != 2 {
+		t.Fatalf("got length %d, want 2", len(ctx.Batch))
+	}
+}
+
+// TestStorageConflictsAccessOtherSender calls checks.Standalone.StorageConflicts where a UserOp accesses the
+// storage of another sender in the batch. Expect the later op and subsequent ops from the same sender to be
+// deferred without being marked for removal.
+func TestStorageConflictsAccessOtherSender(t *testing.T) {
+	op1 := mockOpWithSender(testutils.ValidAddress2, 0)
+	op2 := mockOpWithSender(testutils.ValidAddress3, 0)
+	op3 := mockOpWithSender(testutils.ValidAddress3, 1)
+	ctx := runStorageConflicts(
+		t,
+		[]*userop.UserOperation{op1, op2, op3},
+		map[*userop.UserOperation][]common.Address{op2: {testutils.ValidAddress2}},
+	)
+
+	if len(ctx.Batch) != 1 || !testutils.IsOpsEqual(ctx.Batch[0], op1) {
+		t.Fatalf("got length %d, want 1", len(ctx.Batch))
+	} else if len(ctx.PendingRemoval) != 0 {
+		t.Fatalf("pending removal: got length %d, want 0", len(ctx.PendingRemoval))
+	}
+}
+
+// TestStorageConflictsSenderAccessedEarlier calls checks.Standalone.StorageConflicts where an included UserOp
+// accessed the storage of a later op's sender. Expect the later op to be deferred.
+func TestStorageConflictsSenderAccessedEarlier(t *testing.T) {
+	op1 := mockOpWithSender(testutils.ValidAddress2, 0)
+	op2 := mockOpWithSender(testutils.ValidAddress3, 0)
+	ctx := runStorageConflicts(
+		t,
+		[]*userop.UserOperation{op1, op2},
+		map[*userop.UserOperation][]common.Address{op1: {testutils.ValidAddress3}},
+	)
+
+	if len(ctx.Batch) != 1 || !testutils.IsOpsEqual(ctx.Batch[0], op1) {
+		t.Fatalf("got length %d, want 1", len(ctx.Batch))
+	}
+}
+
+// TestStorageConflictsSenderAsPaymaster calls checks.Standalone.StorageConflicts where the sender of one
+// UserOp is used as the paymaster of another. Expect the later op to be deferred.
+func TestStorageConflictsSenderAsPaymaster(t *testing.T) {
+	op1 := mockOpWithSender(testutils.ValidAddress2, 0)
+	op2 := mockOpWithSender(testutils.ValidAddress3, 0)
+	op2.PaymasterAndData = testutils.ValidAddress2.Bytes()
+	ctx := runStorageConflicts(t, []*userop.UserOperation{op1, op2}, nil)
+
+	if len(ctx.Batch) != 1 || !testutils.IsOpsEqual(ctx.Batch[0], op1) {
+		t.Fatalf("got length %d, want 1", len(ctx.Batch))
+	}
+}
@@ -4,15 +4,30 @@ import (
 	"context"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 )
 
 // GetCodeFunc provides a general interface for retrieving the bytecode for a given address.
 type GetCodeFunc = func(addr common.Address) ([]byte, error)
 
 // getCodeWithEthClient returns a GetCodeFunc that uses an eth client to call eth_getCode.
-func getCodeWithEthClient(eth *ethclient.Client) GetCodeFunc {
+func getCodeWithEthClient(eth backend.CodeReader) GetCodeFunc {
 	return func(addr common.Address) ([]byte, error) {
 		return eth.CodeAt(context.Background(), addr, nil)
 	}
 }
+
+// GetBlockNumberFunc provides a general interface for retrieving the latest block number.
+type GetBlockNumberFunc = func() (uint64, error)
+
+// getBlockNumberWithEthClient returns a GetBlockNumberFunc that uses an eth client to get the number of the
+// latest block header.
+func getBlockNumberWithEthClient(eth backend.HeaderReader) GetBlockNumberFunc {
+	return func() (uint64, error) {
+		head, err := eth.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return 0, err
+		}
+		return head.Number.Uint64(), nil
+	}
+}
@@ -0,0 +1,44 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestValidateSenderWithSimulatedBackend calls checks.ValidateSender with the code of a sender deployed on
+// a simulated backend. Expect an error only when initCode is also set.
+func TestValidateSenderWithSimulatedBackend(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		op.Sender: {Code: testutils.MockByteCode, Balance: big.NewInt(0)},
+	}, 30000000)
+	defer sim.Close()
+	gc := getCodeWithEthClient(sim)
+
+	if err := ValidateSender(op, gc); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	op.InitCode = []byte{}
+	if err := ValidateSender(op, gc); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
+
+// TestGetBlockNumberWithSimulatedBackend calls getBlockNumberWithEthClient after mining blocks on a simulated
+// backend. Expect the latest block number.
+func TestGetBlockNumberWithSimulatedBackend(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{}, 30000000)
+	defer sim.Close()
+	sim.Commit()
+	sim.Commit()
+
+	if bn, err := getBlockNumberWithEthClient(sim)(); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	} else if bn != 2 {
+		t.Fatalf("got block %d, want 2", bn)
+	}
+}
@@ -0,0 +1,61 @@
+package checks
+
+import (
+	"math/big"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+)
+
+var (
+	// The minimum number of seconds remaining before validUntil for a UserOperation to be accepted into the
+	// mempool.
+	minValidUntilOnAdmission = int64(30)
+
+	// The minimum number of seconds remaining before validUntil for a UserOperation to be included in a
+	// bundle. This accounts for the time it takes for the bundle transaction to be mined.
+	minValidUntilOnBundle = int64(10)
+)
+
+// validityWindow is the time range in unix seconds in which a UserOperation can be included on-chain. It is
+// the intersection of the ranges returned by the account and paymaster during validation.
+type validityWindow struct {
+	ValidAfter int64 `json:"validAfter"`
+	ValidUntil int64 `json:"validUntil"`
+}
+
+func newValidityWindow(ri *reverts.ReturnInfo, pmd *simulation.ValidationData) *validityWindow {
+	validAfter := big.NewInt(0)
+	validUntil := big.NewInt(0)
+	if ri != nil {
+		validAfter = ri.ValidAfter
+		validUntil = ri.ValidUntil
+	}
+
+	// The EntryPoint already intersects the account and paymaster ranges in ReturnInfo. The paymaster range
+	// from the trace is applied again so that the window is correct regardless of how the EntryPoint
+	// resolved an aggregator.
+	if pmd != nil {
+		if pmd.ValidAfter.Cmp(validAfter) > 0 {
+			validAfter = pmd.ValidAfter
+		}
+		if validUntil.Sign() == 0 || pmd.ValidUntil.Cmp(validUntil) < 0 {
+			validUntil = pmd.ValidUntil
+		}
+	}
+
+	return &validityWindow{
+		ValidAfter: validAfter.Int64(),
+		ValidUntil: validUntil.Int64(),
+	}
+}
+
+// isPending returns true if the UserOperation cannot be included until a later time.
+func (vw *validityWindow) isPending(now int64) bool {
+	return now < vw.ValidAfter
+}
+
+// expiresWithin returns true if the UserOperation will no longer be valid within the given number of seconds.
+func (vw *validityWindow) expiresWithin(now int64, seconds int64) bool {
+	return vw.ValidUntil != 0 && now >= vw.ValidUntil-seconds
+}
@@ -0,0 +1,105 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func packValidationData(validAfter, validUntil int64) *big.Int {
+	data := big.NewInt(0).Lsh(big.NewInt(validAfter), 208)
+	return data.Or(data, big.NewInt(0).Lsh(big.NewInt(validUntil), 160))
+}
+
+// TestValidityWindowUsesPaymasterRange calls checks.newValidityWindow where the paymaster range is narrower
+// than the ReturnInfo range. Expect the intersection.
+func TestValidityWindowUsesPaymasterRange(t *testing.T) {
+	ri := &reverts.ReturnInfo{ValidAfter: big.NewInt(100), ValidUntil: big.NewInt(1000)}
+	pmd := simulation.ParseValidationData(packValidationData(200, 500))
+	vw := newValidityWindow(ri, pmd)
+
+	if vw.ValidAfter != 200 {
+		t.Fatalf("validAfter: got %d, want 200", vw.ValidAfter)
+	} else if vw.ValidUntil != 500 {
+		t.Fatalf("validUntil: got %d, want 500", vw.ValidUntil)
+	}
+}
+
+// TestValidityWindowNoPaymaster calls checks.newValidityWindow without paymaster validation data. Expect the
+// ReturnInfo range.
+func TestValidityWindowNoPaymaster(t *testing.T) {
+	ri := &reverts.ReturnInfo{ValidAfter: big.NewInt(100), ValidUntil: big.NewInt(1000)}
+	vw := newValidityWindow(ri, nil)
+
+	if vw.ValidAfter != 100 {
+		t.Fatalf("validAfter: got %d, want 100", vw.ValidAfter)
+	} else if vw.ValidUntil != 1000 {
+		t.Fatalf("validUntil: got %d, want 1000", vw.ValidUntil)
+	}
+}
+
+// TestValidityWindowNoExpiry calls checks.validityWindow.expiresWithin with a validUntil of 0. Expect false.
+func TestValidityWindowNoExpiry(t *testing.T) {
+	vw := &validityWindow{}
+
+	if vw.expiresWithin(time.Now().Unix(), minValidUntilOnBundle) {
+		t.Fatal("got true, want false")
+	}
+}
+
+// TestValidityWindowHandler calls checks.Standalone.ValidityWindow with a batch of valid, pending, expired,
+// and unknown UserOps. Expect pending ops to be deferred and expired ops to be marked for removal.
+func TestValidityWindowHandler(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	s := &Standalone{db: db}
+	now := time.Now().Unix()
+
+	valid := testutils.MockValidInitUserOp()
+	pending := testutils.MockValidInitUserOp()
+	pending.Nonce = big.NewInt(1)
+	expired := testutils.MockValidInitUserOp()
+	expired.Nonce = big.NewInt(2)
+	unknown := testutils.MockValidInitUserOp()
+	unknown.Nonce = big.NewInt(3)
+
+	windows := map[*userop.UserOperation]*validityWindow{
+		valid:   {ValidAfter: now - 100, ValidUntil: now + 100},
+		pending: {ValidAfter: now + 100, ValidUntil: now + 200},
+		expired: {ValidAfter: 0, ValidUntil: now + minValidUntilOnBundle - 1},
+	}
+	for op, vw := range windows {
+		if err := saveValidityWindow(db, op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID), vw); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{valid, pending, expired, unknown},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := (s.ValidityWindow())(ctx); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if len(ctx.Batch) != 2 {
+		t.Fatalf("batch: got length %d, want 2", len(ctx.Batch))
+	} else if !testutils.IsOpsEqual(ctx.Batch[0], valid) || !testutils.IsOpsEqual(ctx.Batch[1], unknown) {
+		t.Fatal("batch: got incorrect ops")
+	} else if len(ctx.PendingRemoval) != 1 {
+		t.Fatalf("pending removal: got length %d, want 1", len(ctx.PendingRemoval))
+	} else if ctx.PendingRemoval[0].Op.Nonce.Cmp(common.Big2) != 0 {
+		t.Fatal("pending removal: got incorrect op")
+	}
+}
@@ -76,12 +76,25 @@ func (c *BatchHandlerCtx) MarkOpIndexForRemoval(index int, reason string) {
 	})
 }
 
+// DeferOpIndex will remove the op by index from the batch without adding it to the pending removal array.
+// This should be used for ops that cannot be included on-chain yet but should remain in the mempool.
+func (c *BatchHandlerCtx) DeferOpIndex(index int) {
+	if index < 0 || index >= len(c.Batch) {
+		return
+	}
+
+	batch := []*userop.UserOperation{}
+	batch = append(batch, c.Batch[:index]...)
+	c.Batch = append(batch, c.Batch[index+1:]...)
+}
+
 // UserOpHandlerCtx is the object passed to UserOpHandler functions during the Client's SendUserOperation
 // process.
 type UserOpHandlerCtx struct {
 	UserOp              *userop.UserOperation
 	EntryPoint          common.Address
 	ChainID             *big.Int
+	TenantID            string
 	pendingSenderOps    []*userop.UserOperation
 	pendingFactoryOps   []*userop.UserOperation
 	pendingPaymasterOps []*userop.UserOperation
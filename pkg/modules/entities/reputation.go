@@ -8,7 +8,6 @@ import (
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 )
@@ -17,13 +16,12 @@ import (
 // UserOperation.
 type Reputation struct {
 	db       *badger.DB
-	eth      *ethclient.Client
 	repConst *ReputationConstants
 }
 
 // New returns an instance of a Reputation object to track and appropriately process userOps by entity status.
-func New(db *badger.DB, eth *ethclient.Client, repConst *ReputationConstants) *Reputation {
-	return &Reputation{db, eth, repConst}
+func New(db *badger.DB, repConst *ReputationConstants) *Reputation {
+	return &Reputation{db, repConst}
 }
 
 // CheckStatus returns a UserOpHandler that is used by the Client to determine if the userOp is allowed based
@@ -4,7 +4,7 @@ import (
 	"context"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 )
 
 // GetBaseFeeFunc provides a general interface for retrieving the closest estimate for basefee to allow for
@@ -19,7 +19,7 @@ func NoopGetBaseFeeFunc() GetBaseFeeFunc {
 }
 
 // GetBaseFeeWithEthClient returns a GetBaseFeeFunc using an eth client.
-func GetBaseFeeWithEthClient(eth *ethclient.Client) GetBaseFeeFunc {
+func GetBaseFeeWithEthClient(eth backend.HeaderReader) GetBaseFeeFunc {
 	return func() (*big.Int, error) {
 		head, err := eth.HeaderByNumber(context.Background(), nil)
 		if err != nil {
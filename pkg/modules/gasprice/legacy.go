@@ -4,7 +4,7 @@ import (
 	"context"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 )
 
 // GetLegacyGasPriceFunc provides a general interface for retrieving the closest estimate for gas price to
@@ -19,7 +19,7 @@ func NoopGetLegacyGasPriceFunc() GetLegacyGasPriceFunc {
 }
 
 // GetLegacyGasPriceWithEthClient returns a GetLegacyGasPriceFunc using an eth client.
-func GetLegacyGasPriceWithEthClient(eth *ethclient.Client) GetLegacyGasPriceFunc {
+func GetLegacyGasPriceWithEthClient(eth backend.FeeReader) GetLegacyGasPriceFunc {
 	return func() (*big.Int, error) {
 		gp, err := eth.SuggestGasPrice(context.Background())
 		if err != nil {
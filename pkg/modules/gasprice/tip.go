@@ -4,7 +4,8 @@ import (
 	"context"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+	"github.com/stackup-wallet/stackup-bundler/pkg/fees"
 )
 
 // GetGasTipFunc provides a general interface for retrieving the closest estimate for gas tip to allow for
@@ -19,7 +20,7 @@ func NoopGetGasTipFunc() GetGasTipFunc {
 }
 
 // GetGasTipWithEthClient returns a GetGasTipFunc using an eth client.
-func GetGasTipWithEthClient(eth *ethclient.Client) GetGasTipFunc {
+func GetGasTipWithEthClient(eth backend.FeeReader) GetGasTipFunc {
 	return func() (*big.Int, error) {
 		gt, err := eth.SuggestGasTipCap(context.Background())
 		if err != nil {
@@ -28,3 +29,8 @@ func GetGasTipWithEthClient(eth *ethclient.Client) GetGasTipFunc {
 		return gt, nil
 	}
 }
+
+// GetGasTipWithFeeOracle returns a GetGasTipFunc using the cached fee history of a fee Oracle.
+func GetGasTipWithFeeOracle(o *fees.Oracle) GetGasTipFunc {
+	return o.GasTip
+}
@@ -0,0 +1,152 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	uint256, _      = abi.NewType("uint256", "", nil)
+	uint256Array, _ = abi.NewType("uint256[]", "", nil)
+	bytesType, _    = abi.NewType("bytes", "", nil)
+	bytesArray, _   = abi.NewType("bytes[]", "", nil)
+	address, _      = abi.NewType("address", "", nil)
+	addressArray, _ = abi.NewType("address[]", "", nil)
+
+	// ExecuteMethod is the single call method of the reference SimpleAccount.
+	ExecuteMethod = abi.NewMethod(
+		"execute",
+		"execute",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "dest", Type: address},
+			{Name: "value", Type: uint256},
+			{Name: "func", Type: bytesType},
+		},
+		nil,
+	)
+
+	// ExecuteBatchMethod is the batch call method of the reference SimpleAccount for EntryPoint v0.6.
+	ExecuteBatchMethod = abi.NewMethod(
+		"executeBatch",
+		"executeBatch",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "dest", Type: addressArray},
+			{Name: "func", Type: bytesArray},
+		},
+		nil,
+	)
+
+	// ExecuteBatchWithValueMethod is the batch call method of accounts that also pass a value for each call.
+	ExecuteBatchWithValueMethod = abi.NewMethod(
+		"executeBatch",
+		"executeBatch",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "dest", Type: addressArray},
+			{Name: "value", Type: uint256Array},
+			{Name: "func", Type: bytesArray},
+		},
+		nil,
+	)
+)
+
+// call is a single call made by an account on execution of a UserOperation.
+type call struct {
+	to    common.Address
+	value *big.Int
+	data  []byte
+}
+
+// selector returns the method selector of the call or nil if the call has no data. An error is returned if
+// the data is too short to hold a selector.
+func (c *call) selector() ([]byte, error) {
+	if len(c.data) == 0 {
+		return nil, nil
+	}
+	if len(c.data) < 4 {
+		return nil, fmt.Errorf("call data %#x on target %s is too short for a method selector", c.data, c.to)
+	}
+	return c.data[:4], nil
+}
+
+// decodeCalls returns the calls encoded in the callData of a UserOperation. Only the execute and executeBatch
+// methods of the reference SimpleAccount are supported.
+func decodeCalls(callData []byte) ([]*call, error) {
+	if len(callData) == 0 {
+		return []*call{}, nil
+	}
+	if len(callData) < 4 {
+		return nil, fmt.Errorf("callData: too short")
+	}
+
+	sel, data := callData[:4], callData[4:]
+	switch {
+	case bytes.Equal(sel, ExecuteMethod.ID):
+		args, err := ExecuteMethod.Inputs.Unpack(data)
+		if err != nil {
+			return nil, fmt.Errorf("callData: %w", err)
+		}
+		return []*call{{
+			to:    args[0].(common.Address),
+			value: args[1].(*big.Int),
+			data:  args[2].([]byte),
+		}}, nil
+
+	case bytes.Equal(sel, ExecuteBatchMethod.ID):
+		args, err := ExecuteBatchMethod.Inputs.Unpack(data)
+		if err != nil {
+			return nil, fmt.Errorf("callData: %w", err)
+		}
+		dest, funcs := args[0].([]common.Address), args[1].([][]byte)
+		if len(funcs) != 0 && len(funcs) != len(dest) {
+			return nil, fmt.Errorf("callData: wrong array lengths")
+		}
+
+		calls := []*call{}
+		for i, to := range dest {
+			c := &call{to: to, value: big.NewInt(0)}
+			if len(funcs) != 0 {
+				c.data = funcs[i]
+			}
+			calls = append(calls, c)
+		}
+		return calls, nil
+
+	case bytes.Equal(sel, ExecuteBatchWithValueMethod.ID):
+		args, err := ExecuteBatchWithValueMethod.Inputs.Unpack(data)
+		if err != nil {
+			return nil, fmt.Errorf("callData: %w", err)
+		}
+		dest, values, funcs := args[0].([]common.Address), args[1].([]*big.Int), args[2].([][]byte)
+		if len(values) != len(dest) || (len(funcs) != 0 && len(funcs) != len(dest)) {
+			return nil, fmt.Errorf("callData: wrong array lengths")
+		}
+
+		calls := []*call{}
+		for i, to := range dest {
+			c := &call{to: to, value: values[i]}
+			if len(funcs) != 0 {
+				c.data = funcs[i]
+			}
+			calls = append(calls, c)
+		}
+		return calls, nil
+	}
+
+	return nil, fmt.Errorf("callData: unsupported account method %#x", sel)
+}
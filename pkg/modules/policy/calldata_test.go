@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+var transferSelector = common.Hex2Bytes("a9059cbb")
+
+func encodeExecute(to common.Address, value *big.Int, data []byte) []byte {
+	args, _ := ExecuteMethod.Inputs.Pack(to, value, data)
+	return append(ExecuteMethod.ID, args...)
+}
+
+func encodeExecuteBatch(to []common.Address, data [][]byte) []byte {
+	args, _ := ExecuteBatchMethod.Inputs.Pack(to, data)
+	return append(ExecuteBatchMethod.ID, args...)
+}
+
+// TestDecodeCallsExecute calls decodeCalls with an execute callData. Expect a single call with the same
+// target, value, and data.
+func TestDecodeCallsExecute(t *testing.T) {
+	calls, err := decodeCalls(encodeExecute(testutils.ValidAddress1, big.NewInt(5), transferSelector))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(calls))
+	}
+	c := calls[0]
+	sel, err := c.selector()
+	if err != nil || c.to != testutils.ValidAddress1 || c.value.Cmp(big.NewInt(5)) != 0 ||
+		common.Bytes2Hex(sel) != "a9059cbb" {
+		t.Fatalf("got %+v, want execute call to %s", c, testutils.ValidAddress1)
+	}
+}
+
+// TestDecodeCallsExecuteBatch calls decodeCalls with an executeBatch callData. Expect a call for each target
+// with no value.
+func TestDecodeCallsExecuteBatch(t *testing.T) {
+	calls, err := decodeCalls(encodeExecuteBatch(
+		[]common.Address{testutils.ValidAddress1, testutils.ValidAddress2},
+		[][]byte{transferSelector, {}},
+	))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if len(calls) != 2 || calls[1].to != testutils.ValidAddress2 {
+		t.Fatalf("got %+v, want 2 calls", calls)
+	}
+	if sel, err := calls[1].selector(); sel != nil || err != nil {
+		t.Fatalf("got selector %x and err %v, want nil", sel, err)
+	}
+	for _, c := range calls {
+		if c.value.Sign() != 0 {
+			t.Fatalf("got value %s, want 0", c.value)
+		}
+	}
+}
+
+// TestDecodeCallsUnsupported calls decodeCalls with an unknown account method. Expect an error.
+func TestDecodeCallsUnsupported(t *testing.T) {
+	if _, err := decodeCalls(testutils.MockValidInitUserOp().CallData); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestCallSelectorTooShort calls selector on a call with less than 4 bytes of data. Expect an error.
+func TestCallSelectorTooShort(t *testing.T) {
+	c := &call{to: testutils.ValidAddress1, data: []byte{0x01, 0x02}}
+	if sel, err := c.selector(); sel != nil || err == nil {
+		t.Fatalf("got selector %x and err %v, want error", sel, err)
+	}
+}
@@ -0,0 +1,120 @@
+// Package policy implements a client module to accept UserOperations based on allow and deny rules for their
+// entities, calls, and value.
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+// Policy provides a Client module that rejects UserOperations not allowed by its rules. Rules are loaded from
+// a file and can be reloaded while the bundler is running.
+type Policy struct {
+	path   string
+	rules  atomic.Pointer[Rules]
+	logger logr.Logger
+}
+
+// New returns a Policy with rules loaded from the file at path.
+func New(path string) (*Policy, error) {
+	p := &Policy{path: path, logger: logr.Discard()}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UseLogger defines the logger object used by the Policy to report reloads.
+func (p *Policy) UseLogger(logger logr.Logger) {
+	p.logger = logger.WithName("policy")
+}
+
+// Rules returns the rules currently in use.
+func (p *Policy) Rules() *Rules {
+	return p.rules.Load()
+}
+
+// Reload reads the rules file again. If the file is invalid, the previous rules are kept and an error is
+// returned.
+func (p *Policy) Reload() error {
+	r, err := LoadRules(p.path)
+	if err != nil {
+		return err
+	}
+	p.rules.Store(r)
+	return nil
+}
+
+// Watch reloads the rules whenever the file changes.
+func (p *Policy) Watch() {
+	v := viper.New()
+	v.SetConfigFile(p.path)
+	v.OnConfigChange(func(e fsnotify.Event) {
+		if err := p.Reload(); err != nil {
+			p.logger.Error(err, "policy reload failed, keeping previous rules")
+			return
+		}
+		p.logger.Info("policy reloaded", "file", p.path)
+	})
+	v.WatchConfig()
+}
+
+func reject(format string, a ...any) error {
+	return errors.NewRPCError(errors.UNAUTHORIZED, "policy: "+fmt.Sprintf(format, a...), nil)
+}
+
+// CheckUserOp returns a UserOpHandler that is used by the Client to reject UserOperations that do not pass
+// the sender, factory, and paymaster lists. If any call rules are set, the callData is decoded and each call
+// must pass the target and selector lists. Calls without data, such as plain transfers, are not checked
+// against the selector lists. Calls with data too short to hold a selector are rejected if the selector
+// allowlist is set. The total value of all calls must not exceed the max value.
+func (p *Policy) CheckUserOp() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		r := p.rules.Load()
+		op := ctx.UserOp
+		if !r.Senders.IsAllowed(op.Sender) {
+			return reject("sender %s is not allowed", op.Sender)
+		}
+		if factory := op.GetFactory(); factory != common.HexToAddress("0x") && !r.Factories.IsAllowed(factory) {
+			return reject("factory %s is not allowed", factory)
+		}
+		if pm := op.GetPaymaster(); pm != common.HexToAddress("0x") && !r.Paymasters.IsAllowed(pm) {
+			return reject("paymaster %s is not allowed", pm)
+		}
+		if !r.usesCallData() {
+			return nil
+		}
+
+		calls, err := decodeCalls(op.CallData)
+		if err != nil {
+			return reject("%s", err)
+		}
+		total := big.NewInt(0)
+		for _, c := range calls {
+			if !r.Targets.IsAllowed(c.to) {
+				return reject("target %s is not allowed", c.to)
+			}
+			sel, err := c.selector()
+			if err != nil && r.Selectors.Allow.Cardinality() != 0 {
+				return reject("%s", err)
+			}
+			if sel != nil && !r.Selectors.IsAllowed(hexutil.Encode(sel)) {
+				return reject("method %s on target %s is not allowed", hexutil.Encode(sel), c.to)
+			}
+			total.Add(total, c.value)
+		}
+		if r.MaxValue != nil && total.Cmp(r.MaxValue) > 0 {
+			return reject("value of %s exceeds max of %s", total, r.MaxValue)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func writeRules(t *testing.T, path, data string) string {
+	t.Helper()
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "policy.yaml")
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newPolicy(t *testing.T, data string) *Policy {
+	t.Helper()
+	p, err := New(writeRules(t, "", data))
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	return p
+}
+
+func newOp(callData []byte) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.InitCode = []byte{}
+	op.CallData = callData
+	return op
+}
+
+func check(p *Policy, op *userop.UserOperation) error {
+	return p.CheckUserOp()(&modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress5})
+}
+
+// TestCheckUserOpSenderLists calls Policy.CheckUserOp with denied and allowed senders. Expect only the
+// denied sender to be rejected.
+func TestCheckUserOpSenderLists(t *testing.T) {
+	p := newPolicy(t, "senders:\n  deny: [\""+testutils.ValidAddress1.Hex()+"\"]\n")
+
+	op := newOp(nil)
+	if err := check(p, op); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	op.Sender = testutils.ValidAddress1
+	if err := check(p, op); err == nil || !strings.Contains(err.Error(), "sender") {
+		t.Fatalf("got %v, want sender error", err)
+	}
+}
+
+// TestCheckUserOpFactoryAllowlist calls Policy.CheckUserOp with a factory that is not on the allowlist.
+// Expect an error only when the UserOperation uses the factory.
+func TestCheckUserOpFactoryAllowlist(t *testing.T) {
+	p := newPolicy(t, "factories:\n  allow: [\""+testutils.ValidAddress1.Hex()+"\"]\n")
+
+	if err := check(p, newOp(nil)); err != nil {
+		t.Fatalf("no factory: got err %v, want nil", err)
+	}
+	if err := check(p, testutils.MockValidInitUserOp()); err == nil || !strings.Contains(err.Error(), "factory") {
+		t.Fatalf("got %v, want factory error", err)
+	}
+}
+
+// TestCheckUserOpCalls calls Policy.CheckUserOp with target, selector, and value rules. Expect each rule to
+// be enforced on the decoded callData.
+func TestCheckUserOpCalls(t *testing.T) {
+	p := newPolicy(t, `
+targets:
+  allow: ["`+testutils.ValidAddress1.Hex()+`", "`+testutils.ValidAddress2.Hex()+`"]
+selectors:
+  deny: ["0x095ea7b3"]
+max_value: 100
+`)
+	approve := common.Hex2Bytes("095ea7b3")
+
+	cases := map[string]struct {
+		callData []byte
+		err      string
+	}{
+		"allowed": {encodeExecute(testutils.ValidAddress1, big.NewInt(100), transferSelector), ""},
+		"target":  {encodeExecute(testutils.ValidAddress3, big.NewInt(0), transferSelector), "target"},
+		"selector": {
+			encodeExecuteBatch([]common.Address{testutils.ValidAddress1, testutils.ValidAddress2}, [][]byte{{}, approve}),
+			"method 0x095ea7b3",
+		},
+		"value":       {encodeExecute(testutils.ValidAddress1, big.NewInt(101), nil), "exceeds max"},
+		"unsupported": {testutils.MockValidInitUserOp().CallData, "unsupported account method"},
+		"empty":       {[]byte{}, ""},
+	}
+	for name, c := range cases {
+		err := check(p, newOp(c.callData))
+		if c.err == "" && err != nil {
+			t.Errorf("%s: got err %v, want nil", name, err)
+		} else if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: got %v, want error containing %q", name, err, c.err)
+		}
+	}
+}
+
+// TestReload calls Policy.Reload after the rules file changes. Expect the new rules to apply and an invalid
+// file to keep the previous rules.
+func TestReload(t *testing.T) {
+	path := writeRules(t, "", "senders:\n  deny: [\""+testutils.ValidAddress1.Hex()+"\"]\n")
+	p, err := New(path)
+	if err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	op := newOp(nil)
+	op.Sender = testutils.ValidAddress1
+	if err := check(p, op); err == nil {
+		t.Fatal("got nil, want sender error")
+	}
+
+	writeRules(t, path, "senders:\n  deny: [\""+testutils.ValidAddress2.Hex()+"\"]\n")
+	if err := p.Reload(); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if err := check(p, op); err != nil {
+		t.Fatalf("after reload: got err %v, want nil", err)
+	}
+
+	writeRules(t, path, "senders:\n  deny: [\"0x1\"]\n")
+	if err := p.Reload(); err == nil {
+		t.Fatal("invalid file: got nil, want err")
+	}
+	if !p.Rules().Senders.Deny.Contains(testutils.ValidAddress2) {
+		t.Fatal("got rules replaced, want previous rules kept")
+	}
+}
+
+// TestLoadRulesInvalid calls LoadRules with invalid files. Expect an error for each.
+func TestLoadRulesInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown key":      "foo: bar\n",
+		"unknown list":     "entrypoints:\n  allow: [\"" + testutils.ValidAddress1.Hex() + "\"]\n",
+		"unknown kind":     "senders:\n  block: [\"" + testutils.ValidAddress1.Hex() + "\"]\n",
+		"invalid selector": "selectors:\n  allow: [\"0x1234\"]\n",
+		"invalid value":    "max_value: -1\n",
+	} {
+		if _, err := LoadRules(writeRules(t, "", data)); err == nil {
+			t.Errorf("%s: got nil, want err", name)
+		}
+	}
+}
+
+// TestCheckUserOpShortCallData calls Policy.CheckUserOp with call data too short to hold a selector. Expect
+// an error only if the selector allowlist is set.
+func TestCheckUserOpShortCallData(t *testing.T) {
+	op := newOp(encodeExecute(testutils.ValidAddress1, big.NewInt(0), []byte{0xa9, 0x05}))
+
+	deny := newPolicy(t, "selectors:\n  deny: [\"0x095ea7b3\"]\n")
+	if err := check(deny, op); err != nil {
+		t.Fatalf("denylist: got err %v, want nil", err)
+	}
+	allow := newPolicy(t, "selectors:\n  allow: [\"0xa9059cbb\"]\n")
+	if err := check(allow, op); err == nil || !strings.Contains(err.Error(), "too short") {
+		t.Fatalf("allowlist: got %v, want too short error", err)
+	}
+}
@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/viper"
+)
+
+// List is a pair of allow and deny lists. A value is allowed if it is not denied and either the allowlist is
+// empty or contains the value.
+type List[T comparable] struct {
+	Allow mapset.Set[T]
+	Deny  mapset.Set[T]
+}
+
+func newList[T comparable]() List[T] {
+	return List[T]{Allow: mapset.NewSet[T](), Deny: mapset.NewSet[T]()}
+}
+
+// IsAllowed returns true if the value passes both lists.
+func (l List[T]) IsAllowed(v T) bool {
+	if l.Deny.Contains(v) {
+		return false
+	}
+	return l.Allow.Cardinality() == 0 || l.Allow.Contains(v)
+}
+
+// IsEmpty returns true if neither list has any values.
+func (l List[T]) IsEmpty() bool {
+	return l.Allow.Cardinality() == 0 && l.Deny.Cardinality() == 0
+}
+
+// Rules define which UserOperations are accepted by a Policy. Selectors are hex encoded with a 0x prefix in
+// lowercase. A nil MaxValue means there is no limit on the value sent by a UserOperation.
+type Rules struct {
+	Senders    List[common.Address]
+	Factories  List[common.Address]
+	Paymasters List[common.Address]
+	Targets    List[common.Address]
+	Selectors  List[string]
+	MaxValue   *big.Int
+}
+
+// NewRules returns Rules that accept any UserOperation.
+func NewRules() *Rules {
+	return &Rules{
+		Senders:    newList[common.Address](),
+		Factories:  newList[common.Address](),
+		Paymasters: newList[common.Address](),
+		Targets:    newList[common.Address](),
+		Selectors:  newList[string](),
+	}
+}
+
+// usesCallData returns true if any rule requires decoding the callData of a UserOperation.
+func (r *Rules) usesCallData() bool {
+	return !r.Targets.IsEmpty() || !r.Selectors.IsEmpty() || r.MaxValue != nil
+}
+
+func toStrings(val any) []string {
+	var out []string
+	switch v := val.(type) {
+	case []any:
+		for _, s := range v {
+			out = append(out, strings.TrimSpace(fmt.Sprint(s)))
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			out = append(out, strings.TrimSpace(s))
+		}
+	}
+	return out
+}
+
+func parseAddresses(set mapset.Set[common.Address], val any) error {
+	for _, s := range toStrings(val) {
+		if !common.IsHexAddress(s) {
+			return fmt.Errorf("invalid address %s", s)
+		}
+		set.Add(common.HexToAddress(s))
+	}
+	return nil
+}
+
+func parseSelectors(set mapset.Set[string], val any) error {
+	for _, s := range toStrings(val) {
+		b, err := hexutil.Decode(s)
+		if err != nil || len(b) != 4 {
+			return fmt.Errorf("invalid selector %s", s)
+		}
+		set.Add(hexutil.Encode(b))
+	}
+	return nil
+}
+
+// LoadRules reads and validates a YAML, TOML, or JSON file of policy rules. The format is inferred from the
+// file extension. Each list has an optional allow and deny key and max_value is in wei:
+//
+//	senders:
+//	  deny: ["0x..."]
+//	factories:
+//	  allow: ["0x..."]
+//	paymasters:
+//	  allow: ["0x..."]
+//	targets:
+//	  allow: ["0x..."]
+//	selectors:
+//	  deny: ["0x095ea7b3"]
+//	max_value: "1000000000000000000"
+func LoadRules(path string) (*Rules, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	r := NewRules()
+	addrLists := map[string]List[common.Address]{
+		"senders":    r.Senders,
+		"factories":  r.Factories,
+		"paymasters": r.Paymasters,
+		"targets":    r.Targets,
+	}
+	for _, key := range v.AllKeys() {
+		var err error
+		name, kind, _ := strings.Cut(key, ".")
+		switch {
+		case key == "max_value":
+			max, ok := new(big.Int).SetString(fmt.Sprint(v.Get(key)), 0)
+			if !ok || max.Sign() < 0 {
+				err = fmt.Errorf("invalid value %v", v.Get(key))
+			}
+			r.MaxValue = max
+		case name == "selectors" && kind == "allow":
+			err = parseSelectors(r.Selectors.Allow, v.Get(key))
+		case name == "selectors" && kind == "deny":
+			err = parseSelectors(r.Selectors.Deny, v.Get(key))
+		case addrLists[name].Allow != nil && kind == "allow":
+			err = parseAddresses(addrLists[name].Allow, v.Get(key))
+		case addrLists[name].Deny != nil && kind == "deny":
+			err = parseAddresses(addrLists[name].Deny, v.Get(key))
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("policy: %s: %w", key, err)
+		}
+	}
+
+	return r, nil
+}
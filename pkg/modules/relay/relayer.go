@@ -7,8 +7,8 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
@@ -25,17 +25,18 @@ import (
 // relay the same ops.
 type Relayer struct {
 	eoa         *signer.EOA
-	eth         *ethclient.Client
+	eth         backend.TxSender
 	chainID     *big.Int
 	beneficiary common.Address
 	logger      logr.Logger
 	waitTimeout time.Duration
+	feeStrategy transaction.FeeStrategy
 }
 
 // New initializes a new EOA relayer for sending batches to the EntryPoint.
 func New(
 	eoa *signer.EOA,
-	eth *ethclient.Client,
+	eth backend.TxSender,
 	chainID *big.Int,
 	beneficiary common.Address,
 	l logr.Logger,
@@ -47,6 +48,7 @@ func New(
 		beneficiary: beneficiary,
 		logger:      l.WithName("relayer"),
 		waitTimeout: DefaultWaitTimeout,
+		feeStrategy: transaction.MeanFeeStrategy(),
 	}
 }
 
@@ -59,6 +61,12 @@ func (r *Relayer) SetWaitTimeout(timeout time.Duration) {
 	r.waitTimeout = timeout
 }
 
+// SetFeeStrategy sets the strategy used to determine the fees of the transaction that submits a batch. The
+// default strategy is transaction.MeanFeeStrategy.
+func (r *Relayer) SetFeeStrategy(fs transaction.FeeStrategy) {
+	r.feeStrategy = fs
+}
+
 // SendUserOperation returns a BatchHandler that is used by the Bundler to send batches in a regular EOA
 // transaction.
 func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
@@ -75,6 +83,7 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 			GasPrice:    ctx.GasPrice,
 			GasLimit:    0,
 			WaitTimeout: r.waitTimeout,
+			FeeStrategy: r.feeStrategy,
 		}
 		// Estimate gas for handleOps() and drop all userOps that cause unexpected reverts.
 		for len(ctx.Batch) > 0 {
@@ -0,0 +1,82 @@
+package relay
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var simulatedChainID = big.NewInt(1337)
+
+// newSimulatedBackend returns a simulated chain with a funded bundler EOA and a stub EntryPoint that accepts
+// any call.
+func newSimulatedBackend(t *testing.T) *backends.SimulatedBackend {
+	t.Helper()
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		testutils.DummyEOA.Address: {Balance: big.NewInt(0).Mul(testutils.OneETH, big.NewInt(100))},
+		testutils.ValidAddress5:    {Code: []byte{0x00}, Balance: big.NewInt(0)},
+	}, 30000000)
+	t.Cleanup(func() { _ = sim.Close() })
+	return sim
+}
+
+// rpcBackend wraps a simulated backend to estimate gas the way ethclient does over RPC, where only the gas
+// price of a call is sent to the node.
+type rpcBackend struct {
+	*backends.SimulatedBackend
+}
+
+func (b rpcBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	msg.GasFeeCap, msg.GasTipCap = nil, nil
+	return b.SimulatedBackend.EstimateGas(ctx, msg)
+}
+
+// TestSendUserOperationWithSimulatedBackend calls Relayer.SendUserOperation against a simulated backend.
+// Expect a successful handleOps transaction from the bundler EOA to the EntryPoint.
+func TestSendUserOperationWithSimulatedBackend(t *testing.T) {
+	sim := newSimulatedBackend(t)
+	head, err := sim.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(testutils.DummyEOA, rpcBackend{sim}, simulatedChainID, testutils.DummyEOA.Address, logr.Discard())
+	r.SetWaitTimeout(0)
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{testutils.MockValidInitUserOp()},
+		testutils.ValidAddress5,
+		simulatedChainID,
+		head.BaseFee,
+		big.NewInt(1),
+		nil,
+	)
+	if err := r.SendUserOperation()(ctx); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	sim.Commit()
+
+	hash := common.HexToHash(ctx.Data["txn_hash"].(string))
+	receipt, err := sim.TransactionReceipt(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("got err %v, want receipt", err)
+	} else if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("got status %d, want success", receipt.Status)
+	}
+
+	txn, _, err := sim.TransactionByHash(context.Background(), hash)
+	if err != nil {
+		t.Fatal(err)
+	} else if *txn.To() != testutils.ValidAddress5 {
+		t.Fatalf("got txn to %s, want EntryPoint %s", txn.To(), testutils.ValidAddress5)
+	}
+}
@@ -0,0 +1,40 @@
+package gaspriceoracle
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// L1FeeParamsCache fetches L1FeeParams from the Gas Price Oracle at most once per block. This includes
+// detecting the active fork.
+type L1FeeParamsCache struct {
+	client *rpc.Client
+	mu     sync.Mutex
+	block  *big.Int
+	params *L1FeeParams
+}
+
+// NewL1FeeParamsCache returns an empty L1FeeParamsCache.
+func NewL1FeeParamsCache(client *rpc.Client) *L1FeeParamsCache {
+	return &L1FeeParamsCache{client: client}
+}
+
+// Get returns the L1FeeParams at the given block. The Gas Price Oracle is only called if the block is
+// different from the previous call.
+func (c *L1FeeParamsCache) Get(block *big.Int) (*L1FeeParams, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.params != nil && c.block.Cmp(block) == 0 {
+		return c.params, nil
+	}
+	params, err := GetL1FeeParams(c.client, block)
+	if err != nil {
+		return nil, err
+	}
+	c.block = new(big.Int).Set(block)
+	c.params = params
+	return params, nil
+}
@@ -0,0 +1,196 @@
+package gaspriceoracle
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Fork is an OP Stack network upgrade that changes how the L1 data fee of a transaction is calculated.
+type Fork int
+
+const (
+	Bedrock Fork = iota
+	Ecotone
+	Fjord
+)
+
+func (f Fork) String() string {
+	switch f {
+	case Ecotone:
+		return "ecotone"
+	case Fjord:
+		return "fjord"
+	default:
+		return "bedrock"
+	}
+}
+
+var (
+	decimals           = big.NewInt(1_000_000)
+	calldataGasPerByte = big.NewInt(16)
+
+	// Fjord linear regression constants for estimating the L1 size of a transaction from its FastLZ
+	// compressed size. Values are scaled by 1e6.
+	fjordCostIntercept   = big.NewInt(-42_585_600)
+	fjordCostFastlzCoef  = big.NewInt(836_500)
+	fjordMinTxSizeScaled = big.NewInt(100 * 1_000_000)
+	fjordCostDivisor     = big.NewInt(1_000_000_000_000)
+)
+
+// L1FeeParams are the values from the Gas Price Oracle required to calculate the L1 data fee of a
+// transaction for the active fork.
+type L1FeeParams struct {
+	Fork      Fork
+	L1BaseFee *big.Int
+
+	// Ecotone and later.
+	BlobBaseFee       *big.Int
+	BaseFeeScalar     *big.Int
+	BlobBaseFeeScalar *big.Int
+
+	// Bedrock only.
+	Overhead *big.Int
+	Scalar   *big.Int
+}
+
+func newCallElem(method abi.Method, block *big.Int) rpc.BatchElem {
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []any{
+			map[string]any{
+				"to":   PrecompileAddress,
+				"data": hexutil.Encode(method.ID),
+			},
+			hexutil.EncodeBig(block),
+		},
+		Result: new(string),
+	}
+}
+
+// isForkActive returns true if the fork check method returned true. Methods for future forks revert on
+// networks that have not been upgraded, so only an execution revert means that the fork is not active. Any
+// other error is returned.
+func isForkActive(elem rpc.BatchElem, method abi.Method) (bool, error) {
+	if elem.Error != nil {
+		if strings.Contains(elem.Error.Error(), "execution reverted") {
+			return false, nil
+		}
+		return false, elem.Error
+	}
+	return DecodeBoolMethodOutput(method, *elem.Result.(*string))
+}
+
+// GetL1FeeParams detects the active fork and fetches the parameters required to calculate the L1 data fee
+// from the Gas Price Oracle at the given block.
+func GetL1FeeParams(client *rpc.Client, block *big.Int) (*L1FeeParams, error) {
+	forks := []rpc.BatchElem{newCallElem(IsFjordMethod, block), newCallElem(IsEcotoneMethod, block)}
+	if err := client.BatchCall(forks); err != nil {
+		return nil, err
+	}
+	isFjord, err := isForkActive(forks[0], IsFjordMethod)
+	if err != nil {
+		return nil, err
+	}
+	isEcotone, err := isForkActive(forks[1], IsEcotoneMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &L1FeeParams{Fork: Bedrock}
+	if isFjord {
+		params.Fork = Fjord
+	} else if isEcotone {
+		params.Fork = Ecotone
+	}
+
+	type field struct {
+		method abi.Method
+		dst    **big.Int
+	}
+	fields := []field{{L1BaseFeeMethod, &params.L1BaseFee}}
+	if params.Fork == Bedrock {
+		fields = append(fields, field{OverheadMethod, &params.Overhead}, field{ScalarMethod, &params.Scalar})
+	} else {
+		fields = append(
+			fields,
+			field{BlobBaseFeeMethod, &params.BlobBaseFee},
+			field{BaseFeeScalarMethod, &params.BaseFeeScalar},
+			field{BlobBaseFeeScalarMethod, &params.BlobBaseFeeScalar},
+		)
+	}
+
+	elems := []rpc.BatchElem{}
+	for _, f := range fields {
+		elems = append(elems, newCallElem(f.method, block))
+	}
+	if err := client.BatchCall(elems); err != nil {
+		return nil, err
+	}
+	for i, f := range fields {
+		if elems[i].Error != nil {
+			return nil, elems[i].Error
+		}
+		v, err := DecodeUintMethodOutput(f.method, *elems[i].Result.(*string))
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = v
+	}
+
+	return params, nil
+}
+
+// CalldataGas returns the L1 gas cost of the given data with 4 gas for every zero byte and 16 gas for every
+// non-zero byte.
+func CalldataGas(data []byte) *big.Int {
+	gas := uint64(0)
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	return big.NewInt(0).SetUint64(gas)
+}
+
+// L1Fee returns the L1 data fee in wei for a signed and RLP encoded transaction.
+func (p *L1FeeParams) L1Fee(tx []byte) *big.Int {
+	switch p.Fork {
+	case Fjord:
+		// feeScaled = baseFeeScalar * 16 * l1BaseFee + blobBaseFeeScalar * blobBaseFee
+		feeScaled := big.NewInt(0).Mul(p.BaseFeeScalar, calldataGasPerByte)
+		feeScaled.Mul(feeScaled, p.L1BaseFee)
+		feeScaled.Add(feeScaled, big.NewInt(0).Mul(p.BlobBaseFeeScalar, p.BlobBaseFee))
+
+		// size = max(minTxSize, intercept + fastlzCoef * fastlzSize)
+		size := big.NewInt(0).Mul(fjordCostFastlzCoef, big.NewInt(int64(FlzCompressLen(tx))))
+		size.Add(size, fjordCostIntercept)
+		if size.Cmp(fjordMinTxSizeScaled) < 0 {
+			size = big.NewInt(0).Set(fjordMinTxSizeScaled)
+		}
+
+		fee := big.NewInt(0).Mul(size, feeScaled)
+		return fee.Div(fee, fjordCostDivisor)
+
+	case Ecotone:
+		// fee = calldataGas * (16 * l1BaseFee * baseFeeScalar + blobBaseFee * blobBaseFeeScalar) / (16 * 1e6)
+		scaled := big.NewInt(0).Mul(calldataGasPerByte, p.L1BaseFee)
+		scaled.Mul(scaled, p.BaseFeeScalar)
+		scaled.Add(scaled, big.NewInt(0).Mul(p.BlobBaseFee, p.BlobBaseFeeScalar))
+
+		fee := big.NewInt(0).Mul(CalldataGas(tx), scaled)
+		return fee.Div(fee, big.NewInt(0).Mul(calldataGasPerByte, decimals))
+
+	default:
+		// fee = (calldataGas + overhead) * l1BaseFee * scalar / 1e6
+		fee := big.NewInt(0).Add(CalldataGas(tx), p.Overhead)
+		fee.Mul(fee, p.L1BaseFee)
+		fee.Mul(fee, p.Scalar)
+		return fee.Div(fee, decimals)
+	}
+}
@@ -0,0 +1,291 @@
+package gaspriceoracle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+var oracleMethods = []abi.Method{
+	IsEcotoneMethod, IsFjordMethod, L1BaseFeeMethod, BlobBaseFeeMethod,
+	BaseFeeScalarMethod, BlobBaseFeeScalarMethod, OverheadMethod, ScalarMethod,
+}
+
+// fixture holds the eth_call results of the Gas Price Oracle view methods at a block of a chain. Methods that are not
+// part of the active fork revert and are omitted. Errors are node errors returned for a method instead of a
+// result and are only set by tests.
+type fixture struct {
+	Fork      string            `json:"fork"`
+	ChainID   *hexutil.Big      `json:"chainId"`
+	Block     *hexutil.Big      `json:"block"`
+	Responses map[string]string `json:"responses"`
+	Errors    map[string]string `json:"-"`
+}
+
+func readFixture(t *testing.T, fork string) *fixture {
+	t.Helper()
+	var f fixture
+	testutils.ReadFixture(t, fork, &f)
+	return &f
+}
+
+// oracleMock returns a client for a node that serves the fixture responses at the fixture block. The number
+// of HTTP requests made is counted in requests.
+func oracleMock(t *testing.T, f *fixture, requests *int64) *rpc.Client {
+	t.Helper()
+	methods := map[string]abi.Method{}
+	for _, m := range oracleMethods {
+		methods[hexutil.Encode(m.ID)] = m
+	}
+
+	type req struct {
+		ID     json.RawMessage `json:"id"`
+		Params []json.RawMessage
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(requests, 1)
+		var batch []req
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			panic(err)
+		}
+
+		res := []map[string]any{}
+		for _, b := range batch {
+			var call struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(b.Params[0], &call); err != nil {
+				panic(err)
+			}
+			var block hexutil.Big
+			if err := json.Unmarshal(b.Params[1], &block); err != nil {
+				panic(err)
+			}
+
+			out := map[string]any{"jsonrpc": "2.0", "id": b.ID}
+			if msg, ok := f.Errors[methods[call.Data].Name]; ok {
+				out["error"] = map[string]any{"code": -32000, "message": msg}
+			} else if v, ok := f.Responses[methods[call.Data].Name]; ok && block.ToInt().Cmp(f.Block.ToInt()) == 0 {
+				out["result"] = v
+			} else {
+				out["error"] = map[string]any{"code": -32000, "message": "execution reverted"}
+			}
+			res = append(res, out)
+		}
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := rpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// TestRecordFixture saves the Gas Price Oracle responses of the node given with -record-rpc at -record-block.
+// The fixture is named after the active fork. See testdata/README.md for the recorded blocks.
+func TestRecordFixture(t *testing.T) {
+	testutils.SkipUnlessRecording(t)
+	c := testutils.DialRecordRpc(t)
+	eth := ethclient.NewClient(c)
+	chainID, err := eth.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := eth.HeaderByNumber(context.Background(), testutils.RecordBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{
+		ChainID:   (*hexutil.Big)(chainID),
+		Block:     (*hexutil.Big)(head.Number),
+		Responses: map[string]string{},
+	}
+	elems := []rpc.BatchElem{}
+	for _, m := range oracleMethods {
+		elems = append(elems, newCallElem(m, head.Number))
+	}
+	if err := c.BatchCall(elems); err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range oracleMethods {
+		if elems[i].Error == nil {
+			f.Responses[m.Name] = *elems[i].Result.(*string)
+		}
+	}
+	params, err := GetL1FeeParams(c, head.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Fork = params.Fork.String()
+	testutils.WriteFixture(t, f.Fork, f)
+}
+
+// TestGetL1FeeParams calls GetL1FeeParams against the fixture for each fork. Expect the active fork to be
+// detected and the params for that fork to be decoded from the oracle responses.
+func TestGetL1FeeParams(t *testing.T) {
+	for _, fork := range []Fork{Bedrock, Ecotone, Fjord} {
+		t.Run(fork.String(), func(t *testing.T) {
+			var requests int64
+			f := readFixture(t, fork.String())
+			params, err := GetL1FeeParams(oracleMock(t, f, &requests), f.Block.ToInt())
+			if err != nil {
+				t.Fatalf("got err %v, want nil", err)
+			}
+			if params.Fork != fork {
+				t.Fatalf("got fork %s, want %s", params.Fork, fork)
+			}
+
+			fields := map[string]*big.Int{"l1BaseFee": params.L1BaseFee}
+			if fork == Bedrock {
+				fields["overhead"] = params.Overhead
+				fields["scalar"] = params.Scalar
+			} else {
+				fields["blobBaseFee"] = params.BlobBaseFee
+				fields["baseFeeScalar"] = params.BaseFeeScalar
+				fields["blobBaseFeeScalar"] = params.BlobBaseFeeScalar
+			}
+			for name, got := range fields {
+				want := new(big.Int).SetBytes(common.FromHex(f.Responses[name]))
+				if got == nil || got.Cmp(want) != 0 {
+					t.Fatalf("%s: got %v, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestGetL1FeeParamsNodeError calls GetL1FeeParams on a Fjord node that fails the isFjord call with an
+// error other than a revert. Expect the error instead of falling back to an earlier fork.
+func TestGetL1FeeParamsNodeError(t *testing.T) {
+	var requests int64
+	f := readFixture(t, Fjord.String())
+	f.Errors = map[string]string{"isFjord": "request timed out"}
+
+	params, err := GetL1FeeParams(oracleMock(t, f, &requests), f.Block.ToInt())
+	if err == nil || err.Error() != "request timed out" {
+		t.Fatalf("got params %v and err %v, want request timed out", params, err)
+	}
+}
+
+// TestL1FeeParamsCache calls L1FeeParamsCache.Get twice for the same block and once for the next block.
+// Expect the oracle to only be called again for the new block.
+func TestL1FeeParamsCache(t *testing.T) {
+	var requests int64
+	f := readFixture(t, Fjord.String())
+	cache := NewL1FeeParamsCache(oracleMock(t, f, &requests))
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Get(f.Block.ToInt()); err != nil {
+			t.Fatalf("got err %v, want nil", err)
+		}
+	}
+	if n := atomic.LoadInt64(&requests); n != 2 {
+		t.Fatalf("got %d requests, want 2", n)
+	}
+
+	// The mock reverts for any other block so the fetch is expected to fail.
+	next := new(big.Int).Add(f.Block.ToInt(), common.Big1)
+	if _, err := cache.Get(next); err == nil {
+		t.Fatal("got nil, want err")
+	}
+	if n := atomic.LoadInt64(&requests); n != 4 {
+		t.Fatalf("got %d requests, want 4", n)
+	}
+}
+
+// TestL1Fee verifies that the L1 fee is calculated with the formula of each fork.
+func TestL1Fee(t *testing.T) {
+	tx := bytes.Repeat([]byte{1}, 100)
+	ecotone := &L1FeeParams{
+		Fork:              Ecotone,
+		L1BaseFee:         big.NewInt(10_000_000_000),
+		BlobBaseFee:       big.NewInt(1),
+		BaseFeeScalar:     big.NewInt(1368),
+		BlobBaseFeeScalar: big.NewInt(810_949),
+	}
+	fjord := *ecotone
+	fjord.Fork = Fjord
+	tests := []struct {
+		name   string
+		params *L1FeeParams
+		fee    int64
+	}{
+		// (1600 + 188) * 10 gwei * 684000 / 1e6
+		{
+			"bedrock",
+			&L1FeeParams{
+				Fork:      Bedrock,
+				L1BaseFee: big.NewInt(10_000_000_000),
+				Overhead:  big.NewInt(188),
+				Scalar:    big.NewInt(684_000),
+			},
+			12_229_920_000_000,
+		},
+		// 1600 * (16 * 10 gwei * 1368 + 1 * 810949) / (16 * 1e6)
+		{"ecotone", ecotone, 21_888_000_081},
+		// Minimum size of 100 bytes: 100e6 * (1368 * 16 * 10 gwei + 810949 * 1) / 1e12
+		{"fjord", &fjord, 21_888_000_081},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if fee := tc.params.L1Fee(tx); fee.Cmp(big.NewInt(tc.fee)) != 0 {
+				t.Fatalf("got fee %s, want %d", fee, tc.fee)
+			}
+		})
+	}
+}
+
+// TestFjordL1FeeScalesWithCompressedSize verifies that the Fjord L1 fee is based on the compressed size of
+// the transaction rather than its calldata gas.
+func TestFjordL1FeeScalesWithCompressedSize(t *testing.T) {
+	params := &L1FeeParams{
+		Fork:              Fjord,
+		L1BaseFee:         big.NewInt(10_000_000_000),
+		BlobBaseFee:       big.NewInt(1),
+		BaseFeeScalar:     big.NewInt(1368),
+		BlobBaseFeeScalar: big.NewInt(810_949),
+	}
+	random := []byte{}
+	for seed := []byte{0}; len(random) < 1000; {
+		seed = crypto.Keccak256(seed)
+		random = append(random, seed...)
+	}
+	random = random[:1000]
+	repeated := bytes.Repeat([]byte{1}, 1000)
+
+	if r, c := params.L1Fee(random), params.L1Fee(repeated); r.Cmp(c) != 1 {
+		t.Fatalf("got random fee %s and repeated fee %s, want random fee to be higher", r, c)
+	}
+}
+
+// TestFlzCompressLen verifies the compressed length for literal only and repetitive inputs.
+func TestFlzCompressLen(t *testing.T) {
+	if n := FlzCompressLen([]byte{}); n != 0 {
+		t.Fatalf("empty: got %d, want 0", n)
+	}
+	if n := FlzCompressLen([]byte{1, 2, 3, 4, 5}); n != 6 {
+		t.Fatalf("short: got %d, want 6", n)
+	}
+	if n := FlzCompressLen(bytes.Repeat([]byte{1}, 1000)); n >= 100 {
+		t.Fatalf("repeated: got %d, want less than 100", n)
+	}
+}
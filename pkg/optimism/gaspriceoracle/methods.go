@@ -1,7 +1,6 @@
 package gaspriceoracle
 
 import (
-	"errors"
 	"fmt"
 	"math/big"
 
@@ -10,7 +9,9 @@ import (
 )
 
 var (
+	boolT, _    = abi.NewType("bool", "", nil)
 	bytesT, _   = abi.NewType("bytes", "", nil)
+	uint32T, _  = abi.NewType("uint32", "", nil)
 	uint256T, _ = abi.NewType("uint256", "", nil)
 
 	GetL1FeeMethod = abi.NewMethod(
@@ -27,22 +28,85 @@ var (
 			{Name: "fee", Type: uint256T},
 		},
 	)
+
+	IsEcotoneMethod         = newViewMethod("isEcotone", boolT)
+	IsFjordMethod           = newViewMethod("isFjord", boolT)
+	L1BaseFeeMethod         = newViewMethod("l1BaseFee", uint256T)
+	BlobBaseFeeMethod       = newViewMethod("blobBaseFee", uint256T)
+	BaseFeeScalarMethod     = newViewMethod("baseFeeScalar", uint32T)
+	BlobBaseFeeScalarMethod = newViewMethod("blobBaseFeeScalar", uint32T)
+	OverheadMethod          = newViewMethod("overhead", uint256T)
+	ScalarMethod            = newViewMethod("scalar", uint256T)
 )
 
-func DecodeGetL1FeeMethodOutput(out any) (*big.Int, error) {
+func newViewMethod(name string, out abi.Type) abi.Method {
+	return abi.NewMethod(
+		name,
+		name,
+		abi.Function,
+		"view",
+		false,
+		false,
+		abi.Arguments{},
+		abi.Arguments{
+			{Name: "", Type: out},
+		},
+	)
+}
+
+func decodeOutput(method abi.Method, out any) (any, error) {
 	hex, ok := out.(string)
 	if !ok {
-		return nil, errors.New("getL1Fee: cannot assert type: hex is not of type string")
+		return nil, fmt.Errorf("%s: cannot assert type: hex is not of type string", method.Name)
 	}
 	data, err := hexutil.Decode(hex)
 	if err != nil {
-		return nil, fmt.Errorf("getL1Fee: %s", err)
+		return nil, fmt.Errorf("%s: %s", method.Name, err)
+	}
+
+	args, err := method.Outputs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", method.Name, err)
 	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s: invalid args length: expected 1, got %d", method.Name, len(args))
+	}
+	return args[0], nil
+}
 
-	args, err := GetL1FeeMethod.Outputs.Unpack(data)
+func DecodeGetL1FeeMethodOutput(out any) (*big.Int, error) {
+	v, err := decodeOutput(GetL1FeeMethod, out)
 	if err != nil {
-		return nil, fmt.Errorf("getL1Fee: %s", err)
+		return nil, err
 	}
+	return v.(*big.Int), nil
+}
 
-	return args[0].(*big.Int), nil
+// DecodeBoolMethodOutput decodes the output of a view method that returns a single bool.
+func DecodeBoolMethodOutput(method abi.Method, out any) (bool, error) {
+	v, err := decodeOutput(method, out)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s: cannot assert type: output is not of type bool", method.Name)
+	}
+	return b, nil
+}
+
+// DecodeUintMethodOutput decodes the output of a view method that returns a single uint32 or uint256.
+func DecodeUintMethodOutput(method abi.Method, out any) (*big.Int, error) {
+	v, err := decodeOutput(method, out)
+	if err != nil {
+		return nil, err
+	}
+	switch n := v.(type) {
+	case *big.Int:
+		return n, nil
+	case uint32:
+		return big.NewInt(int64(n)), nil
+	default:
+		return nil, fmt.Errorf("%s: cannot assert type: output is not an unsigned integer", method.Name)
+	}
 }
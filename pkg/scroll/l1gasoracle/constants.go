@@ -0,0 +1,7 @@
+package l1gasoracle
+
+import "github.com/ethereum/go-ethereum/common"
+
+var (
+	PrecompileAddress = common.HexToAddress("0x5300000000000000000000000000000000000002")
+)
@@ -0,0 +1,67 @@
+package tenant
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Directory maps API keys to tenants.
+type Directory struct {
+	byKey map[string]*Tenant
+	byID  map[string]*Tenant
+}
+
+// NewDirectory returns an empty Directory.
+func NewDirectory() *Directory {
+	return &Directory{
+		byKey: make(map[string]*Tenant),
+		byID:  make(map[string]*Tenant),
+	}
+}
+
+// Add registers a tenant with its API keys. Tenant IDs and API keys must be unique across the directory.
+func (d *Directory) Add(t *Tenant, apiKeys ...string) error {
+	if t.ID == "" {
+		return fmt.Errorf("tenant: ID is empty")
+	}
+	if _, ok := d.byID[t.ID]; ok {
+		return fmt.Errorf("tenant: %s already exists", t.ID)
+	}
+	if len(apiKeys) == 0 {
+		return fmt.Errorf("tenant: %s has no API keys", t.ID)
+	}
+	for _, key := range apiKeys {
+		if key == "" {
+			return fmt.Errorf("tenant: %s has an empty API key", t.ID)
+		}
+		if other, ok := d.byKey[key]; ok {
+			return fmt.Errorf("tenant: %s has an API key already used by %s", t.ID, other.ID)
+		}
+	}
+
+	d.byID[t.ID] = t
+	for _, key := range apiKeys {
+		d.byKey[key] = t
+	}
+	return nil
+}
+
+// FromAPIKey returns the tenant for an API key or nil if the key is unknown.
+func (d *Directory) FromAPIKey(key string) *Tenant {
+	return d.byKey[key]
+}
+
+// FromID returns the tenant with the given ID or nil if there is none.
+func (d *Directory) FromID(id string) *Tenant {
+	return d.byID[id]
+}
+
+// Tenants returns all tenants sorted by ID.
+func (d *Directory) Tenants() []*Tenant {
+	tenants := []*Tenant{}
+	for _, t := range d.byID {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
@@ -0,0 +1,47 @@
+package tenant
+
+import "testing"
+
+// TestDirectoryAdd calls tenant.Directory.Add with multiple tenants. Expect each API key to resolve to its
+// tenant.
+func TestDirectoryAdd(t *testing.T) {
+	d := NewDirectory()
+	a, b := New("a"), New("b")
+	if err := d.Add(a, "key1", "key2"); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	if err := d.Add(b, "key3"); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	for key, want := range map[string]*Tenant{"key1": a, "key2": a, "key3": b, "key4": nil} {
+		if got := d.FromAPIKey(key); got != want {
+			t.Errorf("%s: got %v, want %v", key, got, want)
+		}
+	}
+	if got := d.Tenants(); len(got) != 2 || got[0] != a || got[1] != b {
+		t.Fatalf("got %v, want [a b]", got)
+	}
+}
+
+// TestDirectoryAddInvalid calls tenant.Directory.Add with duplicate IDs, duplicate keys, and no keys. Expect
+// an error and the directory to be unchanged.
+func TestDirectoryAddInvalid(t *testing.T) {
+	d := NewDirectory()
+	if err := d.Add(New("a"), "key1"); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+
+	if err := d.Add(New("a"), "key2"); err == nil {
+		t.Fatal("duplicate ID: got nil, want err")
+	}
+	if err := d.Add(New("b"), "key2", "key1"); err == nil {
+		t.Fatal("duplicate key: got nil, want err")
+	}
+	if err := d.Add(New("c")); err == nil {
+		t.Fatal("no keys: got nil, want err")
+	}
+	if d.FromAPIKey("key2") != nil || d.FromID("b") != nil {
+		t.Fatal("got partial tenant, want directory unchanged")
+	}
+}
@@ -0,0 +1,13 @@
+package tenant
+
+// Limit defines a token bucket that refills at Rate tokens per second up to a maximum of Burst tokens. A zero
+// Rate means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// IsUnlimited returns true if the limit does not restrict requests.
+func (l Limit) IsUnlimited() bool {
+	return l.Rate <= 0
+}
@@ -0,0 +1,53 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+// DefaultAPIKeyHeader is the request header used to look up the tenant of a request.
+const DefaultAPIKeyHeader = "x-api-key"
+
+// Authenticate returns a Gin middleware that looks up the tenant for the API key in the given header and
+// attaches it to the request context. Requests with a missing or unknown API key are rejected before reaching
+// the jsonrpc.Controller.
+func (d *Directory) Authenticate(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := d.FromAPIKey(c.Request.Header.Get(header))
+		if t == nil {
+			jsonrpc.AbortWithError(c, errors.NewRPCError(errors.UNAUTHORIZED, "invalid or missing API key", nil))
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithContext(c.Request.Context(), t))
+	}
+}
+
+// ClientID identifies the client of an authenticated request by its tenant. This allows rate limits to be
+// shared across all API keys of a tenant.
+func ClientID(c *gin.Context) string {
+	return RateLimitKey(IDFromContext(c.Request.Context()))
+}
+
+// RateLimitKey returns the client ID used by ClientID for a tenant ID.
+func RateLimitKey(id string) string {
+	return "tenant:" + id
+}
+
+// BeforeCall returns a jsonrpc.BeforeCallFunc that rejects methods not allowed for the tenant.
+func BeforeCall() jsonrpc.BeforeCallFunc {
+	return func(c *gin.Context, call *jsonrpc.Call) error {
+		t := FromContext(c.Request.Context())
+		if t == nil || t.IsMethodAllowed(call.Method) {
+			return nil
+		}
+		return errors.NewRPCError(
+			errors.UNAUTHORIZED,
+			fmt.Sprintf("method %s is not allowed", call.Method),
+			nil,
+		)
+	}
+}
@@ -0,0 +1,81 @@
+package tenant
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
+)
+
+func serve(t *testing.T, d *Directory, key string) (int, map[string]any) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", d.Authenticate("x-api-key"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"clientId": ClientID(c)})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	if key != "" {
+		req.Header.Set("x-api-key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+// TestAuthenticate calls the Authenticate middleware with a known API key. Expect the tenant to be attached
+// to the request.
+func TestAuthenticate(t *testing.T) {
+	d := NewDirectory()
+	_ = d.Add(New("acme"), "key1")
+
+	code, body := serve(t, d, "key1")
+	if code != http.StatusOK || body["clientId"] != "tenant:acme" {
+		t.Fatalf("got %d %v, want 200 with tenant:acme", code, body)
+	}
+}
+
+// TestAuthenticateRejected calls the Authenticate middleware with a missing and unknown API key. Expect an
+// UNAUTHORIZED JSON-RPC error.
+func TestAuthenticateRejected(t *testing.T) {
+	d := NewDirectory()
+	_ = d.Add(New("acme"), "key1")
+
+	for _, key := range []string{"", "key2"} {
+		_, body := serve(t, d, key)
+		rpcErr, ok := body["error"].(map[string]any)
+		if !ok || rpcErr["code"] != float64(errors.UNAUTHORIZED) {
+			t.Fatalf("key %q: got %v, want UNAUTHORIZED error", key, body)
+		}
+	}
+}
+
+// TestBeforeCall calls the BeforeCall hook with allowed and disallowed methods. Expect an UNAUTHORIZED error
+// only for the disallowed method.
+func TestBeforeCall(t *testing.T) {
+	tn := New("acme")
+	tn.AllowedMethods = []string{"eth_*"}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Request = c.Request.WithContext(WithContext(c.Request.Context(), tn))
+
+	fn := BeforeCall()
+	if err := fn(c, &jsonrpc.Call{Method: "eth_chainId"}); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	err := fn(c, &jsonrpc.Call{Method: "debug_bundler_clearState"})
+	if rpcErr, ok := err.(*errors.RPCError); !ok || rpcErr.Code() != errors.UNAUTHORIZED {
+		t.Fatalf("got %v, want UNAUTHORIZED error", err)
+	}
+}
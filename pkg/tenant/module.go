@@ -0,0 +1,33 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+// CheckUserOp returns a UserOpHandler that enforces the EntryPoint, sender, and paymaster allowlists of the
+// tenant that submitted the UserOperation. UserOperations without a tenant are not checked.
+func (d *Directory) CheckUserOp() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		t := d.FromID(ctx.TenantID)
+		if t == nil {
+			return nil
+		}
+
+		var err error
+		switch {
+		case !t.IsEntryPointAllowed(ctx.EntryPoint):
+			err = fmt.Errorf("entryPoint %s is not allowed", ctx.EntryPoint)
+		case !t.IsSenderAllowed(ctx.UserOp.Sender):
+			err = fmt.Errorf("sender %s is not allowed", ctx.UserOp.Sender)
+		case !t.IsPaymasterAllowed(ctx.UserOp.GetPaymaster()):
+			err = fmt.Errorf("paymaster %s is not allowed", ctx.UserOp.GetPaymaster())
+		}
+		if err != nil {
+			return errors.NewRPCError(errors.UNAUTHORIZED, err.Error(), err.Error())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package tenant
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+func newDirectory() *Directory {
+	tn := New("acme")
+	tn.AllowedEntryPoints.Add(testutils.ValidAddress1)
+	tn.AllowedSenders.Add(testutils.ValidAddress2)
+
+	d := NewDirectory()
+	_ = d.Add(tn, "key1")
+	return d
+}
+
+// TestCheckUserOp calls tenant.Directory.CheckUserOp with a UserOperation within the tenant's allowlists.
+// Expect nil.
+func TestCheckUserOp(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = testutils.ValidAddress2
+	op.PaymasterAndData = []byte{}
+	ctx := &modules.UserOpHandlerCtx{UserOp: op, EntryPoint: testutils.ValidAddress1, TenantID: "acme"}
+
+	if err := newDirectory().CheckUserOp()(ctx); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
+
+// TestCheckUserOpNotAllowed calls tenant.Directory.CheckUserOp with an EntryPoint and sender outside the
+// tenant's allowlists. Expect an UNAUTHORIZED error for both.
+func TestCheckUserOpNotAllowed(t *testing.T) {
+	fn := newDirectory().CheckUserOp()
+	op := testutils.MockValidInitUserOp()
+	op.Sender = testutils.ValidAddress2
+	op.PaymasterAndData = []byte{}
+
+	for _, ctx := range []*modules.UserOpHandlerCtx{
+		{UserOp: op, EntryPoint: testutils.ValidAddress3, TenantID: "acme"},
+		{UserOp: testutils.MockValidInitUserOp(), EntryPoint: testutils.ValidAddress1, TenantID: "acme"},
+	} {
+		err := fn(ctx)
+		if rpcErr, ok := err.(*errors.RPCError); !ok || rpcErr.Code() != errors.UNAUTHORIZED {
+			t.Fatalf("got %v, want UNAUTHORIZED error", err)
+		}
+	}
+}
+
+// TestCheckUserOpNoTenant calls tenant.Directory.CheckUserOp without a tenant. Expect nil.
+func TestCheckUserOpNoTenant(t *testing.T) {
+	ctx := &modules.UserOpHandlerCtx{UserOp: testutils.MockValidInitUserOp(), EntryPoint: testutils.ValidAddress3}
+	if err := newDirectory().CheckUserOp()(ctx); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+}
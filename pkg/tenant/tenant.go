@@ -0,0 +1,91 @@
+// Package tenant implements API key authentication and per tenant policies for the bundler RPC.
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type contextKey struct{}
+
+// Tenant is a group of API keys with shared policies. Empty allowlists allow any value.
+type Tenant struct {
+	ID                 string
+	AllowedMethods     []string
+	AllowedEntryPoints mapset.Set[common.Address]
+	AllowedSenders     mapset.Set[common.Address]
+	AllowedPaymasters  mapset.Set[common.Address]
+	RateLimit          Limit
+	MethodRateLimits   map[string]Limit
+}
+
+// New returns a Tenant with the given ID and no restrictions.
+func New(id string) *Tenant {
+	return &Tenant{
+		ID:                 id,
+		AllowedMethods:     []string{},
+		AllowedEntryPoints: mapset.NewSet[common.Address](),
+		AllowedSenders:     mapset.NewSet[common.Address](),
+		AllowedPaymasters:  mapset.NewSet[common.Address](),
+		MethodRateLimits:   map[string]Limit{},
+	}
+}
+
+// IsMethodAllowed returns true if the method matches an allowed method. A pattern ending with "*" matches
+// any method with the same prefix.
+func (t *Tenant) IsMethodAllowed(method string) bool {
+	if len(t.AllowedMethods) == 0 {
+		return true
+	}
+
+	for _, pattern := range t.AllowedMethods {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(method, prefix) {
+			return true
+		} else if pattern == method {
+			return true
+		}
+	}
+	return false
+}
+
+func isAllowed(set mapset.Set[common.Address], addr common.Address) bool {
+	return set.Cardinality() == 0 || set.Contains(addr)
+}
+
+// IsEntryPointAllowed returns true if the tenant can use the EntryPoint.
+func (t *Tenant) IsEntryPointAllowed(ep common.Address) bool {
+	return isAllowed(t.AllowedEntryPoints, ep)
+}
+
+// IsSenderAllowed returns true if the tenant can submit UserOperations for the sender.
+func (t *Tenant) IsSenderAllowed(sender common.Address) bool {
+	return isAllowed(t.AllowedSenders, sender)
+}
+
+// IsPaymasterAllowed returns true if the tenant can submit UserOperations using the paymaster. UserOperations
+// without a paymaster are always allowed.
+func (t *Tenant) IsPaymasterAllowed(pm common.Address) bool {
+	return pm == common.HexToAddress("0x") || isAllowed(t.AllowedPaymasters, pm)
+}
+
+// WithContext returns a copy of ctx with the tenant attached.
+func WithContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant attached to ctx or nil if there is none.
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}
+
+// IDFromContext returns the ID of the tenant attached to ctx or an empty string if there is none.
+func IDFromContext(ctx context.Context) string {
+	if t := FromContext(ctx); t != nil {
+		return t.ID
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestIsMethodAllowed calls tenant.IsMethodAllowed with exact and wildcard patterns. Expect only matching
+// methods to be allowed.
+func TestIsMethodAllowed(t *testing.T) {
+	tn := New("acme")
+	if !tn.IsMethodAllowed("debug_bundler_clearState") {
+		t.Fatal("got false with no patterns, want true")
+	}
+
+	tn.AllowedMethods = []string{"eth_*", "web3_clientVersion"}
+	cases := map[string]bool{
+		"eth_sendUserOperation":    true,
+		"eth_chainId":              true,
+		"web3_clientVersion":       true,
+		"web3_sha3":                false,
+		"debug_bundler_clearState": false,
+		"rpc.discover":             false,
+	}
+	for method, want := range cases {
+		if got := tn.IsMethodAllowed(method); got != want {
+			t.Errorf("%s: got %v, want %v", method, got, want)
+		}
+	}
+}
+
+// TestIsPaymasterAllowed calls tenant.IsPaymasterAllowed with an allowlist. Expect UserOperations without a
+// paymaster to always be allowed.
+func TestIsPaymasterAllowed(t *testing.T) {
+	tn := New("acme")
+	tn.AllowedPaymasters.Add(testutils.ValidAddress1)
+
+	if !tn.IsPaymasterAllowed(common.Address{}) {
+		t.Fatal("zero address: got false, want true")
+	}
+	if !tn.IsPaymasterAllowed(testutils.ValidAddress1) {
+		t.Fatal("allowed paymaster: got false, want true")
+	}
+	if tn.IsPaymasterAllowed(testutils.ValidAddress2) {
+		t.Fatal("other paymaster: got true, want false")
+	}
+}
+
+// TestContext calls tenant.WithContext and tenant.FromContext. Expect the same tenant to be returned and no
+// tenant for a context without one.
+func TestContext(t *testing.T) {
+	tn := New("acme")
+	ctx := WithContext(context.Background(), tn)
+	if got := FromContext(ctx); got != tn {
+		t.Fatalf("got %v, want %v", got, tn)
+	}
+	if got := IDFromContext(context.Background()); got != "" {
+		t.Fatalf("got %s, want empty ID", got)
+	}
+}
@@ -1,4 +1,5 @@
-// This is the same BundlerCollectorTracer from github.com/eth-infinitism/bundler transpiled down to ES5.
+// This is the same BundlerCollectorTracer from github.com/eth-infinitism/bundler transpiled down to ES5. It
+// also records the frame where each opcode was first used so that violations can be located.
 
 var tracer = {
   callsFromEntryPoint: [],
@@ -69,6 +70,16 @@ var tracer = {
     if (!list[key]) list[key] = 0;
     list[key] += 1;
   },
+  // count the opcode and record the address and depth of the frame where it was first used
+  countOpcode: function countOpcode(log, opcode) {
+    if (!this.currentLevel.opcodes[opcode]) {
+      this.currentLevel.opcodeFrames[opcode] = {
+        address: toHex(log.contract.getAddress()),
+        depth: log.getDepth(),
+      };
+    }
+    this.countSlot(this.currentLevel.opcodes, opcode);
+  },
   step: function step(log, db) {
     if (this.stopCollecting) {
       return;
@@ -130,6 +141,7 @@ var tracer = {
             topLevelTargetAddress: topLevelTargetAddress,
             access: {},
             opcodes: {},
+            opcodeFrames: {},
             extCodeAccessInfo: {},
             contractSize: {},
           };
@@ -195,7 +207,7 @@ var tracer = {
     // [OP-012]
     if (this.lastOp === "GAS" && !opcode.includes("CALL")) {
       // count "GAS" opcode only if not followed by "CALL"
-      this.countSlot(this.currentLevel.opcodes, "GAS");
+      this.countOpcode(log, "GAS");
     }
     if (opcode !== "GAS") {
       // ignore "unimportant" opcodes:
@@ -204,7 +216,7 @@ var tracer = {
           /^(DUP\d+|PUSH\d+|SWAP\d+|POP|ADD|SUB|MUL|DIV|EQ|LTE?|S?GTE?|SLT|SH[LR]|AND|OR|NOT|ISZERO)$/
         ) == null
       ) {
-        this.countSlot(this.currentLevel.opcodes, opcode);
+        this.countOpcode(log, opcode);
       }
     }
     this.lastOp = opcode;
@@ -1,15 +1,32 @@
 var tracer = {
   reverts: [],
+  revertTargets: [],
   validationOOG: false,
   executionOOG: false,
   executionGasLimit: 0,
+  validationGasUsed: 0,
+  validationGasLimit: 0,
+  factoryGasUsed: 0,
+  accountValidationGasUsed: 0,
+  paymasterValidationGasUsed: 0,
+  postOpGasUsed: 0,
 
   _depth: 0,
   _executionGasStack: [],
+  _validationGasStack: [],
+  _startGas: undefined,
   _defaultGasItem: { used: 0, required: 0 },
   _marker: 0,
   _validationMarker: 1,
   _executionMarker: 3,
+  _frames: [],
+  _targets: [],
+  _gasUsedKeyBySelector: {
+    "0x570e1a36": "factoryGasUsed",
+    "0x3a871cdd": "accountValidationGasUsed",
+    "0xf465c77e": "paymasterValidationGasUsed",
+    "0xa9a23409": "postOpGasUsed",
+  },
   _userOperationEventTopics0:
     "0x49628fd1471006c1482da88028e9ce4dbb080b815c9b0344d39e5a8e6ec1419f",
 
@@ -48,61 +65,108 @@ var tracer = {
   result: function result(ctx, db) {
     return {
       reverts: this.reverts,
+      revertTargets: this.revertTargets,
       validationOOG: this.validationOOG,
       executionOOG: this.executionOOG,
       executionGasLimit: this.executionGasLimit,
+      validationGasUsed: this.validationGasUsed,
+      validationGasLimit: this.validationGasLimit,
+      factoryGasUsed: this.factoryGasUsed,
+      accountValidationGasUsed: this.accountValidationGasUsed,
+      paymasterValidationGasUsed: this.paymasterValidationGasUsed,
+      postOpGasUsed: this.postOpGasUsed,
       userOperationEvent: this.userOperationEvent,
       output: toHex(ctx.output),
       error: ctx.error,
     };
   },
 
+  _getGasStack: function () {
+    if (this._isExecution()) return this._executionGasStack;
+    if (this._marker < this._executionMarker) return this._validationGasStack;
+    return undefined;
+  },
+
+  _enterGasStack: function (stack) {
+    var next = this._depth + 1;
+    if (stack[next] === undefined)
+      stack[next] = Object.assign({}, this._defaultGasItem);
+  },
+
+  _exitGasStack: function (stack, frame) {
+    // Get the final gas item for the nested frame.
+    var nested = Object.assign(
+      {},
+      stack[this._depth + 1] || this._defaultGasItem
+    );
+
+    // Reset the nested gas item to prevent double counting on re-entry.
+    stack[this._depth + 1] = Object.assign({}, this._defaultGasItem);
+
+    // Keep track of the total gas used by all frames at this depth.
+    // This does not account for the gas required due to the 63/64 rule.
+    var used = frame.getGasUsed();
+    stack[this._depth].used += used;
+
+    // Keep track of the total gas required by all frames at this depth.
+    // This accounts for additional gas needed due to the 63/64 rule.
+    stack[this._depth].required +=
+      used - nested.used + Math.ceil((nested.required * 64) / 63);
+
+    return stack[this._depth].required;
+  },
+
   enter: function enter(frame) {
-    if (this._isExecution()) {
-      var next = this._depth + 1;
-      if (this._executionGasStack[next] === undefined)
-        this._executionGasStack[next] = Object.assign({}, this._defaultGasItem);
-    }
+    // Keep track of calls to known entity methods in order to report the gas used by each phase.
+    var selector = toHex(frame.getInput()).slice(0, 10);
+    this._frames.push(this._gasUsedKeyBySelector[selector]);
+    this._targets.push(toHex(frame.getTo()));
+
+    var stack = this._getGasStack();
+    if (stack !== undefined) this._enterGasStack(stack);
   },
   exit: function exit(frame) {
+    var key = this._frames.pop();
+    var target = this._targets.pop();
+    if (key !== undefined) {
+      this[key] = Math.max(this[key], frame.getGasUsed());
+    }
+
     if (this._isExecution()) {
       if (frame.getError() !== undefined) {
         this.reverts.push(toHex(frame.getOutput()));
+        this.revertTargets.push(target);
       }
 
       if (this._depth >= 2) {
-        // Get the final gas item for the nested frame.
-        var nested = Object.assign(
-          {},
-          this._executionGasStack[this._depth + 1] || this._defaultGasItem
-        );
-
-        // Reset the nested gas item to prevent double counting on re-entry.
-        this._executionGasStack[this._depth + 1] = Object.assign(
-          {},
-          this._defaultGasItem
-        );
-
-        // Keep track of the total gas used by all frames at this depth.
-        // This does not account for the gas required due to the 63/64 rule.
-        var used = frame.getGasUsed();
-        this._executionGasStack[this._depth].used += used;
-
-        // Keep track of the total gas required by all frames at this depth.
-        // This accounts for additional gas needed due to the 63/64 rule.
-        this._executionGasStack[this._depth].required +=
-          used - nested.used + Math.ceil((nested.required * 64) / 63);
-
         // Keep track of the final gas limit.
-        this.executionGasLimit = this._executionGasStack[this._depth].required;
+        this.executionGasLimit = this._exitGasStack(
+          this._executionGasStack,
+          frame
+        );
       }
+    } else if (this._marker < this._executionMarker && this._depth >= 2) {
+      this._exitGasStack(this._validationGasStack, frame);
     }
   },
 
+  _setValidationGas: function (log) {
+    // Gas used by the EntryPoint during validation is measured from the start of the trace. Gas required by
+    // calls made from the EntryPoint replaces the gas used by those calls to account for the 63/64 rule.
+    this.validationGasUsed = this._startGas - log.getGas();
+    var calls = this._validationGasStack[2] || this._defaultGasItem;
+    this.validationGasLimit =
+      this.validationGasUsed - calls.used + calls.required;
+  },
+
   step: function step(log, db) {
     var opcode = log.op.toString();
     this._depth = log.getDepth();
-    if (this._depth === 1 && opcode === "NUMBER") this._marker++;
+    if (this._startGas === undefined) this._startGas = log.getGas();
+    if (this._depth === 1 && opcode === "NUMBER") {
+      this._marker++;
+      if (this._marker === this._executionMarker) this._setValidationGas(log);
+    }
 
     if (
       this._depth <= 2 &&
@@ -0,0 +1,196 @@
+// Package local runs debug_traceCall in an embedded EVM. This allows validation tracing against nodes that do
+// not allow custom JS tracers. The state needed for the call is fetched with the node's built-in
+// prestateTracer and the configured tracer is then run in process.
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+
+	// Register the JS tracer evaluator and the native bundler tracers.
+	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
+	_ "github.com/stackup-wallet/stackup-bundler/pkg/tracer/native"
+)
+
+// CallArgs are the transaction fields of a debug_traceCall request.
+type CallArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Gas                  *hexutil.Uint64 `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Data                 hexutil.Bytes   `json:"data"`
+}
+
+// CallConfig is the tracer config of a debug_traceCall request.
+type CallConfig struct {
+	Tracer         string            `json:"tracer"`
+	TracerConfig   json.RawMessage   `json:"tracerConfig,omitempty"`
+	StateOverrides state.OverrideSet `json:"stateOverrides,omitempty"`
+}
+
+// TraceCall is a decoded debug_traceCall request. Only the latest block is supported.
+type TraceCall struct {
+	Args   CallArgs
+	Config CallConfig
+}
+
+// decode converts an RPC argument into the given type through its JSON encoding.
+func decode(arg any, v any) error {
+	b, err := json.Marshal(arg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// ParseTraceCall decodes the method and args given to a backend.TraceCaller.
+func ParseTraceCall(method string, args ...any) (*TraceCall, error) {
+	if method != "debug_traceCall" {
+		return nil, fmt.Errorf("local: unsupported method %s", method)
+	}
+	if len(args) != 3 || args[1] != "latest" {
+		return nil, fmt.Errorf("local: debug_traceCall expects a call, the latest block, and a config")
+	}
+
+	tc := &TraceCall{}
+	if err := decode(args[0], &tc.Args); err != nil {
+		return nil, err
+	}
+	if err := decode(args[2], &tc.Config); err != nil {
+		return nil, err
+	}
+	return tc, nil
+}
+
+// ApplyOverrides sets the accounts in a state override set on statedb.
+func ApplyOverrides(statedb *gethstate.StateDB, os state.OverrideSet) {
+	for addr, acc := range os {
+		if acc.Nonce != nil {
+			statedb.SetNonce(addr, uint64(*acc.Nonce))
+		}
+		if acc.Code != nil {
+			statedb.SetCode(addr, *acc.Code)
+		}
+		if acc.Balance != nil {
+			statedb.SetBalance(addr, acc.Balance.ToInt())
+		}
+		if acc.State != nil {
+			statedb.SetStorage(addr, *acc.State)
+		}
+		if acc.StateDiff != nil {
+			for key, value := range *acc.StateDiff {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	}
+}
+
+func newMessage(args *CallArgs, blockCtx *vm.BlockContext) *core.Message {
+	msg := &core.Message{
+		From:              args.From,
+		To:                args.To,
+		Value:             big.NewInt(0),
+		GasLimit:          blockCtx.GasLimit,
+		GasPrice:          big.NewInt(0),
+		GasFeeCap:         big.NewInt(0),
+		GasTipCap:         big.NewInt(0),
+		Data:              args.Data,
+		SkipAccountChecks: true,
+	}
+	if args.Gas != nil {
+		msg.GasLimit = uint64(*args.Gas)
+	}
+	if args.Value != nil {
+		msg.Value = args.Value.ToInt()
+	}
+	if args.GasPrice != nil {
+		msg.GasPrice = args.GasPrice.ToInt()
+		msg.GasFeeCap, msg.GasTipCap = msg.GasPrice, msg.GasPrice
+	} else if args.MaxFeePerGas != nil {
+		msg.GasFeeCap = args.MaxFeePerGas.ToInt()
+		if args.MaxPriorityFeePerGas != nil {
+			msg.GasTipCap = args.MaxPriorityFeePerGas.ToInt()
+		}
+		msg.GasPrice = msg.GasTipCap
+		if blockCtx.BaseFee != nil {
+			msg.GasPrice = new(big.Int).Add(msg.GasTipCap, blockCtx.BaseFee)
+		}
+		if msg.GasPrice.Cmp(msg.GasFeeCap) > 0 {
+			msg.GasPrice = msg.GasFeeCap
+		}
+	}
+	return msg
+}
+
+// NewBlockContext returns the context for executing a call on top of header. BLOCKHASH is resolved with
+// getHash.
+func NewBlockContext(header *types.Header, getHash vm.GetHashFunc) vm.BlockContext {
+	var random *common.Hash
+	if header.Difficulty == nil || header.Difficulty.Sign() == 0 {
+		random = &header.MixDigest
+	}
+	difficulty := new(big.Int)
+	if header.Difficulty != nil {
+		difficulty.Set(header.Difficulty)
+	}
+	var baseFee *big.Int
+	if header.BaseFee != nil {
+		baseFee = new(big.Int).Set(header.BaseFee)
+	}
+
+	return vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     getHash,
+		Coinbase:    header.Coinbase,
+		BlockNumber: new(big.Int).Set(header.Number),
+		Time:        header.Time,
+		Difficulty:  difficulty,
+		BaseFee:     baseFee,
+		GasLimit:    header.GasLimit,
+		Random:      random,
+	}
+}
+
+// Trace runs the call on statedb with the configured tracer and returns the tracer result. Overrides in the
+// config are applied to statedb first.
+func Trace(
+	statedb *gethstate.StateDB,
+	blockCtx vm.BlockContext,
+	chain *params.ChainConfig,
+	tc *TraceCall,
+) (json.RawMessage, error) {
+	ApplyOverrides(statedb, tc.Config.StateOverrides)
+
+	msg := newMessage(&tc.Args, &blockCtx)
+	tracer, err := tracers.DefaultDirectory.New(tc.Config.Tracer, new(tracers.Context), tc.Config.TracerConfig)
+	if err != nil {
+		return nil, err
+	}
+	evm := vm.NewEVM(
+		blockCtx,
+		core.NewEVMTxContext(msg),
+		statedb,
+		chain,
+		vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true},
+	)
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64)); err != nil {
+		return nil, fmt.Errorf("local: tracing failed: %w", err)
+	}
+	return tracer.GetResult()
+}
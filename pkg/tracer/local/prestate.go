@@ -0,0 +1,43 @@
+package local
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+)
+
+// Account is the state of an account as returned by the prestateTracer.
+type Account struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// Prestate is the result of the prestateTracer. It holds every account and storage slot accessed by a call.
+type Prestate = map[common.Address]*Account
+
+// NewStateDB returns an in-memory StateDB holding only the accounts in prestate.
+func NewStateDB(prestate Prestate) (*gethstate.StateDB, error) {
+	statedb, err := gethstate.New(common.Hash{}, gethstate.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for addr, acc := range prestate {
+		statedb.CreateAccount(addr)
+		if acc.Balance != nil {
+			statedb.SetBalance(addr, acc.Balance.ToInt())
+		}
+		statedb.SetNonce(addr, acc.Nonce)
+		if len(acc.Code) > 0 {
+			statedb.SetCode(addr, acc.Code)
+		}
+		for key, value := range acc.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	statedb.Finalise(true)
+	return statedb, nil
+}
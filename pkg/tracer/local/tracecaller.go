@@ -0,0 +1,97 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+)
+
+const prestateTracer = "prestateTracer"
+
+// TraceCaller implements backend.TraceCaller by running debug_traceCall in an embedded EVM. The state for
+// the call is fetched from the node with the built-in prestateTracer at the latest block.
+//
+// BLOCKHASH returns a zero hash since the opcode is not allowed during validation. Precompiles that are not
+// part of go-ethereum, such as RIP-7212, are not available.
+type TraceCaller struct {
+	rpc   backend.TraceCaller
+	eth   backend.HeaderReader
+	chain *params.ChainConfig
+}
+
+// NewTraceCaller returns a TraceCaller that fetches state through rpc and eth and executes calls with the
+// rules of the given chain config. See ChainConfig.
+func NewTraceCaller(
+	rpc backend.TraceCaller,
+	eth backend.HeaderReader,
+	chain *params.ChainConfig,
+) *TraceCaller {
+	return &TraceCaller{rpc: rpc, eth: eth, chain: chain}
+}
+
+// ChainConfig returns the go-ethereum chain config for a chain ID. Mainnet, Goerli, and Sepolia use their
+// configs with fork times. Other chains, such as L2s, do not have a config in go-ethereum and are assumed to
+// have all forks up to Shanghai enabled.
+func ChainConfig(chainID *big.Int) *params.ChainConfig {
+	for _, known := range []*params.ChainConfig{
+		params.MainnetChainConfig,
+		params.GoerliChainConfig,
+		params.SepoliaChainConfig,
+	} {
+		if known.ChainID.Cmp(chainID) == 0 {
+			return known
+		}
+	}
+
+	chain := *params.AllEthashProtocolChanges
+	chain.ChainID = new(big.Int).Set(chainID)
+	shanghai := uint64(0)
+	chain.ShanghaiTime = &shanghai
+	return &chain
+}
+
+func zeroHash(uint64) common.Hash {
+	return common.Hash{}
+}
+
+// CallContext fetches the prestate of a debug_traceCall request, runs the call locally with the requested
+// tracer, and decodes the tracer result into result.
+func (t *TraceCaller) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	tc, err := ParseTraceCall(method, args...)
+	if err != nil {
+		return err
+	}
+
+	// Pin the block so that the prestate and block context are consistent.
+	header, err := t.eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	var prestate Prestate
+	if err := t.rpc.CallContext(
+		ctx,
+		&prestate,
+		"debug_traceCall",
+		args[0],
+		hexutil.EncodeBig(header.Number),
+		&CallConfig{Tracer: prestateTracer, StateOverrides: tc.Config.StateOverrides},
+	); err != nil {
+		return err
+	}
+
+	statedb, err := NewStateDB(prestate)
+	if err != nil {
+		return err
+	}
+	res, err := Trace(statedb, NewBlockContext(header, vm.GetHashFunc(zeroHash)), t.chain, tc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(res, result)
+}
@@ -0,0 +1,256 @@
+package local_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/backend"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/local"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/native"
+
+	// Register the prestateTracer served by the node.
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+var (
+	// entryPointCode stores a method sig in memory and calls testutils.ValidAddress2 with it.
+	entryPointCode = append(
+		append(
+			common.Hex2Bytes("7f3a871cdd0000000000000000000000000000000000000000000000000000000060005260006000600460006000"),
+			append([]byte{0x73}, testutils.ValidAddress2.Bytes()...)...,
+		),
+		common.Hex2Bytes("5af15000")...,
+	)
+
+	// accountCode reads slot 0, writes slot 1, and emits an empty LOG0.
+	accountCode = common.Hex2Bytes("60005450600160015560006000a000")
+)
+
+// simNode implements tracers.Backend for a simulated backend so that debug_traceCall is served by
+// go-ethereum's own tracing API, as it would be by a node.
+type simNode struct {
+	bc *core.BlockChain
+}
+
+func (n *simNode) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return n.bc.GetHeaderByHash(hash), nil
+}
+
+func (n *simNode) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return n.bc.CurrentBlock(), nil
+	}
+	return n.bc.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (n *simNode) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return n.bc.GetBlockByHash(hash), nil
+}
+
+func (n *simNode) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber {
+		return n.bc.GetBlockByHash(n.bc.CurrentBlock().Hash()), nil
+	}
+	return n.bc.GetBlockByNumber(uint64(number)), nil
+}
+
+func (n *simNode) GetTransaction(
+	ctx context.Context,
+	hash common.Hash,
+) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return nil, common.Hash{}, 0, 0, errors.New("simNode: unsupported")
+}
+
+func (n *simNode) RPCGasCap() uint64 {
+	return n.bc.CurrentBlock().GasLimit
+}
+
+func (n *simNode) ChainConfig() *params.ChainConfig {
+	return n.bc.Config()
+}
+
+func (n *simNode) Engine() consensus.Engine {
+	return n.bc.Engine()
+}
+
+func (n *simNode) ChainDb() ethdb.Database {
+	return nil
+}
+
+func (n *simNode) StateAtBlock(
+	ctx context.Context,
+	block *types.Block,
+	reexec uint64,
+	base *gethstate.StateDB,
+	readOnly bool,
+	preferDisk bool,
+) (*gethstate.StateDB, tracers.StateReleaseFunc, error) {
+	statedb, err := n.bc.StateAt(block.Root())
+	return statedb, func() {}, err
+}
+
+func (n *simNode) StateAtTransaction(
+	ctx context.Context,
+	block *types.Block,
+	txIndex int,
+	reexec uint64,
+) (*core.Message, vm.BlockContext, *gethstate.StateDB, tracers.StateReleaseFunc, error) {
+	return nil, vm.BlockContext{}, nil, nil, errors.New("simNode: unsupported")
+}
+
+// newNode returns an RPC client serving debug_traceCall from the simulated backend.
+func newNode(t *testing.T, sim *backends.SimulatedBackend) *rpc.Client {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("debug", tracers.NewAPI(&simNode{bc: sim.Blockchain()})); err != nil {
+		t.Fatal(err)
+	}
+	c := rpc.DialInProc(srv)
+	t.Cleanup(c.Close)
+	return c
+}
+
+// nodeRpc forwards requests to a node and records them.
+type nodeRpc struct {
+	node   backend.TraceCaller
+	blocks []any
+	opts   []local.CallConfig
+}
+
+func (n *nodeRpc) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	n.blocks = append(n.blocks, args[1])
+	var opts local.CallConfig
+	b, _ := json.Marshal(args[2])
+	_ = json.Unmarshal(b, &opts)
+	n.opts = append(n.opts, opts)
+	return n.node.CallContext(ctx, result, method, args...)
+}
+
+func newSim() *backends.SimulatedBackend {
+	return backends.NewSimulatedBackend(core.GenesisAlloc{
+		testutils.ValidAddress1: {Code: entryPointCode, Balance: big.NewInt(0)},
+		testutils.ValidAddress2: {
+			Code:    accountCode,
+			Balance: big.NewInt(0),
+			Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(7))},
+		},
+		testutils.ValidAddress3: {
+			Balance: big.NewInt(1),
+			Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(1))},
+		},
+	}, 30000000)
+}
+
+func traceValidation(t *testing.T, tc backend.TraceCaller, name string) *tracer.BundlerCollectorReturn {
+	req := utils.TraceCallReq{
+		From:         common.HexToAddress("0x"),
+		To:           testutils.ValidAddress1,
+		MaxFeePerGas: hexutil.Big(*big.NewInt(2000000000)),
+	}
+	opts := utils.TraceCallOpts{
+		Tracer:         name,
+		StateOverrides: state.WithMaxBalanceOverride(common.HexToAddress("0x"), nil),
+	}
+	var res tracer.BundlerCollectorReturn
+	if err := tc.CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts); err != nil {
+		t.Fatalf("got err %v, want nil", err)
+	}
+	return &res
+}
+
+// TestTraceCallerMatchesNode traces a call locally from the prestate and with go-ethereum's debug_traceCall
+// on the simulated node. Expect the same result for both the native and JS collector tracers.
+func TestTraceCallerMatchesNode(t *testing.T) {
+	sim := newSim()
+	defer sim.Close()
+
+	node := newNode(t, sim)
+	tc := local.NewTraceCaller(&nodeRpc{node: node}, sim, sim.Blockchain().Config())
+	for _, name := range []string{native.BundlerCollectorTracerName, tracer.Loaded.BundlerCollectorTracer} {
+		want := traceValidation(t, node, name)
+		got := traceValidation(t, tc, name)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+}
+
+// TestTraceCallerFetchesPrestate traces a call locally. Expect the state to be fetched once with the
+// prestateTracer at the latest block number and storage reads to return the node's values.
+func TestTraceCallerFetchesPrestate(t *testing.T) {
+	sim := newSim()
+	defer sim.Close()
+
+	rpc := &nodeRpc{node: newNode(t, sim)}
+	tc := local.NewTraceCaller(rpc, sim, sim.Blockchain().Config())
+	res := traceValidation(t, tc, native.BundlerCollectorTracerName)
+
+	if len(rpc.opts) != 1 || rpc.opts[0].Tracer != "prestateTracer" {
+		t.Fatalf("got requests %+v, want 1 prestateTracer request", rpc.opts)
+	}
+	if len(rpc.opts[0].StateOverrides) != 1 {
+		t.Fatalf("got overrides %+v, want 1", rpc.opts[0].StateOverrides)
+	}
+	if rpc.blocks[0] != "0x0" {
+		t.Fatalf("got block %v, want 0x0", rpc.blocks[0])
+	}
+	if len(res.CallsFromEntryPoint) != 1 {
+		t.Fatalf("got %d calls from EntryPoint, want 1", len(res.CallsFromEntryPoint))
+	}
+	access := res.CallsFromEntryPoint[0].Access[testutils.ValidAddress2]
+	if access.Reads[common.Hash{}.Hex()] != common.BigToHash(big.NewInt(7)).Hex() {
+		t.Fatalf("got reads %v, want slot 0 = 7", access.Reads)
+	}
+	if len(res.Logs) != 1 {
+		t.Fatalf("got %d logs, want 1", len(res.Logs))
+	}
+}
+
+// TestTraceCallerUnsupportedMethod calls TraceCaller.CallContext with a method other than debug_traceCall.
+// Expect an error.
+func TestTraceCallerUnsupportedMethod(t *testing.T) {
+	sim := newSim()
+	defer sim.Close()
+
+	var res any
+	tc := local.NewTraceCaller(&nodeRpc{node: newNode(t, sim)}, sim, sim.Blockchain().Config())
+	if err := tc.CallContext(context.Background(), &res, "eth_call"); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestChainConfig returns the chain config for a known and an unknown chain ID. Expect the go-ethereum config
+// for mainnet and all forks up to Shanghai enabled for the unknown chain.
+func TestChainConfig(t *testing.T) {
+	if got := local.ChainConfig(big.NewInt(1)); got != params.MainnetChainConfig {
+		t.Fatalf("got %v, want %v", got, params.MainnetChainConfig)
+	}
+
+	chainID := big.NewInt(8453)
+	got := local.ChainConfig(chainID)
+	if got.ChainID.Cmp(chainID) != 0 {
+		t.Fatalf("got chain ID %v, want %v", got.ChainID, chainID)
+	}
+	if !got.IsShanghai(0) {
+		t.Fatal("got Shanghai disabled, want enabled")
+	}
+}
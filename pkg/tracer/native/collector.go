@@ -0,0 +1,529 @@
+// Package native implements the bundler tracers as go-ethereum native tracers. Importing this package
+// registers each tracer with tracers.DefaultDirectory so that it can be selected by name in debug_traceCall.
+// Nodes that do not support JS tracing can compile this package in as a plugin and set the bundler's
+// ERC4337_BUNDLER_NATIVE_BUNDLER_COLLECTOR_TRACER to the tracer name.
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+const (
+	// BundlerCollectorTracerName is the name the native BundlerCollectorTracer is registered under.
+	BundlerCollectorTracerName = "bundlerCollectorTracer"
+
+	// Same limits as the JS tracer for copying memory and return data.
+	memoryPadLimit = 1024 * 1024
+	maxDataLen     = 4000
+)
+
+var (
+	// keccak("BeforeExecution()") is emitted after all validations are done.
+	stopCollectingTopic = "bb47ee3e183a558b1a2ff0874b079f3fc5478b7454eacf2bfc5af2ff5878f972"
+
+	extOpcodeRegex       = regexp.MustCompile(`^(EXT.*)$`)
+	safeExtCodeSizeRegex = regexp.MustCompile(`^(\w+),EXTCODESIZE,ISZERO$`)
+	accessOpcodeRegex    = regexp.MustCompile(`^(EXT.*|CALL|CALLCODE|DELEGATECALL|STATICCALL)$`)
+	ignoredOpcodeRegex   = regexp.MustCompile(
+		`^(DUP\d+|PUSH\d+|SWAP\d+|POP|ADD|SUB|MUL|DIV|EQ|LTE?|S?GTE?|SLT|SH[LR]|AND|OR|NOT|ISZERO)$`,
+	)
+)
+
+func init() {
+	tracers.DefaultDirectory.Register(BundlerCollectorTracerName, newBundlerCollector, false)
+}
+
+type accessInfo struct {
+	Reads  map[string]string `json:"reads"`
+	Writes map[string]int    `json:"writes"`
+}
+
+type contractSizeInfo struct {
+	ContractSize int    `json:"contractSize"`
+	Opcode       string `json:"opcode"`
+}
+
+type opcodeFrame struct {
+	Address string `json:"address"`
+	Depth   int    `json:"depth"`
+}
+
+type callFromEntryPoint struct {
+	TopLevelMethodSig     string                      `json:"topLevelMethodSig"`
+	TopLevelTargetAddress string                      `json:"topLevelTargetAddress"`
+	Access                map[string]*accessInfo      `json:"access"`
+	Opcodes               map[string]int              `json:"opcodes"`
+	OpcodeFrames          map[string]opcodeFrame      `json:"opcodeFrames"`
+	ExtCodeAccessInfo     map[string]string           `json:"extCodeAccessInfo"`
+	ContractSize          map[string]contractSizeInfo `json:"contractSize"`
+	OOG                   bool                        `json:"oog,omitempty"`
+}
+
+// callEntry is either the enter or exit of a call frame. Fields that the JS tracer does not set for a given
+// kind of entry are left out of the JSON encoding.
+type callEntry struct {
+	Type    string  `json:"type"`
+	From    string  `json:"from,omitempty"`
+	To      string  `json:"to,omitempty"`
+	Method  string  `json:"method,omitempty"`
+	Gas     *uint64 `json:"gas,omitempty"`
+	Value   *string `json:"value,omitempty"`
+	GasUsed *uint64 `json:"gasUsed,omitempty"`
+	Data    *string `json:"data,omitempty"`
+}
+
+type logEntry struct {
+	Topics []string `json:"topics"`
+	Data   string   `json:"data"`
+}
+
+type opcodeInfo struct {
+	opcode    string
+	stackTop3 []*big.Int
+}
+
+type bundlerCollectorResult struct {
+	CallsFromEntryPoint []*callFromEntryPoint `json:"callsFromEntryPoint"`
+	Keccak              []string              `json:"keccak"`
+	Logs                []logEntry            `json:"logs"`
+	Calls               []callEntry           `json:"calls"`
+	Debug               []string              `json:"debug"`
+}
+
+// bundlerCollector is a native port of BundlerCollectorTracer.js. It produces the same JSON output so that
+// either can be used with tracer.BundlerCollectorReturn.
+type bundlerCollector struct {
+	env                 *vm.EVM
+	callsFromEntryPoint []*callFromEntryPoint
+	currentLevel        *callFromEntryPoint
+	keccak              []string
+	calls               []callEntry
+	logs                []logEntry
+	debug               []string
+	lastOp              string
+	lastThreeOpcodes    []opcodeInfo
+	stopCollecting      bool
+
+	interrupt atomic.Bool
+	reason    error
+	err       error
+}
+
+func newBundlerCollector(_ *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &bundlerCollector{
+		callsFromEntryPoint: []*callFromEntryPoint{},
+		keccak:              []string{},
+		calls:               []callEntry{},
+		logs:                []logEntry{},
+		debug:               []string{},
+		lastThreeOpcodes:    []opcodeInfo{},
+	}, nil
+}
+
+// memorySlice returns a copy of memory in the range [begin, end) padded with zeros past the current size.
+func memorySlice(mem *vm.Memory, begin, end int64) ([]byte, error) {
+	if end == begin {
+		return []byte{}, nil
+	}
+	if end < begin || begin < 0 {
+		return nil, fmt.Errorf("tracer accessed out of bound memory: offset %d, end %d", begin, end)
+	}
+	mlen := int64(mem.Len())
+	if end-mlen > memoryPadLimit {
+		return nil, fmt.Errorf("tracer reached limit for padding memory slice: end %d, memorySize %d", end, mlen)
+	}
+	slice := make([]byte, end-begin)
+	if begin < mlen {
+		copy(slice, mem.Data()[begin:min(end, mlen)])
+	}
+	return slice, nil
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// peek returns the nth-from-the-top element of the stack.
+func peek(stack *vm.Stack, n int) (*big.Int, error) {
+	if len(stack.Data()) <= n {
+		return nil, fmt.Errorf("tracer accessed out of bound stack: size %d, index %d", len(stack.Data()), n)
+	}
+	return stack.Back(n).ToBig(), nil
+}
+
+// peekInt64 returns the nth-from-the-top element of the stack as an offset or length into memory.
+func peekInt64(stack *vm.Stack, n int) (int64, error) {
+	v, err := peek(stack, n)
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("tracer accessed out of bound memory: offset %s", v)
+	}
+	return v.Int64(), nil
+}
+
+func toAddressHex(v *big.Int) string {
+	return strings.ToLower(common.BigToAddress(v).Hex())
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// isAllowedPrecompiled only allows the precompiles defined by ERC-4337 as stateless [OP-062].
+func isAllowedPrecompiled(addr common.Address) bool {
+	v := new(big.Int).SetBytes(addr.Bytes())
+	return v.Sign() > 0 && v.Cmp(big.NewInt(10)) < 0
+}
+
+func (t *bundlerCollector) CaptureTxStart(gasLimit uint64) {}
+
+func (t *bundlerCollector) CaptureTxEnd(restGas uint64) {}
+
+func (t *bundlerCollector) CaptureStart(
+	env *vm.EVM,
+	from common.Address,
+	to common.Address,
+	create bool,
+	input []byte,
+	gas uint64,
+	value *big.Int,
+) {
+	t.env = env
+}
+
+func (t *bundlerCollector) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (t *bundlerCollector) CaptureEnter(
+	typ vm.OpCode,
+	from common.Address,
+	to common.Address,
+	input []byte,
+	gas uint64,
+	value *big.Int,
+) {
+	if t.err != nil || t.stopCollecting {
+		return
+	}
+
+	var v *string
+	if value != nil {
+		s := value.String()
+		v = &s
+	}
+	t.calls = append(t.calls, callEntry{
+		Type:   typ.String(),
+		From:   strings.ToLower(from.Hex()),
+		To:     strings.ToLower(to.Hex()),
+		Method: truncate(hexutil.Encode(input), 10),
+		Gas:    &gas,
+		Value:  v,
+	})
+}
+
+func (t *bundlerCollector) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.err != nil || t.stopCollecting {
+		return
+	}
+
+	typ := "RETURN"
+	if err != nil {
+		typ = "REVERT"
+	}
+	data := truncate(hexutil.Encode(output), maxDataLen)
+	t.calls = append(t.calls, callEntry{Type: typ, GasUsed: &gasUsed, Data: &data})
+}
+
+func (t *bundlerCollector) CaptureFault(
+	pc uint64,
+	op vm.OpCode,
+	gas, cost uint64,
+	scope *vm.ScopeContext,
+	depth int,
+	err error,
+) {
+	if t.err != nil {
+		return
+	}
+	// The JS tracer loader strips whitespace around non-word characters, which removes the spaces from this
+	// message. They are left out here as well to produce the same output.
+	t.debug = append(t.debug, fmt.Sprintf("fault depth=%dgas=%dcost=%derr=%s", depth, gas, cost, err))
+}
+
+func (t *bundlerCollector) CaptureState(
+	pc uint64,
+	op vm.OpCode,
+	gas, cost uint64,
+	scope *vm.ScopeContext,
+	rData []byte,
+	depth int,
+	err error,
+) {
+	if t.err != nil || t.stopCollecting || t.interrupt.Load() {
+		return
+	}
+	if err := t.step(op.String(), gas, cost, scope, depth); err != nil {
+		t.err = err
+		t.env.Cancel()
+	}
+}
+
+// step follows the step function of BundlerCollectorTracer.js line by line.
+func (t *bundlerCollector) step(opcode string, gas, cost uint64, scope *vm.ScopeContext, depth int) error {
+	stack := scope.Stack
+	stackTop3 := []*big.Int{}
+	for i := 0; i < 3 && i < len(stack.Data()); i++ {
+		stackTop3 = append(stackTop3, stack.Back(i).ToBig())
+	}
+	t.lastThreeOpcodes = append(t.lastThreeOpcodes, opcodeInfo{opcode: opcode, stackTop3: stackTop3})
+	if len(t.lastThreeOpcodes) > 3 {
+		t.lastThreeOpcodes = t.lastThreeOpcodes[1:]
+	}
+
+	if gas < cost || (opcode == "SSTORE" && gas < 2300) {
+		if t.currentLevel == nil {
+			return errors.New("tracer: out of gas before any call from the EntryPoint")
+		}
+		t.currentLevel.OOG = true
+	}
+
+	if opcode == "REVERT" || opcode == "RETURN" {
+		if depth == 1 {
+			// CaptureExit is not called on a top-level return or revert, so it is reconstructed from the opcode.
+			ofs, err := peekInt64(stack, 0)
+			if err != nil {
+				return err
+			}
+			l, err := peekInt64(stack, 1)
+			if err != nil {
+				return err
+			}
+			mem, err := memorySlice(scope.Memory, ofs, ofs+l)
+			if err != nil {
+				return err
+			}
+			gasUsed := uint64(0)
+			data := truncate(hexutil.Encode(mem), maxDataLen)
+			t.calls = append(t.calls, callEntry{Type: opcode, GasUsed: &gasUsed, Data: &data})
+		}
+		// Flush all history after a RETURN.
+		t.lastThreeOpcodes = []opcodeInfo{}
+	}
+
+	if depth == 1 {
+		if opcode == "CALL" || opcode == "STATICCALL" {
+			addr, err := peek(stack, 1)
+			if err != nil {
+				return err
+			}
+			ofs, err := peekInt64(stack, 3)
+			if err != nil {
+				return err
+			}
+			sig, err := memorySlice(scope.Memory, ofs, ofs+4)
+			if err != nil {
+				return err
+			}
+
+			t.currentLevel = &callFromEntryPoint{
+				TopLevelMethodSig:     hexutil.Encode(sig),
+				TopLevelTargetAddress: toAddressHex(addr),
+				Access:                map[string]*accessInfo{},
+				Opcodes:               map[string]int{},
+				OpcodeFrames:          map[string]opcodeFrame{},
+				ExtCodeAccessInfo:     map[string]string{},
+				ContractSize:          map[string]contractSizeInfo{},
+			}
+			t.callsFromEntryPoint = append(t.callsFromEntryPoint, t.currentLevel)
+		} else if opcode == "LOG1" {
+			topic, err := peek(stack, 2)
+			if err != nil {
+				return err
+			}
+			if topic.Text(16) == stopCollectingTopic {
+				t.stopCollecting = true
+			}
+		}
+		t.lastOp = ""
+		return nil
+	}
+
+	if t.currentLevel == nil {
+		return fmt.Errorf("tracer: %s at depth %d before any call from the EntryPoint", opcode, depth)
+	}
+
+	// Store all addresses touched by EXTCODE* opcodes.
+	if len(t.lastThreeOpcodes) >= 2 {
+		lastOpInfo := t.lastThreeOpcodes[len(t.lastThreeOpcodes)-2]
+		if extOpcodeRegex.MatchString(lastOpInfo.opcode) {
+			if len(lastOpInfo.stackTop3) == 0 {
+				return fmt.Errorf("tracer: %s with an empty stack", lastOpInfo.opcode)
+			}
+			addrHex := toAddressHex(lastOpInfo.stackTop3[0])
+			ops := []string{}
+			for _, info := range t.lastThreeOpcodes {
+				ops = append(ops, info.opcode)
+			}
+			// Only store the last EXTCODE* opcode per address [OP-051].
+			if !safeExtCodeSizeRegex.MatchString(strings.Join(ops, ",")) {
+				t.currentLevel.ExtCodeAccessInfo[addrHex] = opcode
+			}
+		}
+	}
+
+	// [OP-041]
+	if accessOpcodeRegex.MatchString(opcode) {
+		idx := 1
+		if strings.HasPrefix(opcode, "EXT") {
+			idx = 0
+		}
+		v, err := peek(stack, idx)
+		if err != nil {
+			return err
+		}
+		addr := common.BigToAddress(v)
+		addrHex := toAddressHex(v)
+		if _, ok := t.currentLevel.ContractSize[addrHex]; !ok && !isAllowedPrecompiled(addr) {
+			t.currentLevel.ContractSize[addrHex] = contractSizeInfo{
+				ContractSize: len(t.env.StateDB.GetCode(addr)),
+				Opcode:       opcode,
+			}
+		}
+	}
+
+	// Count the GAS opcode only if it is not followed by a CALL [OP-012].
+	if t.lastOp == "GAS" && !strings.Contains(opcode, "CALL") {
+		t.countOpcode("GAS", scope, depth)
+	}
+	if opcode != "GAS" && !ignoredOpcodeRegex.MatchString(opcode) {
+		t.countOpcode(opcode, scope, depth)
+	}
+	t.lastOp = opcode
+
+	if opcode == "SLOAD" || opcode == "SSTORE" {
+		v, err := peek(stack, 0)
+		if err != nil {
+			return err
+		}
+		slot := common.BigToHash(v)
+		slotHex := slot.Hex()
+		addr := scope.Contract.Address()
+		addrHex := strings.ToLower(addr.Hex())
+		access, ok := t.currentLevel.Access[addrHex]
+		if !ok {
+			access = &accessInfo{Reads: map[string]string{}, Writes: map[string]int{}}
+			t.currentLevel.Access[addrHex] = access
+		}
+		if opcode == "SLOAD" {
+			// Read slot values before this UserOperation was created, so skip slots written before the
+			// first read.
+			_, read := access.Reads[slotHex]
+			_, written := access.Writes[slotHex]
+			if !read && !written {
+				access.Reads[slotHex] = t.env.StateDB.GetState(addr, slot).Hex()
+			}
+		} else {
+			access.Writes[slotHex]++
+		}
+	}
+
+	if opcode == "KECCAK256" {
+		ofs, err := peekInt64(stack, 0)
+		if err != nil {
+			return err
+		}
+		l, err := peekInt64(stack, 1)
+		if err != nil {
+			return err
+		}
+		// Solidity only uses 2 words for a mapping key so there is no need to return more.
+		if l > 20 && l < 512 {
+			mem, err := memorySlice(scope.Memory, ofs, ofs+l)
+			if err != nil {
+				return err
+			}
+			t.keccak = append(t.keccak, hexutil.Encode(mem))
+		}
+	} else if strings.HasPrefix(opcode, "LOG") {
+		count, err := strconv.Atoi(opcode[3:])
+		if err != nil {
+			return err
+		}
+		ofs, err := peekInt64(stack, 0)
+		if err != nil {
+			return err
+		}
+		l, err := peekInt64(stack, 1)
+		if err != nil {
+			return err
+		}
+		topics := []string{}
+		for i := 0; i < count; i++ {
+			topic, err := peek(stack, 2+i)
+			if err != nil {
+				return err
+			}
+			topics = append(topics, "0x"+topic.Text(16))
+		}
+		mem, err := memorySlice(scope.Memory, ofs, ofs+l)
+		if err != nil {
+			return err
+		}
+		t.logs = append(t.logs, logEntry{Topics: topics, Data: hexutil.Encode(mem)})
+	}
+	return nil
+}
+
+// countOpcode counts the opcode and records the address and depth of the frame where it was first used.
+func (t *bundlerCollector) countOpcode(opcode string, scope *vm.ScopeContext, depth int) {
+	if _, ok := t.currentLevel.Opcodes[opcode]; !ok {
+		t.currentLevel.OpcodeFrames[opcode] = opcodeFrame{
+			Address: strings.ToLower(scope.Contract.Address().Hex()),
+			Depth:   depth,
+		}
+	}
+	t.currentLevel.Opcodes[opcode]++
+}
+
+// GetResult returns the collected data in the same format as BundlerCollectorTracer.js.
+func (t *bundlerCollector) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(bundlerCollectorResult{
+		CallsFromEntryPoint: t.callsFromEntryPoint,
+		Keccak:              t.keccak,
+		Logs:                t.logs,
+		Calls:               t.calls,
+		Debug:               t.debug,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if t.err != nil {
+		return res, t.err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *bundlerCollector) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -0,0 +1,257 @@
+package native_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/stackup-wallet/stackup-bundler/internal/e2e"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/state"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/local"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer/native"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+type fixture struct {
+	Alloc  core.GenesisAlloc `json:"alloc"`
+	Call   json.RawMessage   `json:"call"`
+	Result json.RawMessage   `json:"result"`
+}
+
+type traceOpts struct {
+	Tracer string `json:"tracer"`
+}
+
+// readFixtures returns every fixture in the testdata directory keyed by name.
+func readFixtures(t *testing.T) map[string]*fixture {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := map[string]*fixture{}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		var f fixture
+		testutils.ReadFixture(t, name, &f)
+		fixtures[name] = &f
+	}
+	return fixtures
+}
+
+func traceFixture(t *testing.T, f *fixture, name string) json.RawMessage {
+	sim := backends.NewSimulatedBackend(f.Alloc, 30000000)
+	defer sim.Close()
+
+	var res json.RawMessage
+	err := e2e.NewTraceCaller(sim).CallContext(
+		context.Background(),
+		&res,
+		"debug_traceCall",
+		f.Call,
+		"latest",
+		traceOpts{Tracer: name},
+	)
+	if err != nil {
+		t.Fatalf("%s: got err %v, want nil", name, err)
+	}
+	return res
+}
+
+func requireJSONEqual(t *testing.T, got, want json.RawMessage) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+// simulateValidationCall returns the EntryPoint.simulateValidation call made for op during validation.
+func simulateValidationCall(t *testing.T, h *e2e.Harness, op *userop.UserOperation) *local.CallArgs {
+	t.Helper()
+	epAbi, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := epAbi.Pack("simulateValidation", entrypoint.UserOperation(*op))
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxFee := hexutil.Big(*op.MaxFeePerGas)
+	return &local.CallArgs{To: &h.EntryPoint, Data: data, MaxFeePerGas: &maxFee}
+}
+
+// validationCalls returns simulateValidation calls for UserOperations that deploy an account through the
+// factory, are sponsored by the paymaster, and access the storage of a deployed account.
+func validationCalls(t *testing.T, h *e2e.Harness) map[string]*local.CallArgs {
+	t.Helper()
+	head, err := h.Sim.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip := big.NewInt(1000000000)
+	maxFee := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+
+	deployed := h.NewAccount()
+	h.Fund(deployed.Address, testutils.OneETH)
+	op := deployed.UserOp(big.NewInt(0), []byte{}, maxFee, tip)
+	deployed.Sign(op)
+	if _, err := h.SendUserOperation(op); err != nil {
+		t.Fatal(err)
+	}
+	h.Bundle()
+	if !deployed.IsDeployed() {
+		t.Fatal("got account not deployed, want deployed")
+	}
+
+	factory := h.NewAccount()
+	h.Fund(factory.Address, testutils.OneETH)
+	factoryOp := factory.UserOp(big.NewInt(0), []byte{}, maxFee, tip)
+	factory.Sign(factoryOp)
+
+	sponsored := h.NewAccount()
+	paymasterOp := sponsored.UserOp(big.NewInt(0), []byte{}, maxFee, tip)
+	h.Sponsor(paymasterOp, 0, 0)
+	sponsored.Sign(paymasterOp)
+
+	storageOp := deployed.UserOp(big.NewInt(1), []byte{}, maxFee, tip)
+	deployed.Sign(storageOp)
+
+	return map[string]*local.CallArgs{
+		"factory":   simulateValidationCall(t, h, factoryOp),
+		"paymaster": simulateValidationCall(t, h, paymasterOp),
+		"storage":   simulateValidationCall(t, h, storageOp),
+	}
+}
+
+// traceValidation traces a simulateValidation call against the harness chain with the same state overrides
+// used during validation.
+func traceValidation(t *testing.T, h *e2e.Harness, call *local.CallArgs, name string) json.RawMessage {
+	t.Helper()
+	var res json.RawMessage
+	err := e2e.NewTraceCaller(h.Sim).CallContext(
+		context.Background(),
+		&res,
+		"debug_traceCall",
+		call,
+		"latest",
+		local.CallConfig{Tracer: name, StateOverrides: state.WithMaxBalanceOverride(common.Address{}, nil)},
+	)
+	if err != nil {
+		t.Fatalf("%s: got err %v, want nil", name, err)
+	}
+	return res
+}
+
+// TestRecordValidationFixtures records a fixture for each call in validationCalls. The alloc is the
+// prestate of the call and the result is recorded with the JS tracer. Run with -record.
+func TestRecordValidationFixtures(t *testing.T) {
+	testutils.SkipUnlessRecording(t)
+
+	h := e2e.New(t)
+	for name, call := range validationCalls(t, h) {
+		var prestate local.Prestate
+		if err := json.Unmarshal(traceValidation(t, h, call, "prestateTracer"), &prestate); err != nil {
+			t.Fatal(err)
+		}
+		alloc := core.GenesisAlloc{}
+		for addr, acc := range prestate {
+			bal := big.NewInt(0)
+			if acc.Balance != nil {
+				bal = acc.Balance.ToInt()
+			}
+			alloc[addr] = core.GenesisAccount{Code: acc.Code, Storage: acc.Storage, Balance: bal, Nonce: acc.Nonce}
+		}
+		c, err := json.Marshal(call)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f := &fixture{Alloc: alloc, Call: c}
+		f.Result = traceFixture(t, f, tracer.Loaded.BundlerCollectorTracer)
+		testutils.WriteFixture(t, name, f)
+	}
+}
+
+// TestBundlerCollectorValidations traces real validations against the e2e harness with the native tracer and
+// the JS tracer. The harness deploys the contracts from its embedded artifacts and fails if any is missing.
+// Expect both results to be equal.
+func TestBundlerCollectorValidations(t *testing.T) {
+	h := e2e.New(t)
+	for name, call := range validationCalls(t, h) {
+		t.Run(name, func(t *testing.T) {
+			js := traceValidation(t, h, call, tracer.Loaded.BundlerCollectorTracer)
+			requireJSONEqual(t, traceValidation(t, h, call, native.BundlerCollectorTracerName), js)
+		})
+	}
+}
+
+// TestBundlerCollectorFixtures traces each recorded fixture with the native tracer and the JS tracer. Expect
+// both results to equal the recorded result. Run with -record to record results from the JS tracer.
+func TestBundlerCollectorFixtures(t *testing.T) {
+	for name, f := range readFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			js := traceFixture(t, f, tracer.Loaded.BundlerCollectorTracer)
+			if testutils.Recording() {
+				f.Result = js
+				testutils.WriteFixture(t, name, f)
+			}
+
+			requireJSONEqual(t, js, f.Result)
+			requireJSONEqual(t, traceFixture(t, f, native.BundlerCollectorTracerName), f.Result)
+		})
+	}
+}
+
+// TestBundlerCollectorReturn decodes the native tracer result of a fixture into a BundlerCollectorReturn.
+// Expect collected calls, opcode frames, storage access, and the stop at BeforeExecution.
+func TestBundlerCollectorReturn(t *testing.T) {
+	f := readFixtures(t)["validation"]
+	var res tracer.BundlerCollectorReturn
+	if err := json.Unmarshal(traceFixture(t, f, native.BundlerCollectorTracerName), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.CallsFromEntryPoint) != 2 {
+		t.Fatalf("got %d calls from EntryPoint, want 2", len(res.CallsFromEntryPoint))
+	}
+	account := res.CallsFromEntryPoint[0]
+	if hexutil.Encode(account.TopLevelMethodSig) != "0x3a871cdd" {
+		t.Fatalf("got method sig %s, want 0x3a871cdd", account.TopLevelMethodSig)
+	}
+	for _, op := range []string{"GAS", "TIMESTAMP", "SLOAD", "SSTORE", "KECCAK256", "LOG2"} {
+		if _, ok := account.Opcodes[op]; !ok {
+			t.Fatalf("got opcodes %v, want %s", account.Opcodes, op)
+		}
+	}
+	revert := account.OpcodeFrames["REVERT"]
+	if revert.Address != common.HexToAddress("0x73570000000000000000000000000000000000c1") || revert.Depth != 3 {
+		t.Fatalf("got REVERT frame %+v, want 0x73570000000000000000000000000000000000c1 at depth 3", revert)
+	}
+	access, ok := account.Access[account.TopLevelTargetAddress]
+	if !ok {
+		t.Fatalf("got access %v, want %s", account.Access, account.TopLevelTargetAddress)
+	}
+	if access.Reads[common.Hash{}.Hex()] != common.BigToHash(big.NewInt(7)).Hex() {
+		t.Fatalf("got reads %v, want slot 0 = 7", access.Reads)
+	}
+	if len(res.Keccak) != 1 || len(res.Logs) != 1 {
+		t.Fatalf("got %d keccak and %d logs, want 1 and 1", len(res.Keccak), len(res.Logs))
+	}
+}
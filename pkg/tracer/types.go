@@ -27,12 +27,20 @@ type ContractSizeMap map[common.Address]ContractSizeInfo
 // ExtCodeAccessInfoMap provides context on potentially illegal use of EXTCODESIZE.
 type ExtCodeAccessInfoMap map[common.Address]string
 
+// OpcodeFrame is the call frame where an opcode was first used.
+type OpcodeFrame struct {
+	Address common.Address `json:"address"`
+	Depth   int            `json:"depth"`
+}
+type OpcodeFrameMap map[string]OpcodeFrame
+
 // CallFromEntryPoint provides context on opcodes and storage access made via the EntryPoint to UserOperation
 // entities.
 type CallFromEntryPointInfo struct {
 	TopLevelMethodSig     hexutil.Bytes        `json:"topLevelMethodSig"`
 	TopLevelTargetAddress common.Address       `json:"topLevelTargetAddress"`
 	Opcodes               Counts               `json:"opcodes"`
+	OpcodeFrames          OpcodeFrameMap       `json:"opcodeFrames"`
 	Access                AccessMap            `json:"access"`
 	ContractSize          ContractSizeMap      `json:"contractSize"`
 	ExtCodeAccessInfo     ExtCodeAccessInfoMap `json:"extCodeAccessInfo"`
@@ -74,10 +82,22 @@ type BundlerCollectorReturn struct {
 // BundlerExecutionReturn is the return value from performing an EVM trace with BundlerExecutionTracer.js.
 type BundlerExecutionReturn struct {
 	Reverts            []string `json:"reverts"`
+	RevertTargets      []string `json:"revertTargets"`
 	ValidationOOG      bool     `json:"validationOOG"`
 	ExecutionOOG       bool     `json:"executionOOG"`
 	ExecutionGasLimit  float64  `json:"executionGasLimit"`
 	UserOperationEvent *LogInfo `json:"userOperationEvent,omitempty"`
 	Output             string   `json:"output"`
 	Error              string   `json:"error"`
+
+	// Gas used and required by the validation phase. The limit accounts for the 63/64 rule on calls made from
+	// the EntryPoint.
+	ValidationGasUsed  float64 `json:"validationGasUsed"`
+	ValidationGasLimit float64 `json:"validationGasLimit"`
+
+	// Gas used by each entity method during simulation. These are 0 if the method was not called.
+	FactoryGasUsed             float64 `json:"factoryGasUsed"`
+	AccountValidationGasUsed   float64 `json:"accountValidationGasUsed"`
+	PaymasterValidationGasUsed float64 `json:"paymasterValidationGasUsed"`
+	PostOpGasUsed              float64 `json:"postOpGasUsed"`
 }
@@ -229,6 +229,30 @@ func (op *UserOperation) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// UnmarshalJSON decodes a JSON object into the UserOperation with the same rules as New.
+func (op *UserOperation) UnmarshalJSON(data []byte) error {
+	var opData map[string]any
+	if err := json.Unmarshal(data, &opData); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadUserOperationData, err)
+	}
+
+	parsed, err := New(opData)
+	if err != nil {
+		return err
+	}
+	*op = *parsed
+	return nil
+}
+
+// JSONSchema describes the JSON encoding of a UserOperation where every field is a hex string.
+func (op *UserOperation) JSONSchema() map[string]any {
+	props := map[string]any{}
+	for _, p := range UserOpPrimitives {
+		props[p.Name] = map[string]any{"type": "string"}
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
 // ToMap returns the current UserOp struct as a map type.
 func (op *UserOperation) ToMap() (map[string]any, error) {
 	data, err := op.MarshalJSON()
@@ -1,10 +1,13 @@
 package userop_test
 
 import (
+	"encoding/json"
+	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // TestUserOperationGetDynamicGasPrice verifies that (*UserOperation).GetDynamicGasPrice returns the correct
@@ -48,3 +51,31 @@ func TestUserOperationGetGasPriceNilBF(t *testing.T) {
 		t.Fatalf("got %d, want %d", op.GetDynamicGasPrice(nil).Int64(), op.MaxPriorityFeePerGas)
 	}
 }
+
+// TestUserOperationUnmarshalJSON decodes the JSON encoding of a UserOperation. Expect the same
+// UserOperation.
+func TestUserOperationUnmarshalJSON(t *testing.T) {
+	want := testutils.MockValidInitUserOp()
+	data, err := want.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got userop.UserOperation
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !testutils.IsOpsEqual(&got, want) {
+		t.Fatalf("got %v, want %v", &got, want)
+	}
+}
+
+// TestUserOperationUnmarshalJSONInvalid decodes a UserOperation with a missing field. Expect
+// ErrBadUserOperationData.
+func TestUserOperationUnmarshalJSONInvalid(t *testing.T) {
+	var op userop.UserOperation
+	err := json.Unmarshal([]byte(`{"sender":"0x0000000000000000000000000000000000000001"}`), &op)
+	if !errors.Is(err, userop.ErrBadUserOperationData) {
+		t.Fatalf("got %v, want %v", err, userop.ErrBadUserOperationData)
+	}
+}